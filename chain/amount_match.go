@@ -5,7 +5,7 @@ import (
     "sort"
 
     "github.com/ethereum/go-ethereum/common"
-    plasma_common "github.com/kyokan/plasma/common"
+    "github.com/kyokan/plasma/signer"
     "github.com/pkg/errors"
 )
 
@@ -14,8 +14,25 @@ type OutputSortHelper struct {
     Amount    *big.Int
 }
 
-// FindBestUTXOs Finds (at most two) UXTOs to match an amount.
-func FindBestUTXOs(from, to common.Address, amount *big.Int, txs []Transaction, client plasma_common.Client) (*Transaction, error) {
+// FindBestUTXOs Finds (at most two) UXTOs of the signer's account to match an amount.
+// Multisig and locked outputs paid to the account are not spent.
+func FindBestUTXOs(to common.Address, amount *big.Int, txs []Transaction, s signer.Signer, domain Domain) (*Transaction, error) {
+    from := s.Address()
+    var candidates []Transaction
+    for _, tx := range txs {
+        if output := tx.OutputFor(&from); !output.IsMultisig() && !output.IsLocked() && !output.IsToken() {
+            candidates = append(candidates, tx)
+        }
+    }
+    utxoTxs, err := SelectUTXOs(from, amount, candidates)
+    if err != nil {
+        return nil, err
+    }
+    return PrepareSendTransaction(to, amount, utxoTxs, s, domain)
+}
+
+// SelectUTXOs selects (at most two) of from's UTXOs to match an amount.
+func SelectUTXOs(from common.Address, amount *big.Int, txs []Transaction) ([]Transaction, error) {
     if len(txs) == 0 {
         return nil, errors.New("no suitable UTXOs found")
     }
@@ -24,7 +41,7 @@ func FindBestUTXOs(from, to common.Address, amount *big.Int, txs []Transaction,
         output := tx.OutputFor(&from) // this call may panic
         if amount.Cmp(output.Amount) == 0 {
             // Found exact match
-            return PrepareSendTransaction(from, to, amount, []Transaction{txs[pos]}, client)
+            return []Transaction{txs[pos]}, nil
         }
         outputs = append(outputs, OutputSortHelper{Position: pos, Amount: output.Amount})
     }
@@ -37,7 +54,7 @@ func FindBestUTXOs(from, to common.Address, amount *big.Int, txs []Transaction,
     // Amount is less the minimum element, no need to do anything else
     min := outputs[0]
     if min.Amount.Cmp(amount) == 1 { // min > amount
-        return PrepareSendTransaction(from, to, amount, []Transaction{txs[min.Position]}, client)
+        return []Transaction{txs[min.Position]}, nil
     }
     leftBound := int(0)
     rightBound := len(outputs) - 1
@@ -62,17 +79,32 @@ func FindBestUTXOs(from, to common.Address, amount *big.Int, txs []Transaction,
     if leftBound < rightBound { // Found two outputs that sum up to amount
         first := outputs[leftBound].Position
         second := outputs[rightBound].Position
-        return PrepareSendTransaction(from, to, amount, []Transaction{txs[first], txs[second]}, client)
+        return []Transaction{txs[first], txs[second]}, nil
     }
     if lhs >= 0 && rhs >= 0 { // smallest sum that's greater than amount
         first := outputs[lhs].Position
         second := outputs[rhs].Position
-        return PrepareSendTransaction(from, to, amount, []Transaction{txs[first], txs[second]}, client)
+        return []Transaction{txs[first], txs[second]}, nil
     }
     return nil, errors.New("no suitable UTXOs found")
 }
 
-func PrepareSendTransaction(from, to common.Address, amount *big.Int, utxoTxs []Transaction, client plasma_common.Client) (*Transaction, error) {
+// PrepareSendTransaction spends the signer's outputs in utxoTxs, paying
+// amount to to, and signs the result for domain.
+func PrepareSendTransaction(to common.Address, amount *big.Int, utxoTxs []Transaction, s signer.Signer, domain Domain) (*Transaction, error) {
+    tx, err := BuildSendTransaction(s.Address(), to, amount, utxoTxs)
+    if err != nil {
+        return nil, err
+    }
+    if err := tx.Sign(s, domain); err != nil {
+        return nil, err
+    }
+    return tx, nil
+}
+
+// BuildSendTransaction spends from's outputs in utxoTxs, paying amount to to
+// and the change back to from. The transaction is not signed.
+func BuildSendTransaction(from, to common.Address, amount *big.Int, utxoTxs []Transaction) (*Transaction, error) {
     var input1 *Input
     var output1 *Output
     totalAmount := big.NewInt(0)
@@ -105,28 +137,15 @@ func PrepareSendTransaction(from, to common.Address, amount *big.Int, utxoTxs []
         output1 = ZeroOutput()
     }
 
-    tx := Transaction{
-        Input0: &Input{
-            BlkNum: utxoTxs[0].BlkNum,
-            TxIdx:  utxoTxs[0].TxIdx,
-            OutIdx: utxoTxs[0].OutputIndexFor(&from),
-        },
-        Input1: input1,
-        Output0: &Output{
-            NewOwner: to,
-            Amount:   amount,
-        },
-        Output1: output1,
-        Fee:     big.NewInt(0),
-    }
-    var err error
-    tx.Sig0, err = client.SignData(&from, tx.SignatureHash())
-    if err != nil {
-        return nil, err
+    input0 := &Input{
+        BlkNum: utxoTxs[0].BlkNum,
+        TxIdx:  utxoTxs[0].TxIdx,
+        OutIdx: utxoTxs[0].OutputIndexFor(&from),
     }
-    if tx.Input1.IsZeroInput() == false {
-        //Input1 is valid, set the signature (note that signature is the same)
-        tx.Sig1 = tx.Sig0
+    output0 := &Output{
+        NewOwner: to,
+        Amount:   amount,
     }
-    return &tx, nil
+    tx := NewTransaction([]*Input{input0, input1}, []*Output{output0, output1}, big.NewInt(0))
+    return tx, nil
 }
@@ -3,17 +3,59 @@ package chain
 import (
 	"bytes"
 	"encoding/binary"
+	"fmt"
+	"io"
 
 	"github.com/ethereum/go-ethereum/crypto/sha3"
+	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/kyokan/plasma/util"
 )
 
+const (
+	blockHeaderRLPLength      = 4
+	tokenBlockHeaderRLPLength = 5
+)
+
+// ChildBlockInterval is the distance between the numbers of the blocks the
+// root node submits. The plasma contract numbers deposit blocks in between,
+// so that a deposit cannot take the number of a block that is waiting to be
+// submitted. It matches CHILD_BLOCK_INTERVAL in Plasma.sol.
+const ChildBlockInterval = 1000
+
+// IsDepositBlock reports whether num is the number of a deposit block.
+func IsDepositBlock(num uint64) bool {
+	return num%ChildBlockInterval != 0
+}
+
+// NextChildBlock returns the number of the first submitted block after num.
+func NextChildBlock(num uint64) uint64 {
+	return (num/ChildBlockInterval + 1) * ChildBlockInterval
+}
+
 // JSON tags needed for test fixtures
 type BlockHeader struct {
 	MerkleRoot    util.Hash `json:"MerkleRoot"`
 	RLPMerkleRoot util.Hash `json:"RLPMerkleRoot"`
 	PrevHash      util.Hash `json:"PrevHash"`
 	Number        uint64    `json:"Number"`
+	// TokenRoot is the root of the sparse Merkle tree of the tokens sent in
+	// the block, see TokenLeaves. It is empty when no token is sent.
+	TokenRoot util.Hash `json:"TokenRoot,omitempty"`
+}
+
+type rlpBlockHeader struct {
+	MerkleRoot    util.Hash
+	RLPMerkleRoot util.Hash
+	PrevHash      util.Hash
+	Number        uint64
+}
+
+type rlpTokenBlockHeader struct {
+	MerkleRoot    util.Hash
+	RLPMerkleRoot util.Hash
+	PrevHash      util.Hash
+	Number        uint64
+	TokenRoot     util.Hash
 }
 
 // JSON tags needed for test fixtures
@@ -22,11 +64,99 @@ type Block struct {
 	BlockHash util.Hash    `json:"BlockHash"`
 }
 
+// Hash hashes the Merkle root, the previous hash, the number and the token
+// root if there is one. Blocks without tokens hash as they did before.
 func (head BlockHeader) Hash() util.Hash {
 	buf := new(bytes.Buffer)
 	buf.Write(head.MerkleRoot)
 	buf.Write(head.PrevHash)
 	binary.Write(buf, binary.BigEndian, head.Number)
+	buf.Write(head.TokenRoot)
 	digest := sha3.Sum256(buf.Bytes())
 	return digest[:]
 }
+
+// TokenTreeRoot returns the root of the block's token tree. Blocks that do
+// not send any token have no TokenRoot, which stands for the empty tree.
+func (head BlockHeader) TokenTreeRoot() util.Hash {
+	if len(head.TokenRoot) == 0 {
+		return util.EmptySparseMerkleRoot()
+	}
+
+	return head.TokenRoot
+}
+
+// EncodeRLP appends the token root to the header only when there is one, so
+// that headers saved before tokens existed keep decoding.
+func (head *BlockHeader) EncodeRLP(w io.Writer) error {
+	if len(head.TokenRoot) > 0 {
+		return rlp.Encode(w, &rlpTokenBlockHeader{
+			MerkleRoot:    head.MerkleRoot,
+			RLPMerkleRoot: head.RLPMerkleRoot,
+			PrevHash:      head.PrevHash,
+			Number:        head.Number,
+			TokenRoot:     head.TokenRoot,
+		})
+	}
+
+	return rlp.Encode(w, &rlpBlockHeader{
+		MerkleRoot:    head.MerkleRoot,
+		RLPMerkleRoot: head.RLPMerkleRoot,
+		PrevHash:      head.PrevHash,
+		Number:        head.Number,
+	})
+}
+
+func (head *BlockHeader) DecodeRLP(s *rlp.Stream) error {
+	raw, err := s.Raw()
+
+	if err != nil {
+		return err
+	}
+
+	content, _, err := rlp.SplitList(raw)
+
+	if err != nil {
+		return err
+	}
+
+	count, err := rlp.CountValues(content)
+
+	if err != nil {
+		return err
+	}
+
+	switch count {
+	case blockHeaderRLPLength:
+		var itf rlpBlockHeader
+
+		if err := rlp.DecodeBytes(raw, &itf); err != nil {
+			return err
+		}
+
+		*head = BlockHeader{
+			MerkleRoot:    itf.MerkleRoot,
+			RLPMerkleRoot: itf.RLPMerkleRoot,
+			PrevHash:      itf.PrevHash,
+			Number:        itf.Number,
+		}
+	case tokenBlockHeaderRLPLength:
+		var itf rlpTokenBlockHeader
+
+		if err := rlp.DecodeBytes(raw, &itf); err != nil {
+			return err
+		}
+
+		*head = BlockHeader{
+			MerkleRoot:    itf.MerkleRoot,
+			RLPMerkleRoot: itf.RLPMerkleRoot,
+			PrevHash:      itf.PrevHash,
+			Number:        itf.Number,
+			TokenRoot:     itf.TokenRoot,
+		}
+	default:
+		return fmt.Errorf("block header has %d fields", count)
+	}
+
+	return nil
+}
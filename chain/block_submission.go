@@ -0,0 +1,26 @@
+package chain
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/kyokan/plasma/util"
+)
+
+const (
+	SubmissionPending = "pending"
+	SubmissionMined   = "mined"
+	SubmissionFailed  = "failed"
+)
+
+// BlockSubmission is the state of a block's submission to the plasma
+// contract. Deposit blocks are created by the contract itself and are mined
+// from the start.
+type BlockSubmission struct {
+	Number   uint64      `json:"Number"`
+	Root     util.Hash   `json:"Root"`
+	Status   string      `json:"Status"`
+	Deposit  bool        `json:"Deposit"`
+	TxHash   common.Hash `json:"TxHash"`
+	Nonce    uint64      `json:"Nonce"`
+	Attempts uint64      `json:"Attempts"`
+	Error    string      `json:"Error"`
+}
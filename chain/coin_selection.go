@@ -0,0 +1,371 @@
+package chain
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var ErrInsufficientFunds = errors.New("insufficient funds")
+
+// UTXO is an unspent output of the account selecting coins.
+type UTXO struct {
+	BlkNum uint64
+	TxIdx  uint32
+	OutIdx uint8
+	Amount *big.Int
+}
+
+func (u UTXO) Input() *Input {
+	return &Input{BlkNum: u.BlkNum, TxIdx: u.TxIdx, OutIdx: u.OutIdx}
+}
+
+// UTXOsFor returns owner's outputs in txs. Multisig outputs paid to owner
+// need the signatures of other owners, and locked outputs a preimage or a
+// later block, so they are left out.
+func UTXOsFor(owner common.Address, txs []Transaction) []UTXO {
+	var utxos []UTXO
+
+	for _, tx := range txs {
+		for i, output := range tx.Outputs {
+			if output == nil || output.NewOwner != owner || output.IsMultisig() || output.IsLocked() || output.Amount == nil || output.Amount.Sign() <= 0 {
+				continue
+			}
+
+			utxos = append(utxos, UTXO{BlkNum: tx.BlkNum, TxIdx: tx.TxIdx, OutIdx: uint8(i), Amount: output.Amount})
+		}
+	}
+
+	return utxos
+}
+
+// Strategy selects the inputs of a payment. Select returns at most
+// MaxInputs UTXOs that cover amount, or nil if there are none. The
+// strategies below return one or two, which keeps the payment a legacy
+// transaction; PlanPayment merges outputs when none of them is enough.
+type Strategy interface {
+	Select(utxos []UTXO, amount *big.Int) []UTXO
+}
+
+// ExactMatch selects one or two outputs that add up to amount, so the
+// payment has no change.
+type ExactMatch struct{}
+
+func (ExactMatch) Select(utxos []UTXO, amount *big.Int) []UTXO {
+	for _, u := range utxos {
+		if u.Amount.Cmp(amount) == 0 {
+			return []UTXO{u}
+		}
+	}
+
+	sum := new(big.Int)
+
+	for i := range utxos {
+		for j := i + 1; j < len(utxos); j++ {
+			if sum.Add(utxos[i].Amount, utxos[j].Amount).Cmp(amount) == 0 {
+				return []UTXO{utxos[i], utxos[j]}
+			}
+		}
+	}
+
+	return nil
+}
+
+// SmallestSufficient selects the smallest output that covers amount, or
+// else the pair with the smallest sum that does. It keeps large outputs
+// whole.
+type SmallestSufficient struct{}
+
+func (SmallestSufficient) Select(utxos []UTXO, amount *big.Int) []UTXO {
+	sorted := sortedUTXOs(utxos)
+
+	for _, u := range sorted {
+		if u.Amount.Cmp(amount) >= 0 {
+			return []UTXO{u}
+		}
+	}
+
+	var best []UTXO
+	var bestSum *big.Int
+
+	for i, j := 0, len(sorted)-1; i < j; {
+		sum := new(big.Int).Add(sorted[i].Amount, sorted[j].Amount)
+
+		if sum.Cmp(amount) < 0 {
+			i++
+			continue
+		}
+
+		if bestSum == nil || sum.Cmp(bestSum) < 0 {
+			best = []UTXO{sorted[i], sorted[j]}
+			bestSum = sum
+		}
+
+		j--
+	}
+
+	return best
+}
+
+// LargestFirst selects the largest output, adding the second largest if it
+// is not enough. It spends large outputs first and leaves small ones for
+// consolidation.
+type LargestFirst struct{}
+
+func (LargestFirst) Select(utxos []UTXO, amount *big.Int) []UTXO {
+	sorted := sortedUTXOs(utxos)
+	n := len(sorted)
+
+	if n == 0 {
+		return nil
+	}
+
+	if sorted[n-1].Amount.Cmp(amount) >= 0 {
+		return []UTXO{sorted[n-1]}
+	}
+
+	if n > 1 && new(big.Int).Add(sorted[n-1].Amount, sorted[n-2].Amount).Cmp(amount) >= 0 {
+		return []UTXO{sorted[n-1], sorted[n-2]}
+	}
+
+	return nil
+}
+
+// PrivacyPreserving selects a single output whenever one covers amount, so
+// that outputs are not linked by being spent together, and picks randomly
+// among the candidates, so that the choice does not identify the wallet.
+type PrivacyPreserving struct {
+	Rand *rand.Rand
+}
+
+func NewPrivacyPreserving() *PrivacyPreserving {
+	return &PrivacyPreserving{Rand: rand.New(rand.NewSource(time.Now().UnixNano()))}
+}
+
+func (p *PrivacyPreserving) Select(utxos []UTXO, amount *big.Int) []UTXO {
+	var singles []UTXO
+
+	for _, u := range utxos {
+		if u.Amount.Cmp(amount) >= 0 {
+			singles = append(singles, u)
+		}
+	}
+
+	if len(singles) > 0 {
+		return []UTXO{singles[p.Rand.Intn(len(singles))]}
+	}
+
+	var pairs [][]UTXO
+	sum := new(big.Int)
+
+	for i := range utxos {
+		for j := i + 1; j < len(utxos); j++ {
+			if sum.Add(utxos[i].Amount, utxos[j].Amount).Cmp(amount) >= 0 {
+				pairs = append(pairs, []UTXO{utxos[i], utxos[j]})
+			}
+		}
+	}
+
+	if len(pairs) > 0 {
+		return pairs[p.Rand.Intn(len(pairs))]
+	}
+
+	return nil
+}
+
+// StrategyByName returns the strategy called exact, smallest, largest or
+// privacy.
+func StrategyByName(name string) (Strategy, error) {
+	switch name {
+	case "exact":
+		return ExactMatch{}, nil
+	case "smallest":
+		return SmallestSufficient{}, nil
+	case "largest":
+		return LargestFirst{}, nil
+	case "privacy":
+		return NewPrivacyPreserving(), nil
+	default:
+		return nil, fmt.Errorf("unknown coin selection strategy %q", name)
+	}
+}
+
+// PlanInput is an input of a planned transaction. It spends either an
+// existing UTXO, or output 0 of an earlier step of the plan.
+type PlanInput struct {
+	UTXO   *UTXO
+	Step   int
+	Amount *big.Int
+}
+
+// PlanStep is a planned transaction paying Amount to To, with the change
+// going back to the sender. Metadata is attached to the transaction.
+type PlanStep struct {
+	Inputs   []PlanInput
+	To       common.Address
+	Amount   *big.Int
+	Metadata []byte `json:",omitempty"`
+}
+
+// Plan is an ordered list of transactions. A step that spends the output of
+// an earlier step can only be built once that step was included in a block.
+type Plan struct {
+	From  common.Address
+	Steps []PlanStep
+}
+
+// DependsOn returns the earlier steps whose outputs step i spends.
+func (p *Plan) DependsOn(i int) []int {
+	var deps []int
+
+	for _, input := range p.Steps[i].Inputs {
+		if input.UTXO == nil {
+			deps = append(deps, input.Step)
+		}
+	}
+
+	return deps
+}
+
+// Build builds the transaction of step i. included maps the earlier steps
+// it depends on to their transactions as included in a block.
+func (p *Plan) Build(i int, included map[int]*Transaction) (*Transaction, error) {
+	step := p.Steps[i]
+	inputs := make([]*Input, len(step.Inputs))
+	total := new(big.Int)
+
+	for k, input := range step.Inputs {
+		if input.UTXO != nil {
+			inputs[k] = input.UTXO.Input()
+		} else {
+			prev, ok := included[input.Step]
+
+			if !ok || prev.BlkNum == 0 {
+				return nil, fmt.Errorf("step %d was not included yet", input.Step)
+			}
+
+			inputs[k] = &Input{BlkNum: prev.BlkNum, TxIdx: prev.TxIdx, OutIdx: 0}
+		}
+
+		total.Add(total, input.Amount)
+	}
+
+	change := ZeroOutput()
+
+	if total.Cmp(step.Amount) > 0 {
+		change = &Output{NewOwner: p.From, Amount: new(big.Int).Sub(total, step.Amount)}
+	}
+
+	pay := &Output{NewOwner: step.To, Amount: step.Amount}
+	tx := NewTransaction(inputs, []*Output{pay, change}, big.NewInt(0))
+	tx.SetMetadata(step.Metadata)
+	return tx, nil
+}
+
+// PlanPayment plans paying amount to to from from's UTXOs. When strategy
+// finds inputs for a single transaction, the plan is that payment.
+// Otherwise the largest outputs that cover amount are merged, up to
+// MaxInputs at a time, into outputs to from, level by level, until at most
+// MaxInputs remain to pay with.
+func PlanPayment(from, to common.Address, amount *big.Int, utxos []UTXO, strategy Strategy) (*Plan, error) {
+	plan := &Plan{From: from}
+
+	if selected := strategy.Select(utxos, amount); selected != nil {
+		step := PlanStep{To: to, Amount: amount}
+
+		for i := range selected {
+			step.Inputs = append(step.Inputs, PlanInput{UTXO: &selected[i], Amount: selected[i].Amount})
+		}
+
+		plan.Steps = append(plan.Steps, step)
+		return plan, nil
+	}
+
+	sorted := sortedUTXOs(utxos)
+	sum := new(big.Int)
+	var queue []PlanInput
+
+	for i := len(sorted) - 1; i >= 0 && sum.Cmp(amount) < 0; i-- {
+		sum.Add(sum, sorted[i].Amount)
+		queue = append(queue, PlanInput{UTXO: &sorted[i], Amount: sorted[i].Amount})
+	}
+
+	if sum.Cmp(amount) < 0 {
+		return nil, ErrInsufficientFunds
+	}
+
+	queue = plan.merge(queue, MaxInputs)
+
+	plan.Steps = append(plan.Steps, PlanStep{Inputs: queue, To: to, Amount: amount})
+	return plan, nil
+}
+
+// PlanConsolidation plans self-transfers that merge owner's UTXOs until at
+// most target remain. The smallest outputs are merged first, up to
+// MaxInputs at a time and level by level, so the plan is a tree whose steps
+// only spend outputs of earlier levels.
+func PlanConsolidation(owner common.Address, utxos []UTXO, target int) (*Plan, error) {
+	if target < 1 {
+		return nil, errors.New("target must be at least 1")
+	}
+
+	plan := &Plan{From: owner}
+	sorted := sortedUTXOs(utxos)
+	queue := make([]PlanInput, len(sorted))
+
+	for i := range sorted {
+		queue[i] = PlanInput{UTXO: &sorted[i], Amount: sorted[i].Amount}
+	}
+
+	plan.merge(queue, target)
+	return plan, nil
+}
+
+// merge adds steps that merge up to MaxInputs of the inputs in queue into
+// outputs to the sender until at most target remain, and returns the
+// remaining inputs. A step merges fewer inputs when that is enough to reach
+// target. Steps are added level by level, so the steps of a level only
+// depend on the level before.
+func (p *Plan) merge(queue []PlanInput, target int) []PlanInput {
+	width := MaxInputs
+
+	if width < 2 {
+		width = 2
+	}
+
+	for len(queue) > target {
+		n := width
+
+		if excess := len(queue) - target + 1; excess < n {
+			n = excess
+		}
+
+		inputs := append([]PlanInput(nil), queue[:n]...)
+		queue = queue[n:]
+		amount := new(big.Int)
+
+		for _, input := range inputs {
+			amount.Add(amount, input.Amount)
+		}
+
+		p.Steps = append(p.Steps, PlanStep{Inputs: inputs, To: p.From, Amount: amount})
+		queue = append(queue, PlanInput{Step: len(p.Steps) - 1, Amount: amount})
+	}
+
+	return queue
+}
+
+func sortedUTXOs(utxos []UTXO) []UTXO {
+	sorted := append([]UTXO(nil), utxos...)
+
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Amount.Cmp(sorted[j].Amount) < 0
+	})
+
+	return sorted
+}
@@ -0,0 +1,118 @@
+package chain
+
+import (
+	"math/big"
+	"math/rand"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+func testUTXOs(amounts ...int64) []UTXO {
+	utxos := make([]UTXO, len(amounts))
+
+	for i, amount := range amounts {
+		utxos[i] = UTXO{BlkNum: uint64(i + 1), Amount: big.NewInt(amount)}
+	}
+
+	return utxos
+}
+
+func selectedAmounts(utxos []UTXO) []int64 {
+	var amounts []int64
+
+	for _, u := range utxos {
+		amounts = append(amounts, u.Amount.Int64())
+	}
+
+	return amounts
+}
+
+func Test_Strategies(t *testing.T) {
+	utxos := testUTXOs(5, 20, 7, 50, 13)
+
+	require.Equal(t, []int64{5, 13}, selectedAmounts(ExactMatch{}.Select(utxos, big.NewInt(18))))
+	require.Equal(t, []int64{20}, selectedAmounts(ExactMatch{}.Select(utxos, big.NewInt(20))))
+	require.Nil(t, ExactMatch{}.Select(utxos, big.NewInt(1000)))
+
+	require.Equal(t, []int64{13}, selectedAmounts(SmallestSufficient{}.Select(utxos, big.NewInt(10))))
+	require.Equal(t, []int64{13, 50}, selectedAmounts(SmallestSufficient{}.Select(utxos, big.NewInt(60))))
+	require.Nil(t, SmallestSufficient{}.Select(utxos, big.NewInt(71)))
+
+	require.Equal(t, []int64{50}, selectedAmounts(LargestFirst{}.Select(utxos, big.NewInt(10))))
+	require.Equal(t, []int64{50, 20}, selectedAmounts(LargestFirst{}.Select(utxos, big.NewInt(60))))
+
+	privacy := &PrivacyPreserving{Rand: rand.New(rand.NewSource(1))}
+	require.Len(t, privacy.Select(utxos, big.NewInt(10)), 1)
+	require.Len(t, privacy.Select(utxos, big.NewInt(60)), 2)
+}
+
+func Test_PlanPaymentConsolidatesSmallOutputs(t *testing.T) {
+	from := common.Address{1}
+	to := common.Address{2}
+	utxos := testUTXOs(10, 10, 10, 10, 10, 10, 1)
+
+	plan, err := PlanPayment(from, to, big.NewInt(55), utxos, SmallestSufficient{})
+	require.NoError(t, err)
+
+	// A merge of three of the six outputs of 10, then the payment with the
+	// other three and the merged output.
+	require.Len(t, plan.Steps, 2)
+	require.Len(t, plan.Steps[0].Inputs, 3)
+	require.Empty(t, plan.DependsOn(0))
+	require.Equal(t, []int{0}, plan.DependsOn(1))
+
+	_, err = plan.Build(1, nil)
+	require.Error(t, err)
+
+	first, err := plan.Build(0, nil)
+	require.NoError(t, err)
+	require.Equal(t, from, first.Outputs[0].NewOwner)
+	require.Equal(t, big.NewInt(30), first.Outputs[0].Amount)
+
+	included := map[int]*Transaction{
+		0: {BlkNum: 7, TxIdx: 1},
+	}
+
+	pay, err := plan.Build(1, included)
+	require.NoError(t, err)
+	require.Len(t, pay.Inputs, MaxInputs)
+	require.Equal(t, uint64(7), pay.Inputs[3].BlkNum)
+	require.Equal(t, uint32(1), pay.Inputs[3].TxIdx)
+	require.Equal(t, to, pay.Outputs[0].NewOwner)
+	require.Equal(t, big.NewInt(55), pay.Outputs[0].Amount)
+	require.Equal(t, big.NewInt(5), pay.Outputs[1].Amount)
+
+	_, err = PlanPayment(from, to, big.NewInt(100), utxos, SmallestSufficient{})
+	require.Equal(t, ErrInsufficientFunds, err)
+}
+
+func Test_PlanConsolidationBuildsTree(t *testing.T) {
+	owner := common.Address{1}
+
+	plan, err := PlanConsolidation(owner, testUTXOs(1, 2, 3, 4, 5, 6, 7, 8, 9, 10), 1)
+	require.NoError(t, err)
+
+	// 1 to 4 and 5 to 8, then 9 and 10 with both merges.
+	require.Len(t, plan.Steps, 3)
+	require.Empty(t, plan.DependsOn(0))
+	require.Empty(t, plan.DependsOn(1))
+	require.Equal(t, []int{0, 1}, plan.DependsOn(2))
+	require.Equal(t, big.NewInt(10), plan.Steps[0].Amount)
+	require.Equal(t, big.NewInt(55), plan.Steps[2].Amount)
+
+	for _, step := range plan.Steps {
+		require.Equal(t, owner, step.To)
+	}
+
+	// Merging 1, 2 and 3 is enough to leave three outputs.
+	plan, err = PlanConsolidation(owner, testUTXOs(1, 2, 3, 4, 5), 3)
+	require.NoError(t, err)
+	require.Len(t, plan.Steps, 1)
+	require.Len(t, plan.Steps[0].Inputs, 3)
+
+	plan, err = PlanConsolidation(owner, testUTXOs(1, 2), 2)
+	require.NoError(t, err)
+	require.Empty(t, plan.Steps)
+}
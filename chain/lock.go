@@ -0,0 +1,171 @@
+package chain
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const (
+	// HashLockSize is the size of a hash lock, the SHA-256 of a preimage.
+	HashLockSize = sha256.Size
+
+	// MaxPreimageSize is the size limit of a preimage.
+	MaxPreimageSize = 32
+)
+
+// NewLockedOutput returns an output locked until plasma block timeLock.
+// With a hashLock, the output can be spent by newOwner with the preimage of
+// the hash lock until timeLock, and by refundOwner once timeLock has passed.
+// Without a refundOwner, the preimage unlocks it at any time. Without a
+// hashLock, newOwner can spend it once timeLock has passed.
+func NewLockedOutput(newOwner common.Address, amount *big.Int, hashLock []byte, timeLock uint64, refundOwner *common.Address) *Output {
+	out := &Output{
+		NewOwner: newOwner,
+		Amount:   new(big.Int).Set(amount),
+		HashLock: hashLock,
+		TimeLock: timeLock,
+	}
+
+	if refundOwner != nil {
+		refund := *refundOwner
+		out.RefundOwner = &refund
+	}
+
+	return out
+}
+
+// HashPreimage returns the hash lock a preimage unlocks.
+func HashPreimage(preimage []byte) []byte {
+	digest := sha256.Sum256(preimage)
+	return digest[:]
+}
+
+func (out *Output) IsLocked() bool {
+	return len(out.HashLock) > 0 || out.TimeLock > 0
+}
+
+// Unlock checks the lock conditions of spending the output in the block
+// after height, with the given preimage, and returns the output whose
+// owners must sign the spend: the output itself, or its refund owner once
+// the time lock of a hash-locked output has passed. The preimage no longer
+// unlocks the output then, so that its owner's claim and the refund never
+// race.
+func (out *Output) Unlock(height uint64, preimage []byte) (*Output, error) {
+	if !out.IsLocked() {
+		return out, nil
+	}
+
+	if len(out.HashLock) == 0 {
+		if height < out.TimeLock {
+			return nil, fmt.Errorf("output is locked until block %d", out.TimeLock)
+		}
+
+		return out, nil
+	}
+
+	if out.RefundOwner != nil && height >= out.TimeLock {
+		if len(preimage) > 0 {
+			return nil, fmt.Errorf("hash lock expired at block %d", out.TimeLock)
+		}
+
+		return NewOutput(*out.RefundOwner, out.Amount), nil
+	}
+
+	if len(preimage) > 0 && bytes.Equal(HashPreimage(preimage), out.HashLock) {
+		return out, nil
+	}
+
+	if len(preimage) > 0 {
+		return nil, errors.New("preimage does not match the hash lock")
+	}
+
+	return nil, errors.New("output is hash-locked")
+}
+
+func (out *Output) checkLock() error {
+	if len(out.HashLock) > 0 && len(out.HashLock) != HashLockSize {
+		return fmt.Errorf("hash locks are %d bytes", HashLockSize)
+	}
+
+	if out.RefundOwner != nil && (len(out.HashLock) == 0 || out.TimeLock == 0) {
+		return errors.New("a refund owner needs a hash lock and a time lock")
+	}
+
+	if out.RefundOwner != nil && *out.RefundOwner == (common.Address{}) {
+		return errors.New("refund owner must not be empty")
+	}
+
+	return nil
+}
+
+// SetLock locks the output at idx with a hash lock and a time lock. Legacy
+// transactions have no room for them, so they are converted to
+// TxVersionMulti.
+func (tx *Transaction) SetLock(idx uint8, hashLock []byte, timeLock uint64, refundOwner *common.Address) error {
+	output := tx.OutputAt(idx)
+
+	if output == nil {
+		return fmt.Errorf("no output %d", idx)
+	}
+
+	locked := NewLockedOutput(output.NewOwner, output.Amount, hashLock, timeLock, refundOwner)
+	output.HashLock = locked.HashLock
+	output.TimeLock = locked.TimeLock
+	output.RefundOwner = locked.RefundOwner
+
+	if output.IsLocked() && tx.Version == TxVersionLegacy {
+		tx.Version = TxVersionMulti
+	}
+
+	return output.checkLock()
+}
+
+// SetPreimage attaches the preimage that unlocks the hash-locked output
+// spent by the input at idx. Legacy transactions have no room for it, so
+// they are converted to TxVersionMulti.
+func (tx *Transaction) SetPreimage(idx int, preimage []byte) error {
+	if idx < 0 || idx >= len(tx.Inputs) {
+		return fmt.Errorf("no input %d", idx)
+	}
+
+	if len(preimage) > MaxPreimageSize {
+		return fmt.Errorf("preimages are at most %d bytes", MaxPreimageSize)
+	}
+
+	if len(tx.Preimages) < len(tx.Inputs) {
+		tx.Preimages = append(tx.Preimages, make([][]byte, len(tx.Inputs)-len(tx.Preimages))...)
+	}
+
+	tx.Preimages[idx] = preimage
+
+	if tx.Version == TxVersionLegacy {
+		tx.Version = TxVersionMulti
+	}
+
+	return nil
+}
+
+// PreimageAt returns the preimage of the input at idx, or nil if there is
+// none.
+func (tx *Transaction) PreimageAt(idx uint8) []byte {
+	if int(idx) >= len(tx.Preimages) {
+		return nil
+	}
+
+	return tx.Preimages[idx]
+}
+
+func (tx *Transaction) hasPreimages() bool {
+	for _, preimage := range tx.Preimages {
+		if len(preimage) > 0 {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,105 @@
+package chain
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/kyokan/plasma/signer"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_LockedOutputRLP(t *testing.T) {
+	refund := common.Address{2}
+	locked := NewLockedOutput(common.Address{1}, big.NewInt(100), HashPreimage([]byte("secret")), 10, &refund)
+	encodeAndDecode(t, locked)
+	encodeAndDecode(t, NewLockedOutput(common.Address{1}, big.NewInt(100), nil, 10, nil))
+
+	tx := NewTransaction([]*Input{randomInput()}, []*Output{locked, randomOutput()}, big.NewInt(0))
+	tx.Sigs = [][]byte{randomSig()}
+	require.Equal(t, TxVersionMulti, tx.Version)
+	require.NoError(t, tx.CheckFormat())
+	encodeAndDecode(t, tx)
+
+	// The legacy format has no room for the lock.
+	tx.Version = TxVersionLegacy
+	require.Error(t, tx.CheckFormat())
+
+	bad := NewLockedOutput(common.Address{1}, big.NewInt(100), []byte("short"), 10, nil)
+	require.Error(t, bad.checkLock())
+	bad = NewLockedOutput(common.Address{1}, big.NewInt(100), nil, 10, &refund)
+	require.Error(t, bad.checkLock())
+}
+
+func Test_TransactionPreimages(t *testing.T) {
+	tx := NewTransaction([]*Input{randomInput()}, []*Output{randomOutput()}, big.NewInt(0))
+	tx.Version = TxVersionMulti
+	hash := tx.Hash()
+	structHash := tx.StructHash()
+
+	require.NoError(t, tx.SetPreimage(0, []byte("secret")))
+	tx.Sigs = [][]byte{randomSig()}
+	require.NoError(t, tx.CheckFormat())
+	require.Equal(t, []byte("secret"), tx.PreimageAt(0))
+	require.Nil(t, tx.PreimageAt(1))
+	encodeAndDecode(t, tx)
+
+	// Preimages are not signed, but they are part of the transaction.
+	require.Equal(t, structHash, tx.StructHash())
+	require.NotEqual(t, hash, tx.Hash())
+
+	require.Error(t, tx.SetPreimage(0, make([]byte, MaxPreimageSize+1)))
+	require.Error(t, tx.SetPreimage(2, []byte("secret")))
+
+	tx.SetMetadata([]byte("INV-1001"))
+	encodeAndDecode(t, tx)
+}
+
+func Test_Unlock(t *testing.T) {
+	owner, err := signer.GenerateMemorySigner()
+	require.NoError(t, err)
+	refund, err := signer.GenerateMemorySigner()
+	require.NoError(t, err)
+
+	preimage := []byte("secret")
+	refundOwner := refund.Address()
+	htlc := NewLockedOutput(owner.Address(), big.NewInt(100), HashPreimage(preimage), 10, &refundOwner)
+
+	// The owner claims with the preimage until the time lock, and no later,
+	// so that the claim and the refund never race.
+	signers, err := htlc.Unlock(9, preimage)
+	require.NoError(t, err)
+	require.True(t, signers.IsOwner(owner.Address()))
+	_, err = htlc.Unlock(10, preimage)
+	require.Error(t, err)
+
+	// Without a refund owner, the preimage unlocks the output at any time.
+	claimOnly := NewLockedOutput(owner.Address(), big.NewInt(100), HashPreimage(preimage), 10, nil)
+	signers, err = claimOnly.Unlock(20, preimage)
+	require.NoError(t, err)
+	require.True(t, signers.IsOwner(owner.Address()))
+
+	_, err = htlc.Unlock(5, nil)
+	require.Error(t, err)
+	_, err = htlc.Unlock(5, []byte("guess"))
+	require.Error(t, err)
+
+	// The refund owner signs once the time lock has passed.
+	signers, err = htlc.Unlock(10, nil)
+	require.NoError(t, err)
+	require.True(t, signers.IsOwner(refund.Address()))
+	require.False(t, signers.IsOwner(owner.Address()))
+
+	timeLocked := NewLockedOutput(owner.Address(), big.NewInt(100), nil, 10, nil)
+	_, err = timeLocked.Unlock(9, nil)
+	require.Error(t, err)
+	signers, err = timeLocked.Unlock(10, nil)
+	require.NoError(t, err)
+	require.True(t, signers.IsOwner(owner.Address()))
+
+	tx := NewTransaction([]*Input{NewInput(2, 0, 0)}, []*Output{NewOutput(refund.Address(), big.NewInt(100))}, big.NewInt(0))
+	require.NoError(t, tx.Sign(refund, testDomain))
+	signers, err = htlc.Unlock(10, tx.PreimageAt(0))
+	require.NoError(t, err)
+	require.NoError(t, signers.VerifySignature(tx.SignatureHash(testDomain), tx.SigAt(0)))
+}
@@ -6,21 +6,28 @@ import (
     "testing"
     "time"
 
-    "github.com/kyokan/plasma/common/mocks"
-    "github.com/stretchr/testify/mock"
+    "github.com/ethereum/go-ethereum/common"
+    "github.com/kyokan/plasma/signer"
     "github.com/stretchr/testify/require"
 )
 
 const size = 10000
 const max  = 4 * size
 
+var testDomain = Domain{ChainID: big.NewInt(1), VerifyingContract: common.Address{0xaa}}
+
+func testSigner(t *testing.T) signer.Signer {
+    s, err := signer.GenerateMemorySigner()
+    require.NoError(t, err)
+    return s
+}
+
 func Test_OneTransactionMatches(t *testing.T) {
     rand.Seed(time.Now().Unix())
-    from   := randomAddress()
+    s      := testSigner(t)
+    from   := s.Address()
     to     := randomAddress()
     amount := big.NewInt(0)
-    client := new(mocks.Client)
-    client.On("SignData", mock.AnythingOfType("*common.Address"), mock.AnythingOfType("[]uint8")).Return(randomSig(), nil)
     transactions := make([]Transaction, size)
     idx := rand.Intn(size)
     for i := 0; i < size; i++ {
@@ -33,30 +40,26 @@ func Test_OneTransactionMatches(t *testing.T) {
             amount.Add(amount, output.Amount)
         }
         transactions[i] = Transaction{
-            Input0: randomInput(),
-            Input1: randomInput(),
+            Inputs: []*Input{randomInput(), randomInput()},
         }
         if outputIdx == false {
-            transactions[i].Output0 = output
-            transactions[i].Output1 = randomOutput()
+            transactions[i].Outputs = []*Output{output, randomOutput()}
         } else {
-            transactions[i].Output1 = output
-            transactions[i].Output0 = randomOutput()
+            transactions[i].Outputs = []*Output{randomOutput(), output}
         }
     }
-    tx, err := FindBestUTXOs(from, to, amount, transactions, client)
+    tx, err := FindBestUTXOs(to, amount, transactions, s, testDomain)
     require.NoError(t, err)
-    require.Equal(t, ZeroOutput(), tx.Output1)
-    require.Equal(t, 0, amount.Cmp(tx.Output0.Amount))
+    require.Equal(t, ZeroOutput(), tx.Outputs[1])
+    require.Equal(t, 0, amount.Cmp(tx.Outputs[0].Amount))
 }
 
 func Test_TwoTransactionsMatch(t *testing.T) {
     rand.Seed(time.Now().Unix())
-    from   := randomAddress()
+    s      := testSigner(t)
+    from   := s.Address()
     to     := randomAddress()
     amount := big.NewInt(0)
-    client := new(mocks.Client)
-    client.On("SignData", mock.AnythingOfType("*common.Address"), mock.AnythingOfType("[]uint8")).Return(randomSig(), nil)
     transactions := make([]Transaction, size)
     firstIdx := rand.Intn(size)
     secondIdx := rand.Intn(size)
@@ -73,30 +76,26 @@ func Test_TwoTransactionsMatch(t *testing.T) {
             amount.Add(amount, output.Amount)
         }
         transactions[i] = Transaction{
-            Input0: randomInput(),
-            Input1: randomInput(),
+            Inputs: []*Input{randomInput(), randomInput()},
         }
         if outputIdx == false {
-            transactions[i].Output0 = output
-            transactions[i].Output1 = randomOutput()
+            transactions[i].Outputs = []*Output{output, randomOutput()}
         } else {
-            transactions[i].Output1 = output
-            transactions[i].Output0 = randomOutput()
+            transactions[i].Outputs = []*Output{randomOutput(), output}
         }
     }
-    tx, err := FindBestUTXOs(from, to, amount, transactions, client)
+    tx, err := FindBestUTXOs(to, amount, transactions, s, testDomain)
     require.NoError(t, err)
-    require.Equal(t, ZeroOutput(), tx.Output1)
-    require.Equal(t, 0, amount.Cmp(tx.Output0.Amount))
+    require.Equal(t, ZeroOutput(), tx.Outputs[1])
+    require.Equal(t, 0, amount.Cmp(tx.Outputs[0].Amount))
 }
 
 func Test_AmountLessThanMinTransaction(t *testing.T) {
     rand.Seed(time.Now().Unix())
-    from   := randomAddress()
+    s      := testSigner(t)
+    from   := s.Address()
     to     := randomAddress()
     amount := big.NewInt(4)
-    client := new(mocks.Client)
-    client.On("SignData", mock.AnythingOfType("*common.Address"), mock.AnythingOfType("[]uint8")).Return(randomSig(), nil)
     transactions := make([]Transaction, size)
     for i := 0; i < size; i++ {
         outputIdx := rand.Float32() < 0.5
@@ -105,30 +104,26 @@ func Test_AmountLessThanMinTransaction(t *testing.T) {
             Amount: big.NewInt(int64(5 + rand.Intn(max))),
         }
         transactions[i] = Transaction{
-            Input0: randomInput(),
-            Input1: randomInput(),
+            Inputs: []*Input{randomInput(), randomInput()},
         }
         if outputIdx == false {
-            transactions[i].Output0 = output
-            transactions[i].Output1 = randomOutput()
+            transactions[i].Outputs = []*Output{output, randomOutput()}
         } else {
-            transactions[i].Output1 = output
-            transactions[i].Output0 = randomOutput()
+            transactions[i].Outputs = []*Output{randomOutput(), output}
         }
     }
-    tx, err := FindBestUTXOs(from, to, amount, transactions, client)
+    tx, err := FindBestUTXOs(to, amount, transactions, s, testDomain)
     require.NoError(t, err)
-    require.Equal(t, from, tx.Output1.NewOwner)
-    require.Equal(t, 0, amount.Cmp(tx.Output0.Amount))
+    require.Equal(t, from, tx.Outputs[1].NewOwner)
+    require.Equal(t, 0, amount.Cmp(tx.Outputs[0].Amount))
 }
 
 func Test_AmountLessThanTwoTransactions(t *testing.T) {
     rand.Seed(time.Now().Unix())
-    from   := randomAddress()
+    s      := testSigner(t)
+    from   := s.Address()
     to     := randomAddress()
     amount := big.NewInt(0)
-    client := new(mocks.Client)
-    client.On("SignData", mock.AnythingOfType("*common.Address"), mock.AnythingOfType("[]uint8")).Return(randomSig(), nil)
     transactions := make([]Transaction, size)
     firstIdx := rand.Intn(size)
     secondIdx := rand.Intn(size)
@@ -145,31 +140,27 @@ func Test_AmountLessThanTwoTransactions(t *testing.T) {
             amount.Add(amount, output.Amount)
         }
         transactions[i] = Transaction{
-            Input0: randomInput(),
-            Input1: randomInput(),
+            Inputs: []*Input{randomInput(), randomInput()},
         }
         if outputIdx == false {
-            transactions[i].Output0 = output
-            transactions[i].Output1 = randomOutput()
+            transactions[i].Outputs = []*Output{output, randomOutput()}
         } else {
-            transactions[i].Output1 = output
-            transactions[i].Output0 = randomOutput()
+            transactions[i].Outputs = []*Output{randomOutput(), output}
         }
     }
     amount.Sub(amount, big.NewInt(1))
-    tx, err := FindBestUTXOs(from, to, amount, transactions, client)
+    tx, err := FindBestUTXOs(to, amount, transactions, s, testDomain)
     require.NoError(t, err)
-    require.Equal(t, from, tx.Output1.NewOwner)
-    require.Equal(t, 0, amount.Cmp(tx.Output0.Amount))
+    require.Equal(t, from, tx.Outputs[1].NewOwner)
+    require.Equal(t, 0, amount.Cmp(tx.Outputs[0].Amount))
 }
 
 func Test_NoMatch(t *testing.T) {
     rand.Seed(time.Now().Unix())
-    from   := randomAddress()
+    s      := testSigner(t)
+    from   := s.Address()
     to     := randomAddress()
     amount := big.NewInt(1 + 2 * max)
-    client := new(mocks.Client)
-    client.On("SignData", mock.AnythingOfType("*common.Address"), mock.AnythingOfType("[]uint8")).Return(randomSig(), nil)
     transactions := make([]Transaction, size)
     for i := 0; i < size; i++ {
         outputIdx := rand.Float32() < 0.5
@@ -178,42 +169,36 @@ func Test_NoMatch(t *testing.T) {
             Amount: big.NewInt(int64(rand.Intn(max))),
         }
         transactions[i] = Transaction{
-            Input0: randomInput(),
-            Input1: randomInput(),
+            Inputs: []*Input{randomInput(), randomInput()},
         }
         if outputIdx == false {
-            transactions[i].Output0 = output
-            transactions[i].Output1 = randomOutput()
+            transactions[i].Outputs = []*Output{output, randomOutput()}
         } else {
-            transactions[i].Output1 = output
-            transactions[i].Output0 = randomOutput()
+            transactions[i].Outputs = []*Output{randomOutput(), output}
         }
     }
-    tx, err := FindBestUTXOs(from, to, amount, transactions, client)
+    tx, err := FindBestUTXOs(to, amount, transactions, s, testDomain)
     require.Error(t, err)
     require.Nil(t, tx)
 }
 
 func Test_NoInput(t *testing.T) {
     rand.Seed(time.Now().Unix())
-    from   := randomAddress()
+    s      := testSigner(t)
     to     := randomAddress()
     amount := big.NewInt(101)
-    client := new(mocks.Client)
-    client.On("SignData", mock.AnythingOfType("*common.Address"), mock.AnythingOfType("[]uint8")).Return(randomSig(), nil)
     transactions := make([]Transaction, 0, size)
-    tx, err := FindBestUTXOs(from, to, amount, transactions, client)
+    tx, err := FindBestUTXOs(to, amount, transactions, s, testDomain)
     require.Error(t, err)
     require.Nil(t, tx)
 }
 
 func Test_OneInputLessThanAmount(t *testing.T) {
     rand.Seed(time.Now().Unix())
-    from   := randomAddress()
+    s      := testSigner(t)
+    from   := s.Address()
     to     := randomAddress()
     amount := big.NewInt(max + 1)
-    client := new(mocks.Client)
-    client.On("SignData", mock.AnythingOfType("*common.Address"), mock.AnythingOfType("[]uint8")).Return(randomSig(), nil)
     size := 1
     transactions := make([]Transaction, size)
     for i := 0; i < size; i++ {
@@ -223,29 +208,25 @@ func Test_OneInputLessThanAmount(t *testing.T) {
             Amount: big.NewInt(int64(rand.Intn(max))),
         }
         transactions[i] = Transaction{
-            Input0: randomInput(),
-            Input1: randomInput(),
+            Inputs: []*Input{randomInput(), randomInput()},
         }
         if outputIdx == false {
-            transactions[i].Output0 = output
-            transactions[i].Output1 = randomOutput()
+            transactions[i].Outputs = []*Output{output, randomOutput()}
         } else {
-            transactions[i].Output1 = output
-            transactions[i].Output0 = randomOutput()
+            transactions[i].Outputs = []*Output{randomOutput(), output}
         }
     }
-    tx, err := FindBestUTXOs(from, to, amount, transactions, client)
+    tx, err := FindBestUTXOs(to, amount, transactions, s, testDomain)
     require.Error(t, err)
     require.Nil(t, tx)
 }
 
 func Test_OneInput(t *testing.T) {
     rand.Seed(time.Now().Unix())
-    from   := randomAddress()
+    s      := testSigner(t)
+    from   := s.Address()
     to     := randomAddress()
     amount := big.NewInt(0)
-    client := new(mocks.Client)
-    client.On("SignData", mock.AnythingOfType("*common.Address"), mock.AnythingOfType("[]uint8")).Return(randomSig(), nil)
     transactions := make([]Transaction, 1)
     for i := 0; i < 1; i++ {
         outputIdx := rand.Float32() < 0.5
@@ -255,19 +236,16 @@ func Test_OneInput(t *testing.T) {
         }
         amount.Sub(output.Amount, big.NewInt(4))
         transactions[i] = Transaction{
-            Input0: randomInput(),
-            Input1: randomInput(),
+            Inputs: []*Input{randomInput(), randomInput()},
         }
         if outputIdx == false {
-            transactions[i].Output0 = output
-            transactions[i].Output1 = randomOutput()
+            transactions[i].Outputs = []*Output{output, randomOutput()}
         } else {
-            transactions[i].Output1 = output
-            transactions[i].Output0 = randomOutput()
+            transactions[i].Outputs = []*Output{randomOutput(), output}
         }
     }
-    tx, err := FindBestUTXOs(from, to, amount, transactions, client)
+    tx, err := FindBestUTXOs(to, amount, transactions, s, testDomain)
     require.NoError(t, err)
-    require.Equal(t, from, tx.Output1.NewOwner)
-    require.Equal(t, 0, amount.Cmp(tx.Output0.Amount))
+    require.Equal(t, from, tx.Outputs[1].NewOwner)
+    require.Equal(t, 0, amount.Cmp(tx.Outputs[0].Amount))
 }
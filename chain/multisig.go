@@ -0,0 +1,399 @@
+package chain
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/kyokan/plasma/util"
+)
+
+const (
+	// MaxOwners is the largest set of owners a multisig output can have.
+	MaxOwners = 8
+
+	// SigLength is the length of one signature. An input spending a
+	// multisig output carries the signatures of its owners concatenated.
+	SigLength = 65
+
+	outputRLPLength         = 2
+	multisigOutputRLPLength = 4
+	lockedOutputRLPLength   = 7
+	tokenOutputRLPLength    = 8
+)
+
+type rlpOutput struct {
+	NewOwner common.Address
+	Amount   *big.Int
+}
+
+type rlpMultisigOutput struct {
+	NewOwner  common.Address
+	Amount    *big.Int
+	Threshold uint8
+	Owners    []common.Address
+}
+
+type rlpLockedOutput struct {
+	NewOwner    common.Address
+	Amount      *big.Int
+	Threshold   uint8
+	Owners      []common.Address
+	HashLock    []byte
+	TimeLock    uint64
+	RefundOwner common.Address
+}
+
+type rlpTokenOutput struct {
+	NewOwner    common.Address
+	Amount      *big.Int
+	Threshold   uint8
+	Owners      []common.Address
+	HashLock    []byte
+	TimeLock    uint64
+	RefundOwner common.Address
+	TokenID     uint64
+}
+
+// NewMultisigOutput returns an output that threshold of owners must sign to
+// spend. It is paid to newOwner when it exits.
+func NewMultisigOutput(newOwner common.Address, amount *big.Int, owners []common.Address, threshold uint8) *Output {
+	return &Output{
+		NewOwner:  newOwner,
+		Amount:    new(big.Int).Set(amount),
+		Owners:    append([]common.Address(nil), owners...),
+		Threshold: threshold,
+	}
+}
+
+func (out *Output) IsMultisig() bool {
+	return len(out.Owners) > 0
+}
+
+// IsOwner returns whether addr can sign for the output: it is NewOwner, or
+// one of the Owners of a multisig output.
+func (out *Output) IsOwner(addr common.Address) bool {
+	if !out.IsMultisig() {
+		return out.NewOwner == addr
+	}
+
+	for _, owner := range out.Owners {
+		if owner == addr {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SameOwners returns whether other is locked to the same owners.
+func (out *Output) SameOwners(other *Output) bool {
+	if out.IsMultisig() != other.IsMultisig() {
+		return false
+	}
+
+	if !out.IsMultisig() {
+		return out.NewOwner == other.NewOwner
+	}
+
+	if out.Threshold != other.Threshold || len(out.Owners) != len(other.Owners) {
+		return false
+	}
+
+	for _, owner := range out.Owners {
+		if !other.IsOwner(owner) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// VerifySignature checks that sig authorizes spending the output for hash:
+// it is the signature of NewOwner, or the signatures of at least Threshold
+// distinct owners of a multisig output.
+func (out *Output) VerifySignature(hash util.Hash, sig []byte) error {
+	if len(sig) == 0 || len(sig)%SigLength != 0 {
+		return errors.New("input is not signed")
+	}
+
+	signers := make(map[common.Address]bool)
+
+	for i := 0; i < len(sig); i += SigLength {
+		pubKey, err := crypto.SigToPub(hash, sig[i:i+SigLength])
+
+		if err != nil {
+			return err
+		}
+
+		addr := crypto.PubkeyToAddress(*pubKey)
+
+		if !out.IsOwner(addr) {
+			return errors.New("signature is not valid")
+		}
+
+		if signers[addr] {
+			return errors.New("duplicate signature")
+		}
+
+		signers[addr] = true
+	}
+
+	if out.IsMultisig() && len(signers) < int(out.Threshold) {
+		return fmt.Errorf("%d of %d required signatures", len(signers), out.Threshold)
+	}
+
+	return nil
+}
+
+// ExitHash returns the hash the owners of a multisig output sign to exit it
+// from the plasma contract at contract, as its exitHash computes it.
+func ExitHash(contract common.Address, blkNum uint64, txIdx uint32, outIdx uint8) util.Hash {
+	return crypto.Keccak256(
+		contract.Bytes(),
+		common.BigToHash(new(big.Int).SetUint64(blkNum)).Bytes(),
+		common.BigToHash(big.NewInt(int64(txIdx))).Bytes(),
+		common.BigToHash(big.NewInt(int64(outIdx))).Bytes(),
+	)
+}
+
+// ExitSignatures checks that sigs hold the signatures of at least Threshold
+// distinct owners over hash, and concatenates Threshold of them in the
+// ascending order of their signers, which is how the plasma contract takes
+// them.
+func (out *Output) ExitSignatures(hash util.Hash, sigs [][]byte) ([]byte, error) {
+	if !out.IsMultisig() {
+		return nil, errors.New("output is not multisig")
+	}
+
+	bySigner := make(map[common.Address][]byte)
+	var signers []common.Address
+
+	for _, sig := range sigs {
+		if len(sig) != SigLength {
+			return nil, fmt.Errorf("signatures are %d bytes", SigLength)
+		}
+
+		pubKey, err := crypto.SigToPub(hash, sig)
+
+		if err != nil {
+			return nil, err
+		}
+
+		addr := crypto.PubkeyToAddress(*pubKey)
+
+		if !out.IsOwner(addr) {
+			return nil, fmt.Errorf("%s is not an owner", addr.Hex())
+		}
+
+		if bySigner[addr] == nil {
+			signers = append(signers, addr)
+		}
+
+		bySigner[addr] = sig
+	}
+
+	if len(signers) < int(out.Threshold) {
+		return nil, fmt.Errorf("%d of %d required signatures", len(signers), out.Threshold)
+	}
+
+	sort.Slice(signers, func(i, j int) bool {
+		return bytes.Compare(signers[i].Bytes(), signers[j].Bytes()) < 0
+	})
+
+	var res []byte
+
+	for _, addr := range signers[:out.Threshold] {
+		res = append(res, bySigner[addr]...)
+	}
+
+	return res, nil
+}
+
+func (out *Output) checkOwners() error {
+	if !out.IsMultisig() {
+		if out.Threshold != 0 {
+			return errors.New("threshold without owners")
+		}
+
+		return nil
+	}
+
+	if len(out.Owners) > MaxOwners {
+		return fmt.Errorf("outputs have at most %d owners", MaxOwners)
+	}
+
+	if out.Threshold == 0 || int(out.Threshold) > len(out.Owners) {
+		return fmt.Errorf("threshold must be between 1 and %d", len(out.Owners))
+	}
+
+	seen := make(map[common.Address]bool)
+
+	for _, owner := range out.Owners {
+		if owner == (common.Address{}) {
+			return errors.New("owners must not be empty")
+		}
+
+		if seen[owner] {
+			return errors.New("owners must be distinct")
+		}
+
+		seen[owner] = true
+	}
+
+	return nil
+}
+
+// EncodeRLP encodes the output as [owner, amount], followed by the
+// threshold and owners of a multisig output, then by the hash lock, time
+// lock and refund owner of a locked output, and then by the token id of a
+// token output.
+func (out *Output) EncodeRLP(w io.Writer) error {
+	if out.IsToken() {
+		itf := rlpTokenOutput{
+			NewOwner:  out.NewOwner,
+			Amount:    out.Amount,
+			Threshold: out.Threshold,
+			Owners:    out.Owners,
+			HashLock:  out.HashLock,
+			TimeLock:  out.TimeLock,
+			TokenID:   out.TokenID,
+		}
+
+		if out.RefundOwner != nil {
+			itf.RefundOwner = *out.RefundOwner
+		}
+
+		return rlp.Encode(w, &itf)
+	}
+
+	if out.IsLocked() {
+		itf := rlpLockedOutput{
+			NewOwner:  out.NewOwner,
+			Amount:    out.Amount,
+			Threshold: out.Threshold,
+			Owners:    out.Owners,
+			HashLock:  out.HashLock,
+			TimeLock:  out.TimeLock,
+		}
+
+		if out.RefundOwner != nil {
+			itf.RefundOwner = *out.RefundOwner
+		}
+
+		return rlp.Encode(w, &itf)
+	}
+
+	if out.IsMultisig() {
+		return rlp.Encode(w, &rlpMultisigOutput{
+			NewOwner:  out.NewOwner,
+			Amount:    out.Amount,
+			Threshold: out.Threshold,
+			Owners:    out.Owners,
+		})
+	}
+
+	return rlp.Encode(w, &rlpOutput{NewOwner: out.NewOwner, Amount: out.Amount})
+}
+
+func (out *Output) DecodeRLP(s *rlp.Stream) error {
+	raw, err := s.Raw()
+
+	if err != nil {
+		return err
+	}
+
+	content, _, err := rlp.SplitList(raw)
+
+	if err != nil {
+		return err
+	}
+
+	count, err := rlp.CountValues(content)
+
+	if err != nil {
+		return err
+	}
+
+	switch count {
+	case outputRLPLength:
+		var itf rlpOutput
+
+		if err := rlp.DecodeBytes(raw, &itf); err != nil {
+			return err
+		}
+
+		*out = Output{NewOwner: itf.NewOwner, Amount: itf.Amount}
+	case multisigOutputRLPLength:
+		var itf rlpMultisigOutput
+
+		if err := rlp.DecodeBytes(raw, &itf); err != nil {
+			return err
+		}
+
+		*out = Output{NewOwner: itf.NewOwner, Amount: itf.Amount, Threshold: itf.Threshold, Owners: itf.Owners}
+	case lockedOutputRLPLength:
+		var itf rlpLockedOutput
+
+		if err := rlp.DecodeBytes(raw, &itf); err != nil {
+			return err
+		}
+
+		*out = Output{NewOwner: itf.NewOwner, Amount: itf.Amount, TimeLock: itf.TimeLock}
+
+		if len(itf.Owners) > 0 {
+			out.Owners = itf.Owners
+			out.Threshold = itf.Threshold
+		}
+
+		if len(itf.HashLock) > 0 {
+			out.HashLock = itf.HashLock
+		}
+
+		if itf.RefundOwner != (common.Address{}) {
+			out.RefundOwner = &itf.RefundOwner
+		}
+	case tokenOutputRLPLength:
+		var itf rlpTokenOutput
+
+		if err := rlp.DecodeBytes(raw, &itf); err != nil {
+			return err
+		}
+
+		*out = Output{NewOwner: itf.NewOwner, Amount: itf.Amount, TimeLock: itf.TimeLock, TokenID: itf.TokenID}
+
+		if len(itf.Owners) > 0 {
+			out.Owners = itf.Owners
+			out.Threshold = itf.Threshold
+		}
+
+		if len(itf.HashLock) > 0 {
+			out.HashLock = itf.HashLock
+		}
+
+		if itf.RefundOwner != (common.Address{}) {
+			out.RefundOwner = &itf.RefundOwner
+		}
+	default:
+		return fmt.Errorf("output has %d fields", count)
+	}
+
+	return nil
+}
+
+// addSig appends sig to the signatures of an input, unless it is there.
+func addSig(sigs []byte, sig []byte) []byte {
+	for i := 0; i+SigLength <= len(sigs); i += SigLength {
+		if bytes.Equal(sigs[i:i+SigLength], sig) {
+			return sigs
+		}
+	}
+
+	return append(append([]byte(nil), sigs...), sig...)
+}
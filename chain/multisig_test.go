@@ -0,0 +1,120 @@
+package chain
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/kyokan/plasma/signer"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_MultisigOutputRLP(t *testing.T) {
+	owners := []common.Address{{1}, {2}, {3}}
+	encodeAndDecode(t, NewMultisigOutput(common.Address{9}, big.NewInt(100), owners, 2))
+
+	tx := NewTransaction([]*Input{randomInput(), randomInput()}, []*Output{NewMultisigOutput(common.Address{9}, big.NewInt(100), owners, 2), randomOutput()}, big.NewInt(7))
+	tx.Sigs = [][]byte{append(randomSig(), randomSig()...), randomSig()}
+	require.Equal(t, TxVersionMulti, tx.Version)
+	require.NoError(t, tx.CheckFormat())
+	encodeAndDecode(t, tx)
+
+	// The legacy format has no room for the owners.
+	tx.Version = TxVersionLegacy
+	require.Error(t, tx.CheckFormat())
+}
+
+func Test_MultisigOutputCheckOwners(t *testing.T) {
+	require.Error(t, NewMultisigOutput(common.Address{9}, big.NewInt(1), []common.Address{{1}, {2}}, 3).checkOwners())
+	require.Error(t, NewMultisigOutput(common.Address{9}, big.NewInt(1), []common.Address{{1}, {2}}, 0).checkOwners())
+	require.Error(t, NewMultisigOutput(common.Address{9}, big.NewInt(1), []common.Address{{1}, {1}}, 1).checkOwners())
+	require.NoError(t, NewMultisigOutput(common.Address{9}, big.NewInt(1), []common.Address{{1}, {2}}, 2).checkOwners())
+}
+
+func Test_MultisigSignatures(t *testing.T) {
+	var signers []signer.Signer
+	var owners []common.Address
+
+	for i := 0; i < 3; i++ {
+		s, err := signer.GenerateMemorySigner()
+		require.NoError(t, err)
+		signers = append(signers, s)
+		owners = append(owners, s.Address())
+	}
+
+	outsider, err := signer.GenerateMemorySigner()
+	require.NoError(t, err)
+
+	spent := NewMultisigOutput(common.Address{9}, big.NewInt(100), owners, 2)
+	tx := NewTransaction([]*Input{NewInput(2, 0, 0)}, []*Output{NewOutput(common.Address{1}, big.NewInt(100))}, big.NewInt(0))
+	hash := tx.SignatureHash(testDomain)
+
+	require.Error(t, spent.VerifySignature(hash, tx.SigAt(0)))
+
+	require.NoError(t, tx.Sign(signers[0], testDomain))
+	require.Error(t, spent.VerifySignature(hash, tx.SigAt(0)))
+
+	// Signing twice does not count twice.
+	require.NoError(t, tx.Sign(signers[0], testDomain))
+	require.Len(t, tx.SigAt(0), SigLength)
+
+	require.NoError(t, tx.Sign(signers[2], testDomain))
+	require.NoError(t, spent.VerifySignature(hash, tx.SigAt(0)))
+
+	require.NoError(t, tx.Sign(outsider, testDomain))
+	require.Error(t, spent.VerifySignature(hash, tx.SigAt(0)))
+}
+
+func Test_MultisigExitSignatures(t *testing.T) {
+	var signers []signer.Signer
+	var owners []common.Address
+
+	for i := 0; i < 3; i++ {
+		s, err := signer.GenerateMemorySigner()
+		require.NoError(t, err)
+		signers = append(signers, s)
+		owners = append(owners, s.Address())
+	}
+
+	outsider, err := signer.GenerateMemorySigner()
+	require.NoError(t, err)
+
+	spent := NewMultisigOutput(common.Address{9}, big.NewInt(100), owners, 2)
+	hash := ExitHash(common.Address{0xaa}, 2000, 1, 0)
+	require.NotEqual(t, hash, ExitHash(common.Address{0xbb}, 2000, 1, 0))
+
+	var sigs [][]byte
+
+	for _, s := range append([]signer.Signer{outsider}, signers...) {
+		sig, err := s.SignHash(hash)
+		require.NoError(t, err)
+		sigs = append(sigs, sig)
+	}
+
+	_, err = spent.ExitSignatures(hash, sigs[1:2])
+	require.Error(t, err)
+
+	// The same owner twice does not meet the threshold.
+	_, err = spent.ExitSignatures(hash, [][]byte{sigs[1], sigs[1]})
+	require.Error(t, err)
+
+	_, err = spent.ExitSignatures(hash, sigs)
+	require.Error(t, err)
+
+	res, err := spent.ExitSignatures(hash, [][]byte{sigs[3], sigs[1], sigs[2]})
+	require.NoError(t, err)
+	require.Len(t, res, 2*SigLength)
+	require.NoError(t, spent.VerifySignature(hash, res))
+
+	var last []byte
+
+	for i := 0; i < len(res); i += SigLength {
+		pubKey, err := crypto.SigToPub(hash, res[i:i+SigLength])
+		require.NoError(t, err)
+		addr := crypto.PubkeyToAddress(*pubKey).Bytes()
+		require.True(t, bytes.Compare(last, addr) < 0)
+		last = addr
+	}
+}
@@ -2,6 +2,7 @@ package chain
 
 import (
 	"bytes"
+	"encoding/binary"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto/sha3"
 	"github.com/kyokan/plasma/util"
@@ -12,6 +13,18 @@ import (
 type Output struct {
 	NewOwner common.Address `json:"NewOwner"`
 	Amount   *big.Int       `json:"Amount"`
+	// Owners and Threshold lock a multisig output: spending it takes the
+	// signatures of Threshold of the Owners. NewOwner receives it on exit.
+	Owners    []common.Address `json:"Owners,omitempty"`
+	Threshold uint8            `json:"Threshold,omitempty"`
+	// HashLock, TimeLock and RefundOwner lock the output, see
+	// NewLockedOutput. TimeLock is a plasma block number.
+	HashLock    []byte          `json:"HashLock,omitempty"`
+	TimeLock    uint64          `json:"TimeLock,omitempty"`
+	RefundOwner *common.Address `json:"RefundOwner,omitempty"`
+	// TokenID identifies the non-fungible token a token output holds, see
+	// NewTokenOutput.
+	TokenID uint64 `json:"TokenID,omitempty"`
 }
 
 func NewOutput(newOwner common.Address, amount *big.Int) *Output {
@@ -44,6 +57,22 @@ func (out *Output) Hash() util.Hash {
 	buf := new(bytes.Buffer)
 	buf.Write(out.NewOwner.Bytes())
 	buf.Write(out.Amount.Bytes())
+	if out.IsMultisig() {
+		buf.WriteByte(out.Threshold)
+		for _, owner := range out.Owners {
+			buf.Write(owner.Bytes())
+		}
+	}
+	if out.IsLocked() {
+		buf.Write(out.HashLock)
+		binary.Write(buf, binary.BigEndian, out.TimeLock)
+		if out.RefundOwner != nil {
+			buf.Write(out.RefundOwner.Bytes())
+		}
+	}
+	if out.IsToken() {
+		binary.Write(buf, binary.BigEndian, out.TokenID)
+	}
 	digest := sha3.Sum256(buf.Bytes())
 	return digest[:]
 }
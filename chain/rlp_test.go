@@ -1,6 +1,7 @@
 package chain
 
 import (
+	"bytes"
 	"fmt"
 	"math/big"
 	"math/rand"
@@ -8,19 +9,18 @@ import (
 	"testing"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto/sha3"
 	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/kyokan/plasma/util"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/require"
 )
 
 func Test_TransactionFullRLP(t *testing.T) {
 	tx := Transaction{
-		Input0:  randomInput(),
-		Input1:  randomInput(),
-		Sig0:    randomSig(),
-		Sig1:    randomSig(),
-		Output0: randomOutput(),
-		Output1: randomOutput(),
+		Inputs:  []*Input{randomInput(), randomInput()},
+		Sigs:    [][]byte{randomSig(), randomSig()},
+		Outputs: []*Output{randomOutput(), randomOutput()},
 		Fee:     big.NewInt(rand.Int63()),
 		BlkNum:  0, // Not encoded in RLP
 		TxIdx:   0, // Not encoded in RLP
@@ -30,12 +30,9 @@ func Test_TransactionFullRLP(t *testing.T) {
 
 func Test_TransactionFirstInputRLP(t *testing.T) {
 	tx := Transaction{
-		Input0:  randomInput(),
-		Input1:  ZeroInput(),
-		Sig0:    randomSig(),
-		Sig1:    []byte{},
-		Output0: randomOutput(),
-		Output1: ZeroOutput(),
+		Inputs:  []*Input{randomInput(), ZeroInput()},
+		Sigs:    [][]byte{randomSig(), {}},
+		Outputs: []*Output{randomOutput(), ZeroOutput()},
 		Fee:     big.NewInt(rand.Int63()),
 		BlkNum:  0,
 		TxIdx:   0,
@@ -43,6 +40,82 @@ func Test_TransactionFirstInputRLP(t *testing.T) {
 	encodeAndDecode(t, &tx)
 }
 
+func Test_TransactionMultiRLP(t *testing.T) {
+	tx := Transaction{
+		Version: TxVersionMulti,
+		Inputs:  []*Input{randomInput(), randomInput(), randomInput()},
+		Sigs:    [][]byte{randomSig(), randomSig(), randomSig()},
+		Outputs: []*Output{randomOutput(), randomOutput(), randomOutput(), randomOutput()},
+		Fee:     big.NewInt(rand.Int63()),
+	}
+	encodeAndDecode(t, &tx)
+	require.NoError(t, tx.CheckFormat())
+
+	legacy := NewTransaction(tx.Inputs[:2], tx.Outputs[:2], tx.Fee)
+	legacy.Sigs = tx.Sigs[:2]
+	require.NotEqual(t, legacy.StructHash(), (&Transaction{
+		Version: TxVersionMulti,
+		Inputs:  legacy.Inputs,
+		Outputs: legacy.Outputs,
+		Fee:     legacy.Fee,
+	}).StructHash())
+
+	tx.Inputs = append(tx.Inputs, randomInput(), randomInput())
+	require.Error(t, tx.CheckFormat())
+}
+
+func Test_TransactionMetadata(t *testing.T) {
+	tx := NewTransaction([]*Input{randomInput()}, []*Output{randomOutput()}, big.NewInt(1))
+	structHash := tx.StructHash()
+
+	tx.SetMetadata([]byte("INV-1001"))
+	tx.Sigs = [][]byte{randomSig(), {}}
+	require.Equal(t, TxVersionMulti, tx.Version)
+	require.NoError(t, tx.CheckFormat())
+	require.NotEqual(t, structHash, tx.StructHash())
+	encodeAndDecode(t, tx)
+
+	tx.Metadata = make([]byte, MaxMetadataSize+1)
+	require.Error(t, tx.CheckFormat())
+
+	legacy := NewTransaction([]*Input{randomInput()}, []*Output{randomOutput()}, big.NewInt(1))
+	legacy.Metadata = []byte("INV-1001")
+	require.Error(t, legacy.CheckFormat())
+}
+
+func Test_TransactionLegacyHashUnchanged(t *testing.T) {
+	tx := NewTransaction(
+		[]*Input{NewInput(2, 0, 0)},
+		[]*Output{NewOutput(common.Address{1}, big.NewInt(3)), NewOutput(common.Address{2}, big.NewInt(4))},
+		big.NewInt(0),
+	)
+	require.Equal(t, TxVersionLegacy, tx.Version)
+
+	tx.BlkNum = 5
+
+	buf := new(bytes.Buffer)
+	for _, h := range [][]byte{
+		NewInput(2, 0, 0).Hash(),
+		ZeroInput().Hash(),
+		NewOutput(common.Address{1}, big.NewInt(3)).Hash(),
+		NewOutput(common.Address{2}, big.NewInt(4)).Hash(),
+		{0, 0, 0, 0, 0, 0, 0, 5},
+		{0, 0, 0, 0},
+	} {
+		buf.Write(h)
+	}
+	digest := sha3.Sum256(buf.Bytes())
+	require.Equal(t, util.Hash(digest[:]), tx.Hash())
+
+	encoded, err := rlp.EncodeToBytes(tx)
+	require.NoError(t, err)
+	content, _, err := rlp.SplitList(encoded)
+	require.NoError(t, err)
+	count, err := rlp.CountValues(content)
+	require.NoError(t, err)
+	require.Equal(t, legacyRLPLength, count)
+}
+
 func Test_InputRLP(t *testing.T) {
 	input := randomInput()
 	encodeAndDecode(t, &input)
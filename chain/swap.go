@@ -0,0 +1,85 @@
+package chain
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/kyokan/plasma/signer"
+)
+
+// SwapLeg is one party's side of a swap: the UTXO it spends and the amount
+// it pays the other party. The rest of the UTXO comes back as change.
+type SwapLeg struct {
+	Owner common.Address
+	UTXO  UTXO
+	Pays  *big.Int
+}
+
+// Swap is a proposed swap. The proposer signs its input first, then the
+// counterparty reviews the transaction, signs its own input and sends it.
+// Neither payment can be included without the other.
+type Swap struct {
+	Transaction  Transaction
+	Proposer     common.Address
+	Counterparty common.Address
+	// Expires is the unix time after which the proposal is dropped.
+	Expires uint64
+}
+
+// BuildSwap returns the unsigned transaction in which proposer and
+// counterparty pay each other. Input 0 belongs to the proposer and input 1
+// to the counterparty.
+func BuildSwap(proposer, counterparty SwapLeg) (*Transaction, error) {
+	if proposer.Owner == counterparty.Owner {
+		return nil, errors.New("a swap needs two parties")
+	}
+
+	outputs := []*Output{
+		NewOutput(counterparty.Owner, proposer.Pays),
+		NewOutput(proposer.Owner, counterparty.Pays),
+	}
+
+	for _, leg := range []SwapLeg{proposer, counterparty} {
+		if leg.Pays == nil || leg.Pays.Sign() <= 0 {
+			return nil, errors.New("both parties must pay")
+		}
+
+		change := new(big.Int).Sub(leg.UTXO.Amount, leg.Pays)
+
+		if change.Sign() < 0 {
+			return nil, ErrInsufficientFunds
+		}
+
+		if change.Sign() > 0 {
+			outputs = append(outputs, NewOutput(leg.Owner, change))
+		}
+	}
+
+	inputs := []*Input{proposer.UTXO.Input(), counterparty.UTXO.Input()}
+	return NewTransaction(inputs, outputs, big.NewInt(0)), nil
+}
+
+// Sign signs the inputs of the swap that s owns, given the outputs the
+// inputs spend.
+func (swap *Swap) Sign(s signer.Signer, domain Domain, spends []*Output) error {
+	var indexes []int
+
+	for i, spend := range spends {
+		if spend != nil && spend.IsOwner(s.Address()) {
+			indexes = append(indexes, i)
+		}
+	}
+
+	if len(indexes) == 0 {
+		return errors.New("signer owns none of the inputs")
+	}
+
+	return swap.Transaction.SignInputs(s, domain, indexes...)
+}
+
+// ID identifies a swap proposal by the hash of its transaction, which does
+// not change as the parties sign it.
+func (swap *Swap) ID() common.Hash {
+	return common.BytesToHash(swap.Transaction.StructHash())
+}
@@ -0,0 +1,56 @@
+package chain
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/kyokan/plasma/signer"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_SwapNeedsBothSignatures(t *testing.T) {
+	alice, err := signer.GenerateMemorySigner()
+	require.NoError(t, err)
+	bob, err := signer.GenerateMemorySigner()
+	require.NoError(t, err)
+
+	aliceUTXO := UTXO{BlkNum: 2, Amount: big.NewInt(100)}
+	bobUTXO := UTXO{BlkNum: 3, Amount: big.NewInt(50)}
+
+	tx, err := BuildSwap(
+		SwapLeg{Owner: alice.Address(), UTXO: aliceUTXO, Pays: big.NewInt(60)},
+		SwapLeg{Owner: bob.Address(), UTXO: bobUTXO, Pays: big.NewInt(50)},
+	)
+	require.NoError(t, err)
+	require.NoError(t, tx.CheckFormat())
+
+	// Bob is paid 60 and Alice 50, Alice gets 40 back and Bob no change.
+	require.Len(t, tx.Outputs, 3)
+	require.Equal(t, bob.Address(), tx.Outputs[0].NewOwner)
+	require.Equal(t, int64(60), tx.Outputs[0].Amount.Int64())
+	require.Equal(t, alice.Address(), tx.Outputs[1].NewOwner)
+	require.Equal(t, int64(50), tx.Outputs[1].Amount.Int64())
+	require.Equal(t, int64(40), tx.Outputs[2].Amount.Int64())
+
+	spends := []*Output{NewOutput(alice.Address(), aliceUTXO.Amount), NewOutput(bob.Address(), bobUTXO.Amount)}
+	swap := &Swap{Transaction: *tx, Proposer: alice.Address(), Counterparty: bob.Address()}
+	id := swap.ID()
+	hash := swap.Transaction.SignatureHash(testDomain)
+
+	require.NoError(t, swap.Sign(alice, testDomain, spends))
+	require.NoError(t, spends[0].VerifySignature(hash, swap.Transaction.SigAt(0)))
+	require.Error(t, spends[1].VerifySignature(hash, swap.Transaction.SigAt(1)))
+
+	require.NoError(t, swap.Sign(bob, testDomain, spends))
+	require.NoError(t, spends[1].VerifySignature(hash, swap.Transaction.SigAt(1)))
+	require.NoError(t, spends[0].VerifySignature(hash, swap.Transaction.SigAt(0)))
+
+	// Signing does not change the proposal's ID.
+	require.Equal(t, id, swap.ID())
+
+	_, err = BuildSwap(
+		SwapLeg{Owner: alice.Address(), UTXO: aliceUTXO, Pays: big.NewInt(60)},
+		SwapLeg{Owner: bob.Address(), UTXO: bobUTXO, Pays: big.NewInt(51)},
+	)
+	require.Equal(t, ErrInsufficientFunds, err)
+}
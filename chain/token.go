@@ -0,0 +1,150 @@
+package chain
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/kyokan/plasma/util"
+)
+
+// TokenIDFor returns the plasma id of token id of an ERC-721 contract: the
+// low 64 bits of keccak256(contract, id), as the plasma contract computes
+// it on deposit.
+func TokenIDFor(contract common.Address, id *big.Int) uint64 {
+	hash := crypto.Keccak256(contract.Bytes(), common.LeftPadBytes(id.Bytes(), 32))
+	return binary.BigEndian.Uint64(hash[24:])
+}
+
+// NewTokenOutput returns an output holding the non-fungible token tokenID.
+// Token outputs have no amount.
+func NewTokenOutput(newOwner common.Address, tokenID uint64) *Output {
+	return &Output{
+		NewOwner: newOwner,
+		Amount:   big.NewInt(0),
+		TokenID:  tokenID,
+	}
+}
+
+// NewTokenDeposit returns the transaction crediting a deposit of tokenID to
+// owner. The depositor sends the same transaction to the plasma contract.
+func NewTokenDeposit(owner common.Address, tokenID uint64) *Transaction {
+	return NewTransaction(nil, []*Output{NewTokenOutput(owner, tokenID)}, big.NewInt(0))
+}
+
+func (out *Output) IsToken() bool {
+	return out.TokenID != 0
+}
+
+func (out *Output) checkToken() error {
+	if out.IsToken() && out.Amount != nil && out.Amount.Sign() != 0 {
+		return errors.New("token outputs have no amount")
+	}
+
+	return nil
+}
+
+// CheckTokenTransfer checks that outputs hold exactly the tokens of the
+// outputs the transaction spends, each in a single output. Tokens are only
+// created by deposits.
+func CheckTokenTransfer(spends []*Output, outputs []*Output) error {
+	unspent := make(map[uint64]bool)
+
+	for _, spend := range spends {
+		if spend != nil && spend.IsToken() {
+			unspent[spend.TokenID] = true
+		}
+	}
+
+	for i, output := range outputs {
+		if !output.IsToken() {
+			continue
+		}
+
+		if !unspent[output.TokenID] {
+			return fmt.Errorf("output %d holds token %d, which no input spends", i, output.TokenID)
+		}
+
+		delete(unspent, output.TokenID)
+	}
+
+	for tokenID := range unspent {
+		return fmt.Errorf("token %d is not sent to any output", tokenID)
+	}
+
+	return nil
+}
+
+// TokenLeaves returns the leaves of the token tree of a block holding txs:
+// the hash of the transaction that sends a token, under the token's id.
+func TokenLeaves(txs []Transaction) map[uint64]util.Hash {
+	leaves := make(map[uint64]util.Hash)
+
+	for i := range txs {
+		for _, output := range txs[i].Outputs {
+			if output.IsToken() {
+				leaves[output.TokenID] = txs[i].Hash()
+			}
+		}
+	}
+
+	return leaves
+}
+
+// TokenRoot returns the root of the token tree of a block holding txs, or
+// nil when they send no tokens.
+func TokenRoot(txs []Transaction) util.Hash {
+	leaves := TokenLeaves(txs)
+
+	if len(leaves) == 0 {
+		return nil
+	}
+
+	return util.NewSparseMerkleTree(leaves).Root()
+}
+
+// TokenProof shows what happened to a token in one block: Transaction sent
+// it, or the token was not sent at all when Transaction is nil. Proof is
+// the path from the token's leaf to the block's token root.
+type TokenProof struct {
+	BlkNum      uint64       `json:"BlkNum"`
+	Transaction *Transaction `json:"Transaction,omitempty"`
+	Proof       []util.Hash  `json:"Proof"`
+}
+
+// Verify checks the proof for tokenID against the header of its block.
+func (p *TokenProof) Verify(tokenID uint64, header *BlockHeader) error {
+	if header.Number != p.BlkNum {
+		return fmt.Errorf("proof is for block %d, not %d", p.BlkNum, header.Number)
+	}
+
+	var leaf util.Hash
+
+	if p.Transaction != nil {
+		if _, ok := p.Transaction.TokenOutput(tokenID); !ok || p.Transaction.BlkNum != p.BlkNum {
+			return fmt.Errorf("transaction does not send token %d in block %d", tokenID, p.BlkNum)
+		}
+
+		leaf = p.Transaction.Hash()
+	}
+
+	if !util.VerifySparseMerkleProof(header.TokenTreeRoot(), tokenID, leaf, p.Proof) {
+		return fmt.Errorf("invalid proof for token %d in block %d", tokenID, p.BlkNum)
+	}
+
+	return nil
+}
+
+// TokenOutput returns the index of the output holding tokenID.
+func (tx *Transaction) TokenOutput(tokenID uint64) (uint8, bool) {
+	for i, output := range tx.Outputs {
+		if output.IsToken() && output.TokenID == tokenID {
+			return uint8(i), true
+		}
+	}
+
+	return 0, false
+}
@@ -0,0 +1,88 @@
+package chain
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/kyokan/plasma/util"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_TokenOutputRLP(t *testing.T) {
+	token := NewTokenOutput(common.Address{1}, TokenIDFor(common.Address{0xee}, big.NewInt(7)))
+	encodeAndDecode(t, token)
+
+	tx := NewTransaction([]*Input{randomInput()}, []*Output{token}, big.NewInt(0))
+	tx.Sigs = [][]byte{randomSig()}
+	require.Equal(t, TxVersionMulti, tx.Version)
+	require.NoError(t, tx.CheckFormat())
+	encodeAndDecode(t, tx)
+
+	token.Amount = big.NewInt(1)
+	require.Error(t, tx.CheckFormat())
+}
+
+func Test_BlockHeaderTokenRoot(t *testing.T) {
+	header := &BlockHeader{
+		MerkleRoot:    randomSig(),
+		RLPMerkleRoot: randomSig(),
+		PrevHash:      randomSig(),
+		Number:        2,
+	}
+	encodeAndDecode(t, header)
+	hash := header.Hash()
+	require.Equal(t, util.EmptySparseMerkleRoot(), header.TokenTreeRoot())
+
+	header.TokenRoot = randomSig()
+	encodeAndDecode(t, header)
+	require.NotEqual(t, hash, header.Hash())
+	require.Equal(t, header.TokenRoot, header.TokenTreeRoot())
+}
+
+func Test_CheckTokenTransfer(t *testing.T) {
+	owner := common.Address{1}
+	token := NewTokenOutput(owner, 5)
+	coin := NewOutput(owner, big.NewInt(100))
+
+	require.NoError(t, CheckTokenTransfer([]*Output{token, coin}, []*Output{NewTokenOutput(common.Address{2}, 5), coin}))
+	require.NoError(t, CheckTokenTransfer([]*Output{coin}, []*Output{coin}))
+
+	// Tokens are neither minted, burned nor split.
+	require.Error(t, CheckTokenTransfer([]*Output{coin}, []*Output{NewTokenOutput(owner, 5)}))
+	require.Error(t, CheckTokenTransfer([]*Output{token}, []*Output{coin}))
+	require.Error(t, CheckTokenTransfer([]*Output{token}, []*Output{token, NewTokenOutput(common.Address{2}, 5)}))
+}
+
+func Test_TokenProof(t *testing.T) {
+	deposit := NewTokenDeposit(common.Address{1}, 5)
+	deposit.BlkNum = 3
+	other := NewTokenDeposit(common.Address{2}, 6)
+	other.BlkNum = 3
+	other.TxIdx = 1
+	txs := []Transaction{*deposit, *other}
+
+	tree := util.NewSparseMerkleTree(TokenLeaves(txs))
+	header := &BlockHeader{Number: 3, TokenRoot: tree.Root()}
+
+	sent := TokenProof{BlkNum: 3, Transaction: deposit, Proof: tree.Proof(5)}
+	require.NoError(t, sent.Verify(5, header))
+
+	// The block does not send token 7, and the proof shows it.
+	notSent := TokenProof{BlkNum: 3, Proof: tree.Proof(7)}
+	require.NoError(t, notSent.Verify(7, header))
+
+	// Hiding a transfer fails.
+	hidden := TokenProof{BlkNum: 3, Proof: tree.Proof(5)}
+	require.Error(t, hidden.Verify(5, header))
+	require.Error(t, sent.Verify(6, header))
+
+	wrongBlock := TokenProof{BlkNum: 4, Transaction: deposit, Proof: tree.Proof(5)}
+	require.Error(t, wrongBlock.Verify(5, header))
+
+	// Blocks without a token root send no tokens.
+	empty := util.NewSparseMerkleTree(nil)
+	require.Equal(t, util.EmptySparseMerkleRoot(), empty.Root())
+	none := TokenProof{BlkNum: 3, Proof: empty.Proof(5)}
+	require.NoError(t, none.Verify(5, &BlockHeader{Number: 3}))
+}
@@ -9,22 +9,53 @@ import (
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/crypto/sha3"
 	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/kyokan/plasma/signer"
 	"github.com/kyokan/plasma/util"
 )
 
+const (
+	// TxVersionLegacy transactions have exactly two inputs and two outputs,
+	// and are encoded as the flat 13 item list of rlpHelper.
+	TxVersionLegacy uint8 = 0
+
+	// TxVersionMulti transactions have up to MaxInputs inputs and MaxOutputs
+	// outputs, and are encoded as [version, inputs, sigs, outputs, fee],
+	// followed by the metadata and the preimages if there are any.
+	TxVersionMulti uint8 = 1
+
+	// MaxMetadataSize is the size limit of a transaction's metadata.
+	MaxMetadataSize = 128
+
+	legacyRLPLength = 13
+	multiRLPLength  = 5
+)
+
+// MaxInputs and MaxOutputs bound the size of TxVersionMulti transactions.
+var (
+	MaxInputs  = 4
+	MaxOutputs = 4
+)
+
+var ErrUnknownVersion = errors.New("unknown transaction version")
+
 // JSON tags needed for test fixtures
 type Transaction struct {
-	Input0  *Input   `json:"Input0"`
-	Sig0    []byte   `json:"Sig0"`
-	Input1  *Input   `json:"Input1"`
-	Sig1    []byte   `json:"Sig1"`
-	Output0 *Output  `json:"Output0"`
-	Output1 *Output  `json:"Output1"`
-	Fee     *big.Int `json:"Fee"`
-	BlkNum  uint64   `json:"BlkNum"`
-	TxIdx   uint32   `json:"TxIdx"`
+	Version uint8     `json:"Version"`
+	Inputs  []*Input  `json:"Inputs"`
+	Sigs    [][]byte  `json:"Sigs"`
+	Outputs []*Output `json:"Outputs"`
+	Fee     *big.Int  `json:"Fee"`
+	// Metadata is a free-form reference, such as an invoice id, covered by
+	// the signature. Only TxVersionMulti transactions have metadata.
+	Metadata []byte `json:"Metadata,omitempty"`
+	// Preimages unlock the hash-locked outputs spent by the inputs with the
+	// same index. Like signatures, they are not covered by the signature.
+	Preimages [][]byte `json:"Preimages,omitempty"`
+	BlkNum    uint64   `json:"BlkNum"`
+	TxIdx     uint32   `json:"TxIdx"`
 }
 
 type rlpHelper struct {
@@ -43,102 +74,359 @@ type rlpHelper struct {
 	Fee       big.Int
 }
 
-func (tx *Transaction) IsDeposit() bool {
-	return tx.Input0.IsZeroInput() &&
-		tx.Input1.IsZeroInput() &&
-		!tx.Output0.IsZeroOutput() &&
-		tx.Output1.IsZeroOutput()
+type rlpMultiHelper struct {
+	Version uint8
+	Inputs  []*Input
+	Sigs    [][]byte
+	Outputs []*Output
+	Fee     *big.Int
+	// Tail holds the metadata, followed by the list of preimages.
+	Tail []rlp.RawValue `rlp:"tail"`
 }
 
-func (tx *Transaction) IsZeroTransaction() bool {
-	return tx.Input0.IsZeroInput() &&
-		tx.Input1.IsZeroInput() &&
-		tx.Output0.IsZeroOutput() &&
-		tx.Output1.IsZeroOutput()
+// NewTransaction returns an unsigned transaction spending inputs to
+// outputs. It uses the legacy format, padded with zero inputs and outputs,
+// when there are at most two of each and none is a multisig, locked or
+// token output, and TxVersionMulti otherwise.
+func NewTransaction(inputs []*Input, outputs []*Output, fee *big.Int) *Transaction {
+	inputs = append([]*Input(nil), inputs...)
+	outputs = append([]*Output(nil), outputs...)
+
+	if len(inputs) > 2 || len(outputs) > 2 || hasExtendedOutputs(outputs) {
+		return &Transaction{
+			Version: TxVersionMulti,
+			Inputs:  inputs,
+			Sigs:    make([][]byte, len(inputs)),
+			Outputs: outputs,
+			Fee:     fee,
+		}
+	}
+
+	for len(inputs) < 2 {
+		inputs = append(inputs, ZeroInput())
+	}
+
+	for len(outputs) < 2 {
+		outputs = append(outputs, ZeroOutput())
+	}
+
+	return &Transaction{
+		Version: TxVersionLegacy,
+		Inputs:  inputs,
+		Sigs:    make([][]byte, 2),
+		Outputs: outputs,
+		Fee:     fee,
+	}
 }
 
-func (tx *Transaction) InputAt(idx uint8) *Input {
-	if idx != 0 && idx != 1 {
-		panic(fmt.Sprint("Invalid input index: ", idx))
+// SetMetadata attaches metadata to the transaction. Legacy transactions
+// have no room for it, so they are converted to TxVersionMulti.
+func (tx *Transaction) SetMetadata(metadata []byte) {
+	tx.Metadata = metadata
+
+	if len(metadata) > 0 && tx.Version == TxVersionLegacy {
+		tx.Version = TxVersionMulti
 	}
+}
 
-	if idx == 0 {
-		return tx.Input0
+// SetOwners locks the output at idx to threshold of owners. Legacy
+// transactions have no room for them, so they are converted to
+// TxVersionMulti.
+func (tx *Transaction) SetOwners(idx uint8, owners []common.Address, threshold uint8) error {
+	output := tx.OutputAt(idx)
+
+	if output == nil {
+		return fmt.Errorf("no output %d", idx)
 	}
 
-	return tx.Input1
+	output.Owners = append([]common.Address(nil), owners...)
+	output.Threshold = threshold
+
+	if output.IsMultisig() && tx.Version == TxVersionLegacy {
+		tx.Version = TxVersionMulti
+	}
+
+	return output.checkOwners()
 }
 
-func (tx *Transaction) OutputAt(idx uint8) *Output {
-	if idx != 0 && idx != 1 {
-		panic(fmt.Sprint("Invalid output index: ", idx))
+// hasExtendedOutputs returns whether any output is a multisig, locked or
+// token output, which the legacy format cannot encode.
+func hasExtendedOutputs(outputs []*Output) bool {
+	for _, output := range outputs {
+		if output != nil && (output.IsMultisig() || output.IsLocked() || output.IsToken()) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CheckFormat checks that the transaction has as many inputs, signatures
+// and outputs as its version allows.
+func (tx *Transaction) CheckFormat() error {
+	switch tx.Version {
+	case TxVersionLegacy:
+		if len(tx.Inputs) != 2 || len(tx.Outputs) != 2 {
+			return errors.New("legacy transactions have two inputs and two outputs")
+		}
+	case TxVersionMulti:
+		if len(tx.Inputs) == 0 || len(tx.Inputs) > MaxInputs {
+			return fmt.Errorf("transactions have between 1 and %d inputs", MaxInputs)
+		}
+
+		if len(tx.Outputs) == 0 || len(tx.Outputs) > MaxOutputs {
+			return fmt.Errorf("transactions have between 1 and %d outputs", MaxOutputs)
+		}
+	default:
+		return ErrUnknownVersion
 	}
 
-	if idx == 0 {
-		return tx.Output0
+	if len(tx.Metadata) > MaxMetadataSize {
+		return fmt.Errorf("metadata is longer than %d bytes", MaxMetadataSize)
 	}
 
-	return tx.Output1
+	if len(tx.Metadata) > 0 && tx.Version == TxVersionLegacy {
+		return errors.New("legacy transactions have no metadata")
+	}
+
+	if len(tx.Sigs) > len(tx.Inputs) {
+		return errors.New("more signatures than inputs")
+	}
+
+	for _, input := range tx.Inputs {
+		if input == nil {
+			return errors.New("missing input")
+		}
+	}
+
+	for _, output := range tx.Outputs {
+		if output == nil || output.Amount == nil {
+			return errors.New("missing output")
+		}
+
+		if err := output.checkOwners(); err != nil {
+			return err
+		}
+
+		if err := output.checkLock(); err != nil {
+			return err
+		}
+
+		if err := output.checkToken(); err != nil {
+			return err
+		}
+	}
+
+	if tx.Version == TxVersionLegacy && hasExtendedOutputs(tx.Outputs) {
+		return errors.New("legacy transactions have no multisig, locked or token outputs")
+	}
+
+	if len(tx.Preimages) > len(tx.Inputs) {
+		return errors.New("more preimages than inputs")
+	}
+
+	for _, preimage := range tx.Preimages {
+		if len(preimage) > MaxPreimageSize {
+			return fmt.Errorf("preimages are at most %d bytes", MaxPreimageSize)
+		}
+	}
+
+	if tx.Version == TxVersionLegacy && tx.hasPreimages() {
+		return errors.New("legacy transactions have no preimages")
+	}
+
+	if tx.Fee == nil {
+		return errors.New("missing fee")
+	}
+
+	return nil
 }
 
-func (tx *Transaction) OutputFor(addr *common.Address) *Output {
-	output := tx.OutputAt(0)
+// Sign signs every input of the transaction for domain. All inputs are
+// owned by the signer, so every input gets the same signature.
+func (tx *Transaction) Sign(s signer.Signer, domain Domain) error {
+	var indexes []int
 
-	if util.AddressesEqual(&output.NewOwner, addr) {
-		return output
+	for i, input := range tx.Inputs {
+		if i == 0 || !input.IsZeroInput() {
+			indexes = append(indexes, i)
+		}
 	}
 
-	output = tx.OutputAt(1)
+	return tx.SignInputs(s, domain, indexes...)
+}
 
-	if util.AddressesEqual(&output.NewOwner, addr) {
-		return output
+// SignInputs adds the signer's signature for domain to the inputs at
+// indexes. An input spending a multisig output collects the signatures of
+// its owners, so signatures are appended rather than replaced.
+func (tx *Transaction) SignInputs(s signer.Signer, domain Domain, indexes ...int) error {
+	for _, i := range indexes {
+		if i < 0 || i >= len(tx.Inputs) {
+			return fmt.Errorf("no input %d", i)
+		}
 	}
 
-	panic(fmt.Sprint("No output found for address: ", addr.Hex()))
+	hash := tx.SignatureHash(domain)
+	var sig []byte
+	var err error
+
+	if typed, ok := s.(signer.TypedDataSigner); ok {
+		sig, err = typed.SignTypedData(tx.TypedData(domain))
+	} else {
+		sig, err = s.SignHash(hash)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	// A signer that hashes the typed data itself must agree with us.
+	pubKey, err := crypto.SigToPub(hash, sig)
+
+	if err != nil {
+		return err
+	}
+
+	if crypto.PubkeyToAddress(*pubKey) != s.Address() {
+		return errors.New("signature does not match the transaction's signature hash")
+	}
+
+	if len(tx.Sigs) < len(tx.Inputs) {
+		tx.Sigs = append(tx.Sigs, make([][]byte, len(tx.Inputs)-len(tx.Sigs))...)
+	}
+
+	for _, i := range indexes {
+		tx.Sigs[i] = addSig(tx.Sigs[i], sig)
+	}
+
+	return nil
 }
 
-func (tx *Transaction) OutputIndexFor(addr *common.Address) uint8 {
-	output := tx.OutputAt(0)
+func (tx *Transaction) IsDeposit() bool {
+	for _, input := range tx.Inputs {
+		if !input.IsZeroInput() {
+			return false
+		}
+	}
 
-	if util.AddressesEqual(&output.NewOwner, addr) {
-		return 0
+	for i, output := range tx.Outputs {
+		if output.IsZeroOutput() != (i > 0) {
+			return false
+		}
 	}
 
-	output = tx.OutputAt(1)
+	return len(tx.Outputs) > 0
+}
 
-	if util.AddressesEqual(&output.NewOwner, addr) {
-		return 1
+func (tx *Transaction) IsZeroTransaction() bool {
+	for _, input := range tx.Inputs {
+		if !input.IsZeroInput() {
+			return false
+		}
+	}
+
+	for _, output := range tx.Outputs {
+		if !output.IsZeroOutput() {
+			return false
+		}
+	}
+
+	return true
+}
+
+// InputAt returns the input at idx, or nil if there is none.
+func (tx *Transaction) InputAt(idx uint8) *Input {
+	if int(idx) >= len(tx.Inputs) {
+		return nil
+	}
+
+	return tx.Inputs[idx]
+}
+
+// SigAt returns the signature of the input at idx, or nil if there is none.
+func (tx *Transaction) SigAt(idx uint8) []byte {
+	if int(idx) >= len(tx.Sigs) {
+		return nil
+	}
+
+	return tx.Sigs[idx]
+}
+
+// OutputAt returns the output at idx, or nil if there is none.
+func (tx *Transaction) OutputAt(idx uint8) *Output {
+	if int(idx) >= len(tx.Outputs) {
+		return nil
+	}
+
+	return tx.Outputs[idx]
+}
+
+func (tx *Transaction) OutputFor(addr *common.Address) *Output {
+	return tx.OutputAt(tx.OutputIndexFor(addr))
+}
+
+func (tx *Transaction) OutputIndexFor(addr *common.Address) uint8 {
+	for i, output := range tx.Outputs {
+		if util.AddressesEqual(&output.NewOwner, addr) {
+			return uint8(i)
+		}
 	}
 
 	panic(fmt.Sprint("No output found for address: ", addr.Hex()))
 }
 
+// Hash hashes the inputs with their signatures, the outputs, the metadata,
+// the preimages, the fee and the position of the transaction. Legacy
+// transactions hash as they did before versions were introduced.
 func (tx *Transaction) Hash() util.Hash {
-	values := []interface{}{
-		tx.Input0.Hash(),
-		tx.Sig0,
-		tx.Input1.Hash(),
-		tx.Sig1,
-		tx.Output0.Hash(),
-		tx.Output1.Hash(),
-		tx.Fee,
-		tx.BlkNum,
-		tx.TxIdx,
+	var values []interface{}
+
+	if tx.Version != TxVersionLegacy {
+		values = append(values, tx.Version)
 	}
 
+	for i, input := range tx.Inputs {
+		values = append(values, input.Hash(), tx.SigAt(uint8(i)))
+	}
+
+	for _, output := range tx.Outputs {
+		values = append(values, output.Hash())
+	}
+
+	values = append(values, tx.metadataHash()...)
+	values = append(values, tx.preimagesHash()...)
+	values = append(values, tx.Fee, tx.BlkNum, tx.TxIdx)
 	return doHash(values)
 }
 
-func (tx *Transaction) SignatureHash() util.Hash {
-	values := []interface{}{
-		tx.Input0.Hash(),
-		tx.Input1.Hash(),
-		tx.Output0.Hash(),
-		tx.Output1.Hash(),
-		tx.Fee,
+// metadataHash returns the hash of the metadata to include in the
+// transaction's hash. It is hashed to a fixed size, so it cannot be
+// confused with the fee next to it, and left out when empty, so that
+// transactions without metadata hash as before.
+func (tx *Transaction) metadataHash() []interface{} {
+	if len(tx.Metadata) == 0 {
+		return nil
 	}
 
-	return doHash(values)
+	digest := sha3.Sum256(tx.Metadata)
+	return []interface{}{util.Hash(digest[:])}
+}
+
+// preimagesHash returns the hash of the preimages to include in the
+// transaction's hash, or nothing when there are none.
+func (tx *Transaction) preimagesHash() []interface{} {
+	if !tx.hasPreimages() {
+		return nil
+	}
+
+	enc, err := rlp.EncodeToBytes(tx.Preimages)
+
+	if err != nil {
+		panic(err)
+	}
+
+	digest := sha3.Sum256(enc)
+	return []interface{}{util.Hash(digest[:])}
 }
 
 func doHash(values []interface{}) util.Hash {
@@ -153,7 +441,7 @@ func doHash(values []interface{}) util.Hash {
 			_, err = buf.Write(t)
 		case *big.Int:
 			_, err = buf.Write(t.Bytes())
-		case uint64, uint32:
+		case uint64, uint32, uint8:
 			err = binary.Write(buf, binary.BigEndian, t)
 		default:
 			err = errors.New("invalid component type")
@@ -179,26 +467,58 @@ func (tx *Transaction) RLPHash() util.Hash {
 }
 
 func (tx *Transaction) EncodeRLP(w io.Writer) error {
+	if tx.Version != TxVersionLegacy {
+		itf := rlpMultiHelper{
+			Version: tx.Version,
+			Inputs:  tx.Inputs,
+			Sigs:    tx.Sigs,
+			Outputs: tx.Outputs,
+			Fee:     tx.Fee,
+		}
+
+		if len(tx.Metadata) > 0 || tx.hasPreimages() {
+			metadata, err := rlp.EncodeToBytes(tx.Metadata)
+
+			if err != nil {
+				return err
+			}
+
+			itf.Tail = append(itf.Tail, metadata)
+		}
+
+		if tx.hasPreimages() {
+			preimages, err := rlp.EncodeToBytes(tx.Preimages)
+
+			if err != nil {
+				return err
+			}
+
+			itf.Tail = append(itf.Tail, preimages)
+		}
+
+		return rlp.Encode(w, &itf)
+	}
+
 	var itf rlpHelper
-	if tx.Input0 != nil {
-		itf.BlkNum0 = tx.Input0.BlkNum
-		itf.TxIdx0  = tx.Input0.TxIdx
-		itf.OutIdx0 = tx.Input0.OutIdx
-		itf.Sig0    = tx.Sig0
-	}
-	if tx.Input1 != nil {
-		itf.BlkNum1 = tx.Input1.BlkNum
-		itf.TxIdx1  = tx.Input1.TxIdx
-		itf.OutIdx1 = tx.Input1.OutIdx
-		itf.Sig1    = tx.Sig1
-	}
-	if tx.Output0 != nil {
-		itf.NewOwner0 = tx.Output0.NewOwner
-		itf.Amount0   = *tx.Output0.Amount
-	}
-	if tx.Output1 != nil {
-		itf.NewOwner1 = tx.Output1.NewOwner
-		itf.Amount1   = *tx.Output1.Amount
+	if input := tx.InputAt(0); input != nil {
+		itf.BlkNum0 = input.BlkNum
+		itf.TxIdx0 = input.TxIdx
+		itf.OutIdx0 = input.OutIdx
+		itf.Sig0 = tx.SigAt(0)
+	}
+	if input := tx.InputAt(1); input != nil {
+		itf.BlkNum1 = input.BlkNum
+		itf.TxIdx1 = input.TxIdx
+		itf.OutIdx1 = input.OutIdx
+		itf.Sig1 = tx.SigAt(1)
+	}
+	if output := tx.OutputAt(0); output != nil {
+		itf.NewOwner0 = output.NewOwner
+		itf.Amount0 = *output.Amount
+	}
+	if output := tx.OutputAt(1); output != nil {
+		itf.NewOwner1 = output.NewOwner
+		itf.Amount1 = *output.Amount
 	}
 	if tx.Fee != nil {
 		itf.Fee = *tx.Fee
@@ -206,18 +526,88 @@ func (tx *Transaction) EncodeRLP(w io.Writer) error {
 	return rlp.Encode(w, &itf)
 }
 
+// DecodeRLP decodes both formats, telling them apart by the length of the
+// list.
 func (tx *Transaction) DecodeRLP(s *rlp.Stream) error {
+	raw, err := s.Raw()
+
+	if err != nil {
+		return err
+	}
+
+	content, _, err := rlp.SplitList(raw)
+
+	if err != nil {
+		return err
+	}
+
+	count, err := rlp.CountValues(content)
+
+	if err != nil {
+		return err
+	}
+
+	switch count {
+	case legacyRLPLength:
+		return tx.decodeLegacy(raw)
+	case multiRLPLength, multiRLPLength + 1, multiRLPLength + 2:
+		return tx.decodeMulti(raw)
+	default:
+		return fmt.Errorf("transaction has %d fields", count)
+	}
+}
+
+func (tx *Transaction) decodeLegacy(raw []byte) error {
 	var itf rlpHelper
-	err := s.Decode(&itf)
+	err := rlp.DecodeBytes(raw, &itf)
 	if err != nil {
 		return err
 	}
-	tx.Input0  = NewInput(itf.BlkNum0, itf.TxIdx0, itf.OutIdx0)
-	tx.Input1  = NewInput(itf.BlkNum1, itf.TxIdx1, itf.OutIdx1)
-	tx.Output0 = NewOutput(itf.NewOwner0, &itf.Amount0)
-	tx.Output1 = NewOutput(itf.NewOwner1, &itf.Amount1)
-	tx.Sig0 = itf.Sig0
-	tx.Sig1 = itf.Sig1
-	tx.Fee  = big.NewInt(itf.Fee.Int64())
+	tx.Version = TxVersionLegacy
+	tx.Inputs = []*Input{
+		NewInput(itf.BlkNum0, itf.TxIdx0, itf.OutIdx0),
+		NewInput(itf.BlkNum1, itf.TxIdx1, itf.OutIdx1),
+	}
+	tx.Sigs = [][]byte{itf.Sig0, itf.Sig1}
+	tx.Outputs = []*Output{
+		NewOutput(itf.NewOwner0, &itf.Amount0),
+		NewOutput(itf.NewOwner1, &itf.Amount1),
+	}
+	tx.Fee = big.NewInt(itf.Fee.Int64())
 	return nil
-}
\ No newline at end of file
+}
+
+func (tx *Transaction) decodeMulti(raw []byte) error {
+	var itf rlpMultiHelper
+	err := rlp.DecodeBytes(raw, &itf)
+	if err != nil {
+		return err
+	}
+	if itf.Version != TxVersionMulti {
+		return ErrUnknownVersion
+	}
+	tx.Version = itf.Version
+	tx.Inputs = itf.Inputs
+	tx.Sigs = itf.Sigs
+	tx.Outputs = itf.Outputs
+	tx.Fee = itf.Fee
+	if len(itf.Tail) > 0 {
+		if err := rlp.DecodeBytes(itf.Tail[0], &tx.Metadata); err != nil {
+			return err
+		}
+		if len(tx.Metadata) == 0 {
+			tx.Metadata = nil
+		}
+	}
+	if len(itf.Tail) > 1 {
+		if err := rlp.DecodeBytes(itf.Tail[1], &tx.Preimages); err != nil {
+			return err
+		}
+		for i, preimage := range tx.Preimages {
+			if len(preimage) == 0 {
+				tx.Preimages[i] = nil
+			}
+		}
+	}
+	return nil
+}
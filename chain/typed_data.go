@@ -0,0 +1,228 @@
+package chain
+
+import (
+	"bytes"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/kyokan/plasma/signer"
+	"github.com/kyokan/plasma/util"
+)
+
+// Transactions are signed as EIP-712 typed data. The domain binds a
+// signature to one plasma contract on one Ethereum chain, so it cannot be
+// replayed on another deployment.
+const (
+	DomainName    = "Plasma"
+	DomainVersion = "1"
+)
+
+var (
+	domainFields = []signer.TypedDataField{
+		{Name: "name", Type: "string"},
+		{Name: "version", Type: "string"},
+		{Name: "chainId", Type: "uint256"},
+		{Name: "verifyingContract", Type: "address"},
+	}
+	inputFields = []signer.TypedDataField{
+		{Name: "blkNum", Type: "uint256"},
+		{Name: "txIdx", Type: "uint256"},
+		{Name: "outIdx", Type: "uint256"},
+	}
+	outputFields = []signer.TypedDataField{
+		{Name: "owner", Type: "address"},
+		{Name: "amount", Type: "uint256"},
+		{Name: "owners", Type: "address[]"},
+		{Name: "threshold", Type: "uint256"},
+		{Name: "hashLock", Type: "bytes"},
+		{Name: "timeLock", Type: "uint256"},
+		{Name: "refundOwner", Type: "address"},
+		{Name: "tokenId", Type: "uint256"},
+	}
+	transactionFields = []signer.TypedDataField{
+		{Name: "version", Type: "uint256"},
+		{Name: "inputs", Type: "Input[]"},
+		{Name: "outputs", Type: "Output[]"},
+		{Name: "fee", Type: "uint256"},
+		{Name: "metadata", Type: "bytes"},
+	}
+
+	domainTypeHash      = typeHash(encodeType("EIP712Domain", domainFields))
+	inputTypeHash       = typeHash(encodeType("Input", inputFields))
+	outputTypeHash      = typeHash(encodeType("Output", outputFields))
+	transactionTypeHash = typeHash(
+		encodeType("Transaction", transactionFields),
+		encodeType("Input", inputFields),
+		encodeType("Output", outputFields),
+	)
+)
+
+// Domain identifies the plasma deployment a transaction is signed for.
+type Domain struct {
+	ChainID           *big.Int       `json:"chainId"`
+	VerifyingContract common.Address `json:"verifyingContract"`
+}
+
+// Separator returns the EIP-712 domain separator.
+func (d Domain) Separator() util.Hash {
+	return crypto.Keccak256(
+		domainTypeHash,
+		crypto.Keccak256([]byte(DomainName)),
+		crypto.Keccak256([]byte(DomainVersion)),
+		encodeUint(d.ChainID),
+		encodeAddress(d.VerifyingContract),
+	)
+}
+
+// StructHash returns the EIP-712 hash of the transaction's contents,
+// without the signatures and independent of the domain. It identifies a
+// transaction before it is included in a block.
+func (tx *Transaction) StructHash() util.Hash {
+	var inputs, outputs bytes.Buffer
+
+	for _, input := range tx.Inputs {
+		inputs.Write(crypto.Keccak256(
+			inputTypeHash,
+			encodeUint(new(big.Int).SetUint64(input.BlkNum)),
+			encodeUint(big.NewInt(int64(input.TxIdx))),
+			encodeUint(big.NewInt(int64(input.OutIdx))),
+		))
+	}
+
+	for _, output := range tx.Outputs {
+		var owners bytes.Buffer
+
+		for _, owner := range output.Owners {
+			owners.Write(encodeAddress(owner))
+		}
+
+		outputs.Write(crypto.Keccak256(
+			outputTypeHash,
+			encodeAddress(output.NewOwner),
+			encodeUint(output.Amount),
+			crypto.Keccak256(owners.Bytes()),
+			encodeUint(big.NewInt(int64(output.Threshold))),
+			crypto.Keccak256(output.HashLock),
+			encodeUint(new(big.Int).SetUint64(output.TimeLock)),
+			encodeAddress(refundOwner(output)),
+			encodeUint(new(big.Int).SetUint64(output.TokenID)),
+		))
+	}
+
+	return crypto.Keccak256(
+		transactionTypeHash,
+		encodeUint(big.NewInt(int64(tx.Version))),
+		crypto.Keccak256(inputs.Bytes()),
+		crypto.Keccak256(outputs.Bytes()),
+		encodeUint(tx.Fee),
+		crypto.Keccak256(tx.Metadata),
+	)
+}
+
+// SignatureHash returns the hash the inputs' owners sign for domain.
+func (tx *Transaction) SignatureHash(domain Domain) util.Hash {
+	return crypto.Keccak256([]byte{0x19, 0x01}, domain.Separator(), tx.StructHash())
+}
+
+// TypedData returns the transaction as the typed data a wallet displays
+// before signing it.
+func (tx *Transaction) TypedData(domain Domain) *signer.TypedData {
+	inputs := make([]map[string]interface{}, len(tx.Inputs))
+	outputs := make([]map[string]interface{}, len(tx.Outputs))
+
+	for i, input := range tx.Inputs {
+		inputs[i] = map[string]interface{}{
+			"blkNum": input.BlkNum,
+			"txIdx":  input.TxIdx,
+			"outIdx": input.OutIdx,
+		}
+	}
+
+	for i, output := range tx.Outputs {
+		owners := make([]string, len(output.Owners))
+
+		for k, owner := range output.Owners {
+			owners[k] = owner.Hex()
+		}
+
+		outputs[i] = map[string]interface{}{
+			"owner":       output.NewOwner.Hex(),
+			"amount":      output.Amount.String(),
+			"owners":      owners,
+			"threshold":   output.Threshold,
+			"hashLock":    hexutil.Bytes(output.HashLock),
+			"timeLock":    output.TimeLock,
+			"refundOwner": refundOwner(output).Hex(),
+			"tokenId":     output.TokenID,
+		}
+	}
+
+	fee := "0"
+
+	if tx.Fee != nil {
+		fee = tx.Fee.String()
+	}
+
+	return &signer.TypedData{
+		Types: map[string][]signer.TypedDataField{
+			"EIP712Domain": domainFields,
+			"Transaction":  transactionFields,
+			"Input":        inputFields,
+			"Output":       outputFields,
+		},
+		PrimaryType: "Transaction",
+		Domain: map[string]interface{}{
+			"name":              DomainName,
+			"version":           DomainVersion,
+			"chainId":           domain.ChainID.String(),
+			"verifyingContract": domain.VerifyingContract.Hex(),
+		},
+		Message: map[string]interface{}{
+			"version":  tx.Version,
+			"inputs":   inputs,
+			"outputs":  outputs,
+			"fee":      fee,
+			"metadata": hexutil.Bytes(tx.Metadata),
+		},
+	}
+}
+
+func refundOwner(output *Output) common.Address {
+	if output.RefundOwner == nil {
+		return common.Address{}
+	}
+
+	return *output.RefundOwner
+}
+
+// typeHash hashes the encoding of a struct type followed by the types it
+// references, in alphabetical order.
+func typeHash(types ...string) []byte {
+	return crypto.Keccak256([]byte(strings.Join(types, "")))
+}
+
+func encodeType(name string, fields []signer.TypedDataField) string {
+	members := make([]string, len(fields))
+
+	for i, field := range fields {
+		members[i] = field.Type + " " + field.Name
+	}
+
+	return name + "(" + strings.Join(members, ",") + ")"
+}
+
+func encodeUint(x *big.Int) []byte {
+	if x == nil {
+		return make([]byte, 32)
+	}
+
+	return math.PaddedBigBytes(math.U256(new(big.Int).Set(x)), 32)
+}
+
+func encodeAddress(addr common.Address) []byte {
+	return common.LeftPadBytes(addr.Bytes(), 32)
+}
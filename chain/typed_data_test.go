@@ -0,0 +1,42 @@
+package chain
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/kyokan/plasma/signer"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_DomainTypeHash(t *testing.T) {
+	// The EIP712Domain type hash given in EIP-712.
+	require.Equal(t, common.HexToHash("0x8b73c3c69bb8fe3d512ecc4cf759cc79239f7b179b0ffacaa9a75d522b39400f").Bytes(), domainTypeHash)
+}
+
+func Test_SignatureIsBoundToDomain(t *testing.T) {
+	s, err := signer.GenerateMemorySigner()
+	require.NoError(t, err)
+
+	mainnet := Domain{ChainID: big.NewInt(1), VerifyingContract: common.Address{0xaa}}
+	testnet := Domain{ChainID: big.NewInt(3), VerifyingContract: common.Address{0xaa}}
+	otherContract := Domain{ChainID: big.NewInt(1), VerifyingContract: common.Address{0xbb}}
+
+	tx := NewTransaction([]*Input{NewInput(2, 0, 0)}, []*Output{NewOutput(common.Address{1}, big.NewInt(3))}, big.NewInt(0))
+	require.NoError(t, tx.Sign(s, mainnet))
+
+	recovered := func(domain Domain) common.Address {
+		pubKey, err := crypto.SigToPub(tx.SignatureHash(domain), tx.SigAt(0))
+		require.NoError(t, err)
+		return crypto.PubkeyToAddress(*pubKey)
+	}
+
+	require.Equal(t, s.Address(), recovered(mainnet))
+	require.NotEqual(t, s.Address(), recovered(testnet))
+	require.NotEqual(t, s.Address(), recovered(otherContract))
+
+	data := tx.TypedData(mainnet)
+	require.Equal(t, "Transaction", data.PrimaryType)
+	require.Equal(t, "1", data.Domain["chainId"])
+}
@@ -2,7 +2,9 @@ package main
 
 import (
 	"os"
+	"time"
 
+	"github.com/kyokan/plasma/chain"
 	"github.com/kyokan/plasma/db"
 	"github.com/kyokan/plasma/plasma"
 	db_tests "github.com/kyokan/plasma/tester/db"
@@ -31,10 +33,28 @@ func main() {
 			Name:  "node-url",
 			Usage: "Full URL to a running geth node.",
 		}),
+		altsrc.NewStringFlag(cli.StringFlag{
+			Name:  "node-ws-url",
+			Usage: "WebSocket URL of the geth node, used to subscribe to plasma contract events. Events are polled over node-url when it is not set or cannot be reached.",
+		}),
+		altsrc.NewDurationFlag(cli.DurationFlag{
+			Name:  "event-poll-interval",
+			Value: 10 * time.Second,
+			Usage: "Interval at which plasma contract events are polled while there is no WebSocket subscription.",
+		}),
+		altsrc.NewDurationFlag(cli.DurationFlag{
+			Name:  "event-reconnect-interval",
+			Value: 30 * time.Second,
+			Usage: "Interval at which a lost WebSocket subscription is redialed.",
+		}),
 		altsrc.NewStringFlag(cli.StringFlag{
 			Name:  "contract-addr",
 			Usage: "Plasma contract address.",
 		}),
+		altsrc.NewIntFlag(cli.IntFlag{
+			Name:  "chain-id",
+			Usage: "Ethereum chain id, part of the domain plasma transactions are signed for. Read from node-url when not set.",
+		}),
 		altsrc.NewStringFlag(cli.StringFlag{
 			Name:  "priority-queue-contract-addr",
 			Usage: "Plasma contract address.",
@@ -53,15 +73,72 @@ func main() {
 		}),
 		altsrc.NewStringFlag(cli.StringFlag{
 			Name:  "private-key",
-			Usage: "Private key of user address.",
+			Usage: "Private key of user address. Only read by the test commands; use a signer instead.",
+		}),
+		altsrc.NewStringFlag(cli.StringFlag{
+			Name:  "signer",
+			Value: "keystore",
+			Usage: "Signer for user address: keystore, which unlocks the account in keystore-dir, or external, which sends signing requests to signer-url.",
+		}),
+		altsrc.NewStringFlag(cli.StringFlag{
+			Name:  "signer-url",
+			Usage: "HTTP or WebSocket URL, or IPC socket path, of the external signer.",
+		}),
+		altsrc.NewStringFlag(cli.StringFlag{
+			Name:  "sign-passphrase-file",
+			Usage: "File containing the passphrase of the keystore account.",
 		}),
 		altsrc.NewStringFlag(cli.StringFlag{
 			Name:  "sign-passphrase",
-			Usage: "Passphrase for keystore file.",
+			Usage: "Passphrase for keystore file. Prefer sign-passphrase-file, or set it in the config file.",
+		}),
+		altsrc.NewStringFlag(cli.StringFlag{
+			Name:  "wallet",
+			Usage: "HD wallet file. When set, balance, send and exit operate across the wallet's accounts instead of user-address.",
+		}),
+		altsrc.NewStringFlag(cli.StringFlag{
+			Name:  "wallet-passphrase-file",
+			Usage: "File containing the passphrase the wallet is encrypted with, or - for stdin.",
+		}),
+		altsrc.NewIntFlag(cli.IntFlag{
+			Name:  "eth-confirmations",
+			Value: 1,
+			Usage: "Blocks required on top of an Ethereum transaction before it is considered final.",
+		}),
+		altsrc.NewDurationFlag(cli.DurationFlag{
+			Name:  "eth-gas-bump-interval",
+			Value: 2 * time.Minute,
+			Usage: "Time after which an Ethereum transaction that is not mined is resent with a higher gas price. 0 disables gas bumping.",
+		}),
+		altsrc.NewIntFlag(cli.IntFlag{
+			Name:  "eth-gas-bump-percent",
+			Value: 20,
+			Usage: "Percentage the gas price is raised by when a transaction is resent.",
 		}),
-		altsrc.NewBoolFlag(cli.BoolFlag{
-			Name:  "use-geth",
-			Usage: "Use geth to sign transactions.",
+		altsrc.NewFloat64Flag(cli.Float64Flag{
+			Name:  "eth-max-gas-price",
+			Value: 200,
+			Usage: "Maximum gas price in gwei.",
+		}),
+		altsrc.NewIntFlag(cli.IntFlag{
+			Name:  "eth-gas-limit-margin",
+			Value: 20,
+			Usage: "Percentage added to the estimated gas of a transaction.",
+		}),
+		altsrc.NewDurationFlag(cli.DurationFlag{
+			Name:  "eth-poll-interval",
+			Value: 5 * time.Second,
+			Usage: "Interval at which Ethereum transaction receipts are polled.",
+		}),
+		altsrc.NewIntFlag(cli.IntFlag{
+			Name:  "max-tx-inputs",
+			Value: chain.MaxInputs,
+			Usage: "Maximum number of inputs of a versioned transaction.",
+		}),
+		altsrc.NewIntFlag(cli.IntFlag{
+			Name:  "max-tx-outputs",
+			Value: chain.MaxOutputs,
+			Usage: "Maximum number of outputs of a versioned transaction.",
 		}),
 	}
 
@@ -73,7 +150,86 @@ func main() {
 		return &altsrc.MapInputSource{}, nil
 	}
 
-	app.Before = altsrc.InitInputSourceWithContext(flags, loadCfgFn)
+	// Commands read the same config file as the global flags.
+	loadCommandCfgFn := func(context *cli.Context) (altsrc.InputSourceContext, error) {
+		cfgFilePath := context.GlobalString("config")
+		if cfgFilePath != "" {
+			return altsrc.NewYamlSourceFromFile(cfgFilePath)
+		}
+		return &altsrc.MapInputSource{}, nil
+	}
+
+	startFlags := []cli.Flag{
+		altsrc.NewIntFlag(cli.IntFlag{
+			Name:  "rpc-port",
+			Value: 8643,
+			Usage: "Port for the RPC server to listen on.",
+		}),
+		altsrc.NewStringSliceFlag(cli.StringSliceFlag{
+			Name:  "rpc-api-keys",
+			Usage: "API keys allowed to call privileged RPC methods.",
+		}),
+		altsrc.NewStringFlag(cli.StringFlag{
+			Name:  "rpc-jwt-secret",
+			Usage: "Secret used to verify HS256 JWTs for privileged RPC methods.",
+		}),
+		altsrc.NewStringSliceFlag(cli.StringSliceFlag{
+			Name:  "rpc-privileged-methods",
+			Usage: "RPC methods that require an API key or JWT. Defaults to Transaction.Send, Swap.Propose and Swap.Accept.",
+		}),
+		altsrc.NewFloat64Flag(cli.Float64Flag{
+			Name:  "rpc-ip-rate-limit",
+			Usage: "Requests per second allowed per client IP. 0 disables the limit.",
+		}),
+		altsrc.NewIntFlag(cli.IntFlag{
+			Name:  "rpc-ip-rate-burst",
+			Value: 20,
+			Usage: "Burst of requests allowed per client IP.",
+		}),
+		altsrc.NewFloat64Flag(cli.Float64Flag{
+			Name:  "rpc-address-rate-limit",
+			Usage: "Sends per second allowed per sending address. 0 disables the limit.",
+		}),
+		altsrc.NewIntFlag(cli.IntFlag{
+			Name:  "rpc-address-rate-burst",
+			Value: 5,
+			Usage: "Burst of sends allowed per sending address.",
+		}),
+		altsrc.NewIntFlag(cli.IntFlag{
+			Name:  "rpc-max-request-size",
+			Value: 1024 * 1024,
+			Usage: "Maximum RPC request size in bytes. 0 disables the limit.",
+		}),
+		altsrc.NewStringFlag(cli.StringFlag{
+			Name:  "rpc-tls-cert",
+			Usage: "TLS certificate file for the RPC server.",
+		}),
+		altsrc.NewStringFlag(cli.StringFlag{
+			Name:  "rpc-tls-key",
+			Usage: "TLS key file for the RPC server.",
+		}),
+		altsrc.NewIntFlag(cli.IntFlag{
+			Name:  "max-submission-lag",
+			Value: 1,
+			Usage: "Number of blocks that may wait to be mined before block production pauses.",
+		}),
+		altsrc.NewDurationFlag(cli.DurationFlag{
+			Name:  "submission-retry-interval",
+			Value: 30 * time.Second,
+			Usage: "Time to wait before retrying a failed block submission.",
+		}),
+	}
+
+	initCfg := altsrc.InitInputSourceWithContext(flags, loadCfgFn)
+	app.Before = func(c *cli.Context) error {
+		if err := initCfg(c); err != nil {
+			return err
+		}
+
+		chain.MaxInputs = c.Int("max-tx-inputs")
+		chain.MaxOutputs = c.Int("max-tx-outputs")
+		return nil
+	}
 	app.Flags = flags
 
 	app.Name = "Plasma"
@@ -83,13 +239,8 @@ func main() {
 			Name:   "start",
 			Usage:  "Starts running a Plasma root node.",
 			Action: plasma.Start,
-			Flags: []cli.Flag{
-				cli.IntFlag{
-					Name:  "rpc-port",
-					Value: 8643,
-					Usage: "Port for the RPC server to listen on.",
-				},
-			},
+			Before: altsrc.InitInputSourceWithContext(startFlags, loadCommandCfgFn),
+			Flags:  startFlags,
 		},
 		{
 			Name:   "validate",
@@ -145,6 +296,21 @@ func main() {
 				},
 			},
 		},
+		{
+			Name:   "deposit-nft",
+			Usage:  "Deposits an ERC-721 token. The token contract must have approved the plasma contract to transfer it.",
+			Action: userclient.DepositNFTCLI,
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "token",
+					Usage: "Address of the ERC-721 contract.",
+				},
+				cli.StringFlag{
+					Name:  "id",
+					Usage: "Id of the token in its contract.",
+				},
+			},
+		},
 		{
 			Name:   "exit",
 			Usage:  "Runs exit started",
@@ -167,6 +333,33 @@ func main() {
 					Name:  "oindex",
 					Usage: "Output to exit.",
 				},
+				cli.StringSliceFlag{
+					Name:  "sig",
+					Usage: "Signature of an owner of a multisig output over its exit, from sign-exit. Repeat for each owner; owners in the wallet sign too.",
+				},
+				cli.StringFlag{
+					Name:  "preimage",
+					Usage: "Hex preimage of the hash lock, to exit a hash-locked output to its owner before its time lock.",
+				},
+			},
+		},
+		{
+			Name:   "sign-exit",
+			Usage:  "Signs the exit of a multisig output as one of its owners",
+			Action: userclient.SignExitCLI,
+			Flags: []cli.Flag{
+				cli.IntFlag{
+					Name:  "blocknum",
+					Usage: "Block to exit.",
+				},
+				cli.IntFlag{
+					Name:  "txindex",
+					Usage: "Transaction to exit.",
+				},
+				cli.IntFlag{
+					Name:  "oindex",
+					Usage: "Output to exit.",
+				},
 			},
 		},
 		{
@@ -178,6 +371,53 @@ func main() {
 			Name:   "balance",
 			Usage:  "Runs get balance",
 			Action: userclient.GetBalance,
+			Flags: []cli.Flag{
+				cli.IntFlag{
+					Name:  "root-port",
+					Value: 8643,
+					Usage: "Port for the root server to listen on.",
+				},
+			},
+		},
+		{
+			Name:  "wallet",
+			Usage: "Manages the HD wallet.",
+			Subcommands: []cli.Command{
+				{
+					Name:   "create",
+					Usage:  "Creates a wallet for a new mnemonic.",
+					Action: userclient.WalletCreateCLI,
+				},
+				{
+					Name:   "import",
+					Usage:  "Creates a wallet for an existing mnemonic.",
+					Action: userclient.WalletImportCLI,
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "mnemonic-file",
+							Value: "-",
+							Usage: "File containing the mnemonic, or - for stdin.",
+						},
+					},
+				},
+				{
+					Name:   "list",
+					Usage:  "Lists the wallet's addresses.",
+					Action: userclient.WalletListCLI,
+				},
+				{
+					Name:   "derive",
+					Usage:  "Derives new addresses.",
+					Action: userclient.WalletDeriveCLI,
+					Flags: []cli.Flag{
+						cli.IntFlag{
+							Name:  "count",
+							Value: 1,
+							Usage: "Number of addresses to derive.",
+						},
+					},
+				},
+			},
 		},
 		{
 			Name:   "block",
@@ -213,6 +453,346 @@ func main() {
 					Name:  "amount",
 					Usage: "Amont to send.",
 				},
+				cli.StringFlag{
+					Name:  "memo",
+					Usage: "Reference attached to the payment, such as an invoice id.",
+				},
+				cli.StringFlag{
+					Name:  "strategy",
+					Value: "smallest",
+					Usage: "Coin selection strategy: exact, smallest, largest or privacy. Outputs are consolidated first when no two of them cover the amount.",
+				},
+				cli.DurationFlag{
+					Name:  "inclusion-timeout",
+					Value: 5 * time.Minute,
+					Usage: "Time to wait for consolidating transactions to be included in blocks.",
+				},
+			},
+		},
+		{
+			Name:   "consolidate",
+			Usage:  "Merges the UTXOs of user-address, or of every wallet account, down to a target count.",
+			Action: userclient.ConsolidateCLI,
+			Flags: []cli.Flag{
+				cli.IntFlag{
+					Name:  "root-port",
+					Value: 8643,
+					Usage: "Port for the root server to listen on.",
+				},
+				cli.IntFlag{
+					Name:  "target",
+					Value: 1,
+					Usage: "Number of outputs to merge down to.",
+				},
+				cli.DurationFlag{
+					Name:  "inclusion-timeout",
+					Value: 10 * time.Minute,
+					Usage: "Time to wait for the merges to be included in blocks.",
+				},
+			},
+		},
+		{
+			Name:  "tx",
+			Usage: "Builds, signs and broadcasts transactions in separate steps, so they can be signed offline.",
+			Subcommands: []cli.Command{
+				{
+					Name:   "build",
+					Usage:  "Writes an unsigned payment from user-address to a file.",
+					Action: userclient.TxBuildCLI,
+					Flags: []cli.Flag{
+						cli.IntFlag{
+							Name:  "root-port",
+							Value: 8643,
+							Usage: "Port for the root server to listen on.",
+						},
+						cli.StringFlag{
+							Name:  "to",
+							Usage: "Recipient.",
+						},
+						cli.IntFlag{
+							Name:  "amount",
+							Usage: "Amount to send.",
+						},
+						cli.StringFlag{
+							Name:  "memo",
+							Usage: "Reference attached to the payment, such as an invoice id.",
+						},
+						cli.StringFlag{
+							Name:  "from",
+							Usage: "Spend the multisig outputs paid to this address instead of user-address's outputs.",
+						},
+						cli.StringSliceFlag{
+							Name:  "owner",
+							Usage: "Lock the payment to a set of owners. Repeat for each owner; to receives it on exit.",
+						},
+						cli.IntFlag{
+							Name:  "threshold",
+							Value: 1,
+							Usage: "Number of owners that must sign to spend a payment locked to owners.",
+						},
+						cli.StringFlag{
+							Name:  "out",
+							Value: "unsigned-tx.json",
+							Usage: "File to write the unsigned transaction to.",
+						},
+					},
+				},
+				{
+					Name:   "sign",
+					Usage:  "Signs the inputs of a transaction file that the configured signer owns.",
+					Action: userclient.TxSignCLI,
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "in",
+							Value: "unsigned-tx.json",
+							Usage: "Unsigned transaction file.",
+						},
+						cli.StringFlag{
+							Name:  "out",
+							Value: "signed-tx.json",
+							Usage: "File to write the signed transaction to.",
+						},
+						cli.Int64Flag{
+							Name:  "max-fee",
+							Usage: "Refuse to sign a transaction whose fee is above this.",
+						},
+					},
+				},
+				{
+					Name:   "broadcast",
+					Usage:  "Sends a signed transaction file to the root node.",
+					Action: userclient.TxBroadcastCLI,
+					Flags: []cli.Flag{
+						cli.IntFlag{
+							Name:  "root-port",
+							Value: 8643,
+							Usage: "Port for the root server to listen on.",
+						},
+						cli.StringFlag{
+							Name:  "in",
+							Value: "signed-tx.json",
+							Usage: "Signed transaction file.",
+						},
+					},
+				},
+				{
+					Name:   "find",
+					Usage:  "Prints the transactions carrying a memo.",
+					Action: userclient.TxFindCLI,
+					Flags: []cli.Flag{
+						cli.IntFlag{
+							Name:  "root-port",
+							Value: 8643,
+							Usage: "Port for the root server to listen on.",
+						},
+						cli.StringFlag{
+							Name:  "memo",
+							Usage: "Memo to look up. Only exact matches are returned.",
+						},
+					},
+				},
+			},
+		},
+		{
+			Name:  "swap",
+			Usage: "Swaps payments with another user in a single transaction.",
+			Subcommands: []cli.Command{
+				{
+					Name:   "propose",
+					Usage:  "Signs and posts a swap of pay from user-address for receive from counterparty.",
+					Action: userclient.SwapProposeCLI,
+					Flags: []cli.Flag{
+						cli.IntFlag{
+							Name:  "root-port",
+							Value: 8643,
+							Usage: "Port for the root server to listen on.",
+						},
+						cli.StringFlag{
+							Name:  "counterparty",
+							Usage: "User to swap with.",
+						},
+						cli.IntFlag{
+							Name:  "pay",
+							Usage: "Amount paid to the counterparty.",
+						},
+						cli.IntFlag{
+							Name:  "receive",
+							Usage: "Amount received from the counterparty.",
+						},
+					},
+				},
+				{
+					Name:   "list",
+					Usage:  "Prints the swaps proposed to user-address.",
+					Action: userclient.SwapListCLI,
+					Flags: []cli.Flag{
+						cli.IntFlag{
+							Name:  "root-port",
+							Value: 8643,
+							Usage: "Port for the root server to listen on.",
+						},
+					},
+				},
+				{
+					Name:   "accept",
+					Usage:  "Signs a swap proposed to user-address and sends it.",
+					Action: userclient.SwapAcceptCLI,
+					Flags: []cli.Flag{
+						cli.IntFlag{
+							Name:  "root-port",
+							Value: 8643,
+							Usage: "Port for the root server to listen on.",
+						},
+						cli.StringFlag{
+							Name:  "id",
+							Usage: "ID of the swap, as printed by swap list.",
+						},
+					},
+				},
+			},
+		},
+		{
+			Name:  "token",
+			Usage: "Sends deposited non-fungible tokens and proves their history.",
+			Subcommands: []cli.Command{
+				{
+					Name:   "send",
+					Usage:  "Sends a token owned by user-address.",
+					Action: userclient.TokenSendCLI,
+					Flags: []cli.Flag{
+						cli.IntFlag{
+							Name:  "root-port",
+							Value: 8643,
+							Usage: "Port for the root server to listen on.",
+						},
+						cli.StringFlag{
+							Name:  "token-id",
+							Usage: "Plasma id of the token.",
+						},
+						cli.StringFlag{
+							Name:  "to",
+							Usage: "Recipient.",
+						},
+					},
+				},
+				{
+					Name:   "history",
+					Usage:  "Prints the verified history of a token since its deposit.",
+					Action: userclient.TokenHistoryCLI,
+					Flags: []cli.Flag{
+						cli.IntFlag{
+							Name:  "root-port",
+							Value: 8643,
+							Usage: "Port for the root server to listen on.",
+						},
+						cli.StringFlag{
+							Name:  "token-id",
+							Usage: "Plasma id of the token.",
+						},
+					},
+				},
+				{
+					Name:   "withdraw",
+					Usage:  "Sends a token whose exit is finalized to the owner of the exit.",
+					Action: userclient.TokenWithdrawCLI,
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "token-id",
+							Usage: "Plasma id of the token.",
+						},
+					},
+				},
+			},
+		},
+		{
+			Name:  "lock",
+			Usage: "Sends, claims and refunds payments locked by a hash or a block number.",
+			Subcommands: []cli.Command{
+				{
+					Name:   "preimage",
+					Usage:  "Prints a random preimage and its hash lock.",
+					Action: userclient.LockPreimageCLI,
+				},
+				{
+					Name:   "send",
+					Usage:  "Sends a locked payment from user-address. A hash-locked payment with a time lock is refunded to user-address after it.",
+					Action: userclient.LockSendCLI,
+					Flags: []cli.Flag{
+						cli.IntFlag{
+							Name:  "root-port",
+							Value: 8643,
+							Usage: "Port for the root server to listen on.",
+						},
+						cli.StringFlag{
+							Name:  "to",
+							Usage: "Recipient of the payment.",
+						},
+						cli.IntFlag{
+							Name:  "amount",
+							Usage: "Amount to send.",
+						},
+						cli.StringFlag{
+							Name:  "hash-lock",
+							Usage: "SHA-256 hash of the preimage the recipient claims the payment with.",
+						},
+						cli.IntFlag{
+							Name:  "time-lock",
+							Usage: "Plasma block after which the payment is released, or refunded if it is hash-locked.",
+						},
+					},
+				},
+				{
+					Name:   "claim",
+					Usage:  "Sends a locked output paid to user-address to itself, with the preimage of its hash lock.",
+					Action: userclient.LockClaimCLI,
+					Flags: []cli.Flag{
+						cli.IntFlag{
+							Name:  "root-port",
+							Value: 8643,
+							Usage: "Port for the root server to listen on.",
+						},
+						cli.IntFlag{
+							Name:  "blocknum",
+							Usage: "Block of the locked output.",
+						},
+						cli.IntFlag{
+							Name:  "txindex",
+							Usage: "Transaction of the locked output.",
+						},
+						cli.IntFlag{
+							Name:  "oindex",
+							Usage: "Index of the locked output.",
+						},
+						cli.StringFlag{
+							Name:  "preimage",
+							Usage: "Preimage of the hash lock, as printed by lock preimage.",
+						},
+					},
+				},
+				{
+					Name:   "refund",
+					Usage:  "Sends a hash-locked output refunded to user-address back to itself once its time lock has passed.",
+					Action: userclient.LockRefundCLI,
+					Flags: []cli.Flag{
+						cli.IntFlag{
+							Name:  "root-port",
+							Value: 8643,
+							Usage: "Port for the root server to listen on.",
+						},
+						cli.IntFlag{
+							Name:  "blocknum",
+							Usage: "Block of the locked output.",
+						},
+						cli.IntFlag{
+							Name:  "txindex",
+							Usage: "Transaction of the locked output.",
+						},
+						cli.IntFlag{
+							Name:  "oindex",
+							Usage: "Index of the locked output.",
+						},
+					},
+				},
 			},
 		},
 		{
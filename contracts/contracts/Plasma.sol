@@ -5,13 +5,17 @@ import './PriorityQueue.sol';
 import './libraries/RLP.sol';
 import './libraries/SafeMath.sol';
 
+interface ERC721 {
+    function transferFrom(address from, address to, uint256 tokenId) external;
+}
+
 contract Plasma {
     using SafeMath for uint256;
     using RLP for bytes;
     using RLP for RLP.RLPItem;
     using RLP for RLP.Iterator;
 
-    event Deposit(address sender, uint value);
+    event Deposit(address sender, uint value, uint blocknum, uint tokenId);
     event SubmitBlock(address sender, bytes32 root);
     event ExitStarted(address sender, uint exitId);
     event ChallengeSuccess(address sender, uint exitId);
@@ -23,17 +27,48 @@ contract Plasma {
     event DebugUint(address sender, uint item);
     event DebugBool(address sender, bool item);
 
+    // Legacy transactions are a flat list of two inputs with their
+    // signatures, two outputs and the fee. Versioned transactions are
+    // [version, [[blknum, txindex, oindex], ...], [sig, ...], [[owner, amount], ...], fee].
+    // A multisig output is [owner, amount, threshold, [owner, ...]]. Its exit
+    // pays out to owner, and takes the signatures of threshold of its owners
+    // over exitHash, see startMultisigExit.
+    // A locked output is [owner, amount, threshold, [owner, ...], hashLock,
+    // timeLock, refund]. It exits to owner once the child chain is past
+    // timeLock, unless it is hash-locked: then it exits to refund, and until
+    // timeLock owner exits it with the preimage, see startClaimExit.
+    // A token output is a locked output followed by tokenId, the plasma id
+    // of a deposited ERC-721 token: the low 64 bits of keccak256(token, id).
+    // It has no amount, and once its exit is finalized the exit's owner
+    // withdraws the token.
+    uint constant LEGACY_TX_LENGTH = 13;
+
+    // Submitted blocks are numbered CHILD_BLOCK_INTERVAL apart, and deposit
+    // blocks take the numbers in between. A deposit mined while a block is
+    // waiting to be submitted never takes that block's number.
+    uint constant CHILD_BLOCK_INTERVAL = 1000;
+
     address public authority;
     mapping(uint256 => ChildBlock) public childChain;
     mapping(uint256 => Exit) public exits;
     uint256 public currentChildBlock;
+    uint256 public currentDepositBlock;
     PriorityQueue public exitQueue;
     uint256 public lastExitId;
     uint256 public lastFinalizedTime;
+    mapping(uint64 => Token) tokens;
+    mapping(uint256 => uint64) exitTokens;
+    mapping(uint64 => address) tokenWithdrawals;
 
     struct ChildBlock {
         bytes32 root;
         uint256 created_at;
+        bytes32 tokenRoot;
+    }
+
+    struct Token {
+        address token;
+        uint256 id;
     }
 
     struct Exit {
@@ -47,18 +82,24 @@ contract Plasma {
 
     constructor() {
         authority = msg.sender;
-        currentChildBlock = 1;
+        currentChildBlock = CHILD_BLOCK_INTERVAL;
+        currentDepositBlock = 1;
         lastFinalizedTime = block.timestamp;
         exitQueue = new PriorityQueue();
     }
 
-    function submitBlock(bytes32 root) public {
+    // tokenRoot is the root of the block's token tree, zero when the block
+    // sends no tokens. Deposit blocks have none, their token tree follows
+    // from the deposit.
+    function submitBlock(bytes32 root, bytes32 tokenRoot) public {
         require(msg.sender == authority);
         childChain[currentChildBlock] = ChildBlock({
             root: root,
-            created_at: block.timestamp
+            created_at: block.timestamp,
+            tokenRoot: tokenRoot
         });
-        currentChildBlock = currentChildBlock.add(1);
+        currentChildBlock = currentChildBlock.add(CHILD_BLOCK_INTERVAL);
+        currentDepositBlock = 1;
 
         SubmitBlock(msg.sender, root);
     }
@@ -66,31 +107,153 @@ contract Plasma {
     function getBlock(uint256 blocknum)
         public
         view
-        returns (bytes32, uint256)
+        returns (bytes32, uint256, bytes32)
     {
         ChildBlock memory blk = childChain[blocknum];
-        return (blk.root, blk.created_at);
+        return (blk.root, blk.created_at, blk.tokenRoot);
     }
 
     function deposit(bytes txBytes) public payable {
         RLP.RLPItem memory txItem = txBytes.toRLPItem();
         RLP.RLPItem[] memory txList = txItem.toList();
 
-        uint newOwnerIdx = 8;
-        uint amountIdx = 9;
-        require(msg.sender == txList[newOwnerIdx].toAddress());
-        require(msg.value == txList[amountIdx].toUint());
+        address owner;
+        uint amount;
+        (owner, amount) = outputAt(txList, 0);
+        require(msg.sender == owner);
+        require(msg.value == amount);
+        require(tokenAt(txList, 0) == 0);
+        require(isDeposit(txList));
+
+        uint256 blocknum = createDepositBlock(txBytes);
+
+        Deposit(msg.sender, msg.value, blocknum, 0);
+    }
+
+    // The sender must have approved this contract to transfer the token.
+    function depositNFT(address token, uint256 id, bytes txBytes) public {
+        RLP.RLPItem memory txItem = txBytes.toRLPItem();
+        RLP.RLPItem[] memory txList = txItem.toList();
+
+        address owner;
+        uint amount;
+        (owner, amount) = outputAt(txList, 0);
+        require(msg.sender == owner);
+        require(amount == 0);
+        require(isDeposit(txList));
+
+        uint64 tokenId = tokenAt(txList, 0);
+        require(tokenId != 0 && tokenId == uint64(uint256(keccak256(token, id))));
+        require(tokens[tokenId].token == address(0));
+
+        tokens[tokenId] = Token({
+            token: token,
+            id: id
+        });
+
+        ERC721(token).transferFrom(msg.sender, this, id);
+
+        uint256 blocknum = createDepositBlock(txBytes);
+
+        Deposit(msg.sender, 0, blocknum, tokenId);
+    }
+
+    // isDeposit reports whether txList only credits output 0: it spends
+    // nothing, pays no fee, output 0 has no multisig owners or locks, and
+    // every other output is a zero output without a token. The deposit block
+    // then commits to the same transaction the root node credits, and no
+    // output in it can exit funds or tokens the depositor did not pay in.
+    function isDeposit(RLP.RLPItem[] memory txList)
+        internal
+        returns (bool)
+    {
+        uint i;
+        uint outputs = 2;
+
+        if (txList.length == LEGACY_TX_LENGTH) {
+            // Items 3 and 7 are the signatures of the two inputs.
+            for (i = 0; i < 8; i++) {
+                if (i == 3 || i == 7) {
+                    if (txList[i].toData().length != 0) {
+                        return false;
+                    }
+                } else if (txList[i].toUint() != 0) {
+                    return false;
+                }
+            }
+
+            if (txList[12].toUint() != 0) {
+                return false;
+            }
+        } else {
+            RLP.RLPItem[] memory inputs = txList[1].toList();
+            RLP.RLPItem[] memory sigs = txList[2].toList();
+
+            for (i = 0; i < inputs.length; i++) {
+                RLP.RLPItem[] memory input = inputs[i].toList();
+
+                if (input[0].toUint() != 0 || input[1].toUint() != 0 || input[2].toUint() != 0) {
+                    return false;
+                }
+            }
+
+            for (i = 0; i < sigs.length; i++) {
+                if (sigs[i].toData().length != 0) {
+                    return false;
+                }
+            }
+
+            if (txList[4].toUint() != 0 || !isPlainOutput(txList[3].toList()[0].toList())) {
+                return false;
+            }
+
+            outputs = txList[3].toList().length;
+        }
+
+        for (i = 1; i < outputs; i++) {
+            address owner;
+            uint amount;
+            (owner, amount) = outputAt(txList, i);
+
+            if (owner != address(0) || amount != 0 || tokenAt(txList, i) != 0) {
+                return false;
+            }
+        }
+
+        return true;
+    }
+
+    // isPlainOutput reports whether a versioned output has neither multisig
+    // owners nor a hash or time lock.
+    function isPlainOutput(RLP.RLPItem[] memory output)
+        internal
+        returns (bool)
+    {
+        if (output.length >= 4 && output[3].toList().length != 0) {
+            return false;
+        }
+
+        if (output.length >= 7) {
+            return output[4].toData().length == 0 && output[5].toUint() == 0 && output[6].toAddress() == address(0);
+        }
+
+        return true;
+    }
+
+    function createDepositBlock(bytes txBytes) internal returns (uint256) {
+        require(currentDepositBlock < CHILD_BLOCK_INTERVAL);
 
         bytes32 root = createSimpleMerkleRoot(txBytes);
+        uint256 blocknum = currentChildBlock.sub(CHILD_BLOCK_INTERVAL).add(currentDepositBlock);
 
-        childChain[currentChildBlock] = ChildBlock({
+        childChain[blocknum] = ChildBlock({
             root: root,
-            created_at: block.timestamp
+            created_at: block.timestamp,
+            tokenRoot: bytes32(0)
         });
 
-        currentChildBlock = currentChildBlock.add(1);
-
-        Deposit(msg.sender, msg.value);
+        currentDepositBlock = currentDepositBlock.add(1);
+        return blocknum;
     }
 
     function createSimpleMerkleRoot(bytes txBytes) returns (bytes32) {
@@ -113,30 +276,121 @@ contract Plasma {
         bytes proof
     ) public
     {
-        RLP.RLPItem memory txItem = txBytes.toRLPItem();
-        RLP.RLPItem[] memory txList = txItem.toList();
+        address payee = authorizeExit(blocknum, txindex, oindex, txBytes, "", "");
+        addExit(blocknum, txindex, oindex, txBytes, proof, payee);
+    }
 
-        uint baseIndex = 8 + (oindex * 2);
+    // startMultisigExit starts the exit of a multisig output paid to its
+    // owner. sigs holds the signatures of threshold of its owners over
+    // exitHash(blocknum, txindex, oindex), 65 bytes each, in the ascending
+    // order of their signers. Anyone can send them.
+    function startMultisigExit(
+        uint256 blocknum,
+        uint256 txindex,
+        uint256 oindex,
+        bytes txBytes,
+        bytes proof,
+        bytes sigs
+    ) public
+    {
+        address payee = authorizeExit(blocknum, txindex, oindex, txBytes, sigs, "");
+        addExit(blocknum, txindex, oindex, txBytes, proof, payee);
+    }
 
-        require(msg.sender == txList[baseIndex].toAddress());
+    // startClaimExit starts the exit of a hash-locked output to its owner
+    // with the preimage of its hash lock, before the chain is past timeLock.
+    // sigs are only needed when the output is multisig, as for
+    // startMultisigExit.
+    function startClaimExit(
+        uint256 blocknum,
+        uint256 txindex,
+        uint256 oindex,
+        bytes txBytes,
+        bytes proof,
+        bytes preimage,
+        bytes sigs
+    ) public
+    {
+        require(preimage.length > 0);
 
-        uint amount = txList[baseIndex + 1].toUint();
-        // Simplify contract by only allowing exits > 0
-        require(amount > 0);
+        address payee = authorizeExit(blocknum, txindex, oindex, txBytes, sigs, preimage);
+        addExit(blocknum, txindex, oindex, txBytes, proof, payee);
+    }
 
-        bool exists = checkProof(blocknum, txindex, txBytes, proof);
+    function exitHash(uint256 blocknum, uint256 txindex, uint256 oindex)
+        public
+        view
+        returns (bytes32)
+    {
+        return keccak256(address(this), blocknum, txindex, oindex);
+    }
+
+    // authorizeExit returns the address the output exits to, once the
+    // sender may start its exit: it is the payee, or it sends the signatures
+    // of threshold owners of a multisig output paid to its owner.
+    function authorizeExit(
+        uint256 blocknum,
+        uint256 txindex,
+        uint256 oindex,
+        bytes txBytes,
+        bytes sigs,
+        bytes preimage
+    ) internal returns (address)
+    {
+        RLP.RLPItem[] memory txList = txBytes.toRLPItem().toList();
+
+        address owner;
+        (owner, ) = outputAt(txList, oindex);
 
-        require(exists);
+        address payee = exitPayee(txList, oindex, owner, preimage);
+
+        if (payee == owner && multisigThreshold(txList, oindex) > 0) {
+            require(hasOwnerSigs(txList, oindex, exitHash(blocknum, txindex, oindex), sigs));
+        } else {
+            require(msg.sender == payee);
+        }
+
+        return payee;
+    }
+
+    function addExit(
+        uint256 blocknum,
+        uint256 txindex,
+        uint256 oindex,
+        bytes txBytes,
+        bytes proof,
+        address payee
+    ) internal
+    {
+        RLP.RLPItem[] memory txList = txBytes.toRLPItem().toList();
+
+        uint amount;
+        (, amount) = outputAt(txList, oindex);
+
+        // Simplify contract by only allowing exits > 0 or of tokens
+        uint64 tokenId = tokenAt(txList, oindex);
+        require(amount > 0 || tokenId != 0);
+
+        require(checkProof(blocknum, txindex, txBytes, proof));
 
         // TODO: check that the sigs given to the utxo owner from the input owner
         // are legit from the side chain.
 
         uint256 priority = calcPriority(blocknum, txindex, oindex);
+
+        // An output exits once at a time, so that the refund of a hash-locked
+        // output cannot replace its owner's claim.
+        require(exits[priority].owner == address(0));
+
         lastExitId = priority; // For convenience and debugging.
         exitQueue.add(priority);
+
+        if (tokenId != 0) {
+            exitTokens[priority] = tokenId;
+        }
         
         exits[priority] = Exit({
-            owner: msg.sender,
+            owner: payee,
             amount: amount,
             // These are necessary for challenges.
             blocknum: blocknum,
@@ -170,10 +424,7 @@ contract Plasma {
         RLP.RLPItem memory txItem = txBytes.toRLPItem();
         RLP.RLPItem[] memory txList = txItem.toList();
 
-        bool firstInput = txList[0].toUint() == currExit.blocknum && txList[1].toUint() == currExit.txindex && txList[2].toUint() == currExit.oindex;
-        bool secondInput = txList[3].toUint() == currExit.blocknum && txList[4].toUint() == currExit.txindex && txList[5].toUint() == currExit.oindex;
-
-        if(!firstInput && !secondInput) {
+        if(!spendsExit(txList, currExit)) {
             ChallengeFailure(msg.sender, exitId);
             return;
         }
@@ -181,7 +432,7 @@ contract Plasma {
         bool exists = checkProof(blocknum, txindex, txBytes, proof);
 
         if (exists) {
-            require(currExit.amount > 0);
+            require(currExit.amount > 0 || exitTokens[exitId] != 0);
 
             uint256 burn;
             if (currExit.owner.balance < currExit.amount) {
@@ -201,6 +452,7 @@ contract Plasma {
                 started_at: 0
             });
 
+            delete exitTokens[exitId];
             exitQueue.remove(exitId);
 
             ChallengeSuccess(msg.sender, exitId);
@@ -209,6 +461,204 @@ contract Plasma {
         }
     }
 
+    function outputAt(RLP.RLPItem[] memory txList, uint256 oindex)
+        internal
+        returns (address, uint256)
+    {
+        if (txList.length == LEGACY_TX_LENGTH) {
+            require(oindex < 2);
+            uint baseIndex = 8 + (oindex * 2);
+            return (txList[baseIndex].toAddress(), txList[baseIndex + 1].toUint());
+        }
+
+        RLP.RLPItem[] memory outputs = txList[3].toList();
+        require(oindex < outputs.length);
+
+        RLP.RLPItem[] memory output = outputs[oindex].toList();
+        return (output[0].toAddress(), output[1].toUint());
+    }
+
+    function tokenAt(RLP.RLPItem[] memory txList, uint256 oindex)
+        internal
+        returns (uint64)
+    {
+        if (txList.length == LEGACY_TX_LENGTH) {
+            return 0;
+        }
+
+        RLP.RLPItem[] memory output = txList[3].toList()[oindex].toList();
+
+        if (output.length < 8) {
+            return 0;
+        }
+
+        return uint64(output[7].toUint());
+    }
+
+    function isMultisigOwner(RLP.RLPItem[] memory txList, uint256 oindex, address sender)
+        internal
+        returns (bool)
+    {
+        if (txList.length == LEGACY_TX_LENGTH) {
+            return false;
+        }
+
+        RLP.RLPItem[] memory output = txList[3].toList()[oindex].toList();
+
+        if (output.length < 4) {
+            return false;
+        }
+
+        RLP.RLPItem[] memory owners = output[3].toList();
+
+        for (uint i = 0; i < owners.length; i++) {
+            if (owners[i].toAddress() == sender) {
+                return true;
+            }
+        }
+
+        return false;
+    }
+
+    function multisigThreshold(RLP.RLPItem[] memory txList, uint256 oindex)
+        internal
+        returns (uint256)
+    {
+        if (txList.length == LEGACY_TX_LENGTH) {
+            return 0;
+        }
+
+        RLP.RLPItem[] memory output = txList[3].toList()[oindex].toList();
+
+        if (output.length < 4 || output[3].toList().length == 0) {
+            return 0;
+        }
+
+        return output[2].toUint();
+    }
+
+    // hasOwnerSigs checks that sigs holds the signatures of threshold
+    // distinct owners of the output over hash. Signers must be in ascending
+    // order, which keeps them distinct.
+    function hasOwnerSigs(RLP.RLPItem[] memory txList, uint256 oindex, bytes32 hash, bytes sigs)
+        internal
+        returns (bool)
+    {
+        uint256 threshold = multisigThreshold(txList, oindex);
+
+        if (sigs.length != threshold.mul(65)) {
+            return false;
+        }
+
+        address last = address(0);
+
+        for (uint i = 0; i < threshold; i++) {
+            address signer = recoverSigner(hash, sigs, i.mul(65));
+
+            if (signer <= last || !isMultisigOwner(txList, oindex, signer)) {
+                return false;
+            }
+
+            last = signer;
+        }
+
+        return true;
+    }
+
+    // recoverSigner returns the signer of the [R || S || V] signature at
+    // offset in sigs. V is 0 or 1, or 27 or 28.
+    function recoverSigner(bytes32 hash, bytes sigs, uint256 offset)
+        internal
+        pure
+        returns (address)
+    {
+        bytes32 r;
+        bytes32 s;
+        uint8 v;
+
+        assembly {
+            r := mload(add(sigs, add(32, offset)))
+            s := mload(add(sigs, add(64, offset)))
+            v := byte(0, mload(add(sigs, add(96, offset))))
+        }
+
+        if (v < 27) {
+            v += 27;
+        }
+
+        return ecrecover(hash, v, r, s);
+    }
+
+    // exitPayee returns the address an output exits to. A locked output
+    // exits to owner once the latest submitted block has reached timeLock.
+    // A hash-locked one exits to refund then, and to owner with the preimage
+    // only before then: the claim window closes as the refund opens, so the
+    // two never race. Without a refund, the claim window stays open.
+    function exitPayee(RLP.RLPItem[] memory txList, uint256 oindex, address owner, bytes preimage)
+        internal
+        returns (address)
+    {
+        if (txList.length == LEGACY_TX_LENGTH) {
+            return owner;
+        }
+
+        RLP.RLPItem[] memory output = txList[3].toList()[oindex].toList();
+
+        if (output.length < 7) {
+            return owner;
+        }
+
+        bool expired = currentChildBlock.sub(CHILD_BLOCK_INTERVAL) >= output[5].toUint();
+        address refund = output[6].toAddress();
+
+        if (output[4].toData().length == 0) {
+            require(expired);
+            return owner;
+        }
+
+        if (preimage.length > 0) {
+            require(sha256(preimage) == output[4].toBytes32());
+            require(!expired || refund == address(0));
+            return owner;
+        }
+
+        require(expired);
+        require(refund != address(0));
+        return refund;
+    }
+
+    function spendsExit(RLP.RLPItem[] memory txList, Exit memory exit)
+        internal
+        returns (bool)
+    {
+        if (txList.length == LEGACY_TX_LENGTH) {
+            return isExitInput(txList[0], txList[1], txList[2], exit) ||
+                isExitInput(txList[4], txList[5], txList[6], exit);
+        }
+
+        RLP.RLPItem[] memory inputs = txList[1].toList();
+
+        for (uint i = 0; i < inputs.length; i++) {
+            RLP.RLPItem[] memory input = inputs[i].toList();
+
+            if (isExitInput(input[0], input[1], input[2], exit)) {
+                return true;
+            }
+        }
+
+        return false;
+    }
+
+    function isExitInput(
+        RLP.RLPItem memory blocknum,
+        RLP.RLPItem memory txindex,
+        RLP.RLPItem memory oindex,
+        Exit memory exit
+    ) internal returns (bool)
+    {
+        return blocknum.toUint() == exit.blocknum && txindex.toUint() == exit.txindex && oindex.toUint() == exit.oindex;
+    }
+
     // TODO: move into merkle file.
     function checkProof(
         uint256 blocknum,
@@ -259,13 +709,21 @@ contract Plasma {
         uint256 exitId = exitQueue.pop();
         while(exitId != SafeMath.max()) {
             Exit memory currExit = exits[exitId];
+            uint64 tokenId = exitTokens[exitId];
 
             if (
                 isFinalizableTime(currExit.started_at) &&
                 currExit.owner != address(0) &&
-                currExit.amount > 0
+                (currExit.amount > 0 || tokenId != 0)
             ) {
-                currExit.owner.send(currExit.amount);
+                if (tokenId != 0) {
+                    // The owner withdraws the token, so that a token
+                    // contract that reverts cannot hold up other exits.
+                    tokenWithdrawals[tokenId] = currExit.owner;
+                    delete exitTokens[exitId];
+                } else {
+                    currExit.owner.send(currExit.amount);
+                }
                 
                 exits[exitId] = Exit({
                     owner: address(0),
@@ -282,6 +740,18 @@ contract Plasma {
         }
     }
 
+    // Sends a token whose exit is finalized to the exit's owner.
+    function withdrawToken(uint64 tokenId) public {
+        address owner = tokenWithdrawals[tokenId];
+        require(owner != address(0));
+
+        Token memory token = tokens[tokenId];
+        delete tokenWithdrawals[tokenId];
+        delete tokens[tokenId];
+
+        ERC721(token.token).transferFrom(this, owner, token.id);
+    }
+
     // Periodically monitor if we should finalize
     function shouldFinalize() constant returns (bool) {
         // Not used for testing
@@ -16,7 +16,7 @@ import (
 )
 
 // PlasmaABI is the input ABI used to generate the binding from.
-const PlasmaABI = "[{\"constant\":true,\"inputs\":[],\"name\":\"lastExitId\",\"outputs\":[{\"name\":\"\",\"type\":\"uint256\"}],\"payable\":false,\"stateMutability\":\"view\",\"type\":\"function\"},{\"constant\":true,\"inputs\":[{\"name\":\"\",\"type\":\"uint256\"}],\"name\":\"exits\",\"outputs\":[{\"name\":\"owner\",\"type\":\"address\"},{\"name\":\"amount\",\"type\":\"uint256\"},{\"name\":\"blocknum\",\"type\":\"uint256\"},{\"name\":\"txindex\",\"type\":\"uint256\"},{\"name\":\"oindex\",\"type\":\"uint256\"},{\"name\":\"started_at\",\"type\":\"uint256\"}],\"payable\":false,\"stateMutability\":\"view\",\"type\":\"function\"},{\"constant\":true,\"inputs\":[],\"name\":\"currentChildBlock\",\"outputs\":[{\"name\":\"\",\"type\":\"uint256\"}],\"payable\":false,\"stateMutability\":\"view\",\"type\":\"function\"},{\"constant\":true,\"inputs\":[],\"name\":\"authority\",\"outputs\":[{\"name\":\"\",\"type\":\"address\"}],\"payable\":false,\"stateMutability\":\"view\",\"type\":\"function\"},{\"constant\":true,\"inputs\":[],\"name\":\"lastFinalizedTime\",\"outputs\":[{\"name\":\"\",\"type\":\"uint256\"}],\"payable\":false,\"stateMutability\":\"view\",\"type\":\"function\"},{\"constant\":true,\"inputs\":[{\"name\":\"\",\"type\":\"uint256\"}],\"name\":\"childChain\",\"outputs\":[{\"name\":\"root\",\"type\":\"bytes32\"},{\"name\":\"created_at\",\"type\":\"uint256\"}],\"payable\":false,\"stateMutability\":\"view\",\"type\":\"function\"},{\"constant\":true,\"inputs\":[],\"name\":\"exitQueue\",\"outputs\":[{\"name\":\"\",\"type\":\"address\"}],\"payable\":false,\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[],\"payable\":false,\"stateMutability\":\"nonpayable\",\"type\":\"constructor\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":false,\"name\":\"sender\",\"type\":\"address\"},{\"indexed\":false,\"name\":\"value\",\"type\":\"uint256\"}],\"name\":\"Deposit\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":false,\"name\":\"sender\",\"type\":\"address\"},{\"indexed\":false,\"name\":\"root\",\"type\":\"bytes32\"}],\"name\":\"SubmitBlock\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":false,\"name\":\"sender\",\"type\":\"address\"},{\"indexed\":false,\"name\":\"exitId\",\"type\":\"uint256\"}],\"name\":\"ExitStarted\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":false,\"name\":\"sender\",\"type\":\"address\"},{\"indexed\":false,\"name\":\"exitId\",\"type\":\"uint256\"}],\"name\":\"ChallengeSuccess\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":false,\"name\":\"sender\",\"type\":\"address\"},{\"indexed\":false,\"name\":\"exitId\",\"type\":\"uint256\"}],\"name\":\"ChallengeFailure\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":false,\"name\":\"sender\",\"type\":\"address\"},{\"indexed\":false,\"name\":\"exitId\",\"type\":\"uint256\"}],\"name\":\"FinalizeExit\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":false,\"name\":\"sender\",\"type\":\"address\"},{\"indexed\":false,\"name\":\"item\",\"type\":\"bytes32\"}],\"name\":\"DebugBytes32\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":false,\"name\":\"sender\",\"type\":\"address\"},{\"indexed\":false,\"name\":\"item\",\"type\":\"bytes\"}],\"name\":\"DebugBytes\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":false,\"name\":\"sender\",\"type\":\"address\"},{\"indexed\":false,\"name\":\"item\",\"type\":\"address\"}],\"name\":\"DebugAddress\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":false,\"name\":\"sender\",\"type\":\"address\"},{\"indexed\":false,\"name\":\"item\",\"type\":\"uint256\"}],\"name\":\"DebugUint\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":false,\"name\":\"sender\",\"type\":\"address\"},{\"indexed\":false,\"name\":\"item\",\"type\":\"bool\"}],\"name\":\"DebugBool\",\"type\":\"event\"},{\"constant\":false,\"inputs\":[{\"name\":\"root\",\"type\":\"bytes32\"}],\"name\":\"submitBlock\",\"outputs\":[],\"payable\":false,\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"constant\":true,\"inputs\":[{\"name\":\"blocknum\",\"type\":\"uint256\"}],\"name\":\"getBlock\",\"outputs\":[{\"name\":\"\",\"type\":\"bytes32\"},{\"name\":\"\",\"type\":\"uint256\"}],\"payable\":false,\"stateMutability\":\"view\",\"type\":\"function\"},{\"constant\":false,\"inputs\":[{\"name\":\"txBytes\",\"type\":\"bytes\"}],\"name\":\"deposit\",\"outputs\":[],\"payable\":true,\"stateMutability\":\"payable\",\"type\":\"function\"},{\"constant\":false,\"inputs\":[{\"name\":\"txBytes\",\"type\":\"bytes\"}],\"name\":\"createSimpleMerkleRoot\",\"outputs\":[{\"name\":\"\",\"type\":\"bytes32\"}],\"payable\":false,\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"constant\":false,\"inputs\":[{\"name\":\"blocknum\",\"type\":\"uint256\"},{\"name\":\"txindex\",\"type\":\"uint256\"},{\"name\":\"oindex\",\"type\":\"uint256\"},{\"name\":\"txBytes\",\"type\":\"bytes\"},{\"name\":\"proof\",\"type\":\"bytes\"}],\"name\":\"startExit\",\"outputs\":[],\"payable\":false,\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"constant\":true,\"inputs\":[{\"name\":\"exitId\",\"type\":\"uint256\"}],\"name\":\"getExit\",\"outputs\":[{\"name\":\"\",\"type\":\"address\"},{\"name\":\"\",\"type\":\"uint256\"},{\"name\":\"\",\"type\":\"uint256\"},{\"name\":\"\",\"type\":\"uint256\"},{\"name\":\"\",\"type\":\"uint256\"},{\"name\":\"\",\"type\":\"uint256\"}],\"payable\":false,\"stateMutability\":\"view\",\"type\":\"function\"},{\"constant\":false,\"inputs\":[{\"name\":\"exitId\",\"type\":\"uint256\"},{\"name\":\"blocknum\",\"type\":\"uint256\"},{\"name\":\"txindex\",\"type\":\"uint256\"},{\"name\":\"txBytes\",\"type\":\"bytes\"},{\"name\":\"proof\",\"type\":\"bytes\"}],\"name\":\"challengeExit\",\"outputs\":[],\"payable\":false,\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"constant\":false,\"inputs\":[{\"name\":\"blocknum\",\"type\":\"uint256\"},{\"name\":\"txindex\",\"type\":\"uint256\"},{\"name\":\"txBytes\",\"type\":\"bytes\"},{\"name\":\"proof\",\"type\":\"bytes\"}],\"name\":\"checkProof\",\"outputs\":[{\"name\":\"\",\"type\":\"bool\"}],\"payable\":false,\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"constant\":false,\"inputs\":[],\"name\":\"finalize\",\"outputs\":[],\"payable\":false,\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"constant\":true,\"inputs\":[],\"name\":\"shouldFinalize\",\"outputs\":[{\"name\":\"\",\"type\":\"bool\"}],\"payable\":false,\"stateMutability\":\"view\",\"type\":\"function\"},{\"constant\":true,\"inputs\":[{\"name\":\"timestamp\",\"type\":\"uint256\"}],\"name\":\"isFinalizableTime\",\"outputs\":[{\"name\":\"\",\"type\":\"bool\"}],\"payable\":false,\"stateMutability\":\"view\",\"type\":\"function\"},{\"constant\":true,\"inputs\":[{\"name\":\"blocknum\",\"type\":\"uint256\"},{\"name\":\"txindex\",\"type\":\"uint256\"},{\"name\":\"oindex\",\"type\":\"uint256\"}],\"name\":\"calcPriority\",\"outputs\":[{\"name\":\"\",\"type\":\"uint256\"}],\"payable\":false,\"stateMutability\":\"view\",\"type\":\"function\"}]"
+const PlasmaABI = "[{\"constant\":true,\"inputs\":[],\"name\":\"lastExitId\",\"outputs\":[{\"name\":\"\",\"type\":\"uint256\"}],\"payable\":false,\"stateMutability\":\"view\",\"type\":\"function\"},{\"constant\":true,\"inputs\":[{\"name\":\"\",\"type\":\"uint256\"}],\"name\":\"exits\",\"outputs\":[{\"name\":\"owner\",\"type\":\"address\"},{\"name\":\"amount\",\"type\":\"uint256\"},{\"name\":\"blocknum\",\"type\":\"uint256\"},{\"name\":\"txindex\",\"type\":\"uint256\"},{\"name\":\"oindex\",\"type\":\"uint256\"},{\"name\":\"started_at\",\"type\":\"uint256\"}],\"payable\":false,\"stateMutability\":\"view\",\"type\":\"function\"},{\"constant\":true,\"inputs\":[],\"name\":\"currentChildBlock\",\"outputs\":[{\"name\":\"\",\"type\":\"uint256\"}],\"payable\":false,\"stateMutability\":\"view\",\"type\":\"function\"},{\"constant\":true,\"inputs\":[],\"name\":\"currentDepositBlock\",\"outputs\":[{\"name\":\"\",\"type\":\"uint256\"}],\"payable\":false,\"stateMutability\":\"view\",\"type\":\"function\"},{\"constant\":true,\"inputs\":[],\"name\":\"authority\",\"outputs\":[{\"name\":\"\",\"type\":\"address\"}],\"payable\":false,\"stateMutability\":\"view\",\"type\":\"function\"},{\"constant\":true,\"inputs\":[],\"name\":\"lastFinalizedTime\",\"outputs\":[{\"name\":\"\",\"type\":\"uint256\"}],\"payable\":false,\"stateMutability\":\"view\",\"type\":\"function\"},{\"constant\":true,\"inputs\":[{\"name\":\"\",\"type\":\"uint256\"}],\"name\":\"childChain\",\"outputs\":[{\"name\":\"root\",\"type\":\"bytes32\"},{\"name\":\"created_at\",\"type\":\"uint256\"},{\"name\":\"tokenRoot\",\"type\":\"bytes32\"}],\"payable\":false,\"stateMutability\":\"view\",\"type\":\"function\"},{\"constant\":true,\"inputs\":[],\"name\":\"exitQueue\",\"outputs\":[{\"name\":\"\",\"type\":\"address\"}],\"payable\":false,\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[],\"payable\":false,\"stateMutability\":\"nonpayable\",\"type\":\"constructor\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":false,\"name\":\"sender\",\"type\":\"address\"},{\"indexed\":false,\"name\":\"value\",\"type\":\"uint256\"},{\"indexed\":false,\"name\":\"blocknum\",\"type\":\"uint256\"},{\"indexed\":false,\"name\":\"tokenId\",\"type\":\"uint256\"}],\"name\":\"Deposit\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":false,\"name\":\"sender\",\"type\":\"address\"},{\"indexed\":false,\"name\":\"root\",\"type\":\"bytes32\"}],\"name\":\"SubmitBlock\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":false,\"name\":\"sender\",\"type\":\"address\"},{\"indexed\":false,\"name\":\"exitId\",\"type\":\"uint256\"}],\"name\":\"ExitStarted\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":false,\"name\":\"sender\",\"type\":\"address\"},{\"indexed\":false,\"name\":\"exitId\",\"type\":\"uint256\"}],\"name\":\"ChallengeSuccess\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":false,\"name\":\"sender\",\"type\":\"address\"},{\"indexed\":false,\"name\":\"exitId\",\"type\":\"uint256\"}],\"name\":\"ChallengeFailure\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":false,\"name\":\"sender\",\"type\":\"address\"},{\"indexed\":false,\"name\":\"exitId\",\"type\":\"uint256\"}],\"name\":\"FinalizeExit\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":false,\"name\":\"sender\",\"type\":\"address\"},{\"indexed\":false,\"name\":\"item\",\"type\":\"bytes32\"}],\"name\":\"DebugBytes32\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":false,\"name\":\"sender\",\"type\":\"address\"},{\"indexed\":false,\"name\":\"item\",\"type\":\"bytes\"}],\"name\":\"DebugBytes\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":false,\"name\":\"sender\",\"type\":\"address\"},{\"indexed\":false,\"name\":\"item\",\"type\":\"address\"}],\"name\":\"DebugAddress\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":false,\"name\":\"sender\",\"type\":\"address\"},{\"indexed\":false,\"name\":\"item\",\"type\":\"uint256\"}],\"name\":\"DebugUint\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":false,\"name\":\"sender\",\"type\":\"address\"},{\"indexed\":false,\"name\":\"item\",\"type\":\"bool\"}],\"name\":\"DebugBool\",\"type\":\"event\"},{\"constant\":false,\"inputs\":[{\"name\":\"root\",\"type\":\"bytes32\"},{\"name\":\"tokenRoot\",\"type\":\"bytes32\"}],\"name\":\"submitBlock\",\"outputs\":[],\"payable\":false,\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"constant\":true,\"inputs\":[{\"name\":\"blocknum\",\"type\":\"uint256\"}],\"name\":\"getBlock\",\"outputs\":[{\"name\":\"\",\"type\":\"bytes32\"},{\"name\":\"\",\"type\":\"uint256\"},{\"name\":\"\",\"type\":\"bytes32\"}],\"payable\":false,\"stateMutability\":\"view\",\"type\":\"function\"},{\"constant\":false,\"inputs\":[{\"name\":\"txBytes\",\"type\":\"bytes\"}],\"name\":\"deposit\",\"outputs\":[],\"payable\":true,\"stateMutability\":\"payable\",\"type\":\"function\"},{\"constant\":false,\"inputs\":[{\"name\":\"token\",\"type\":\"address\"},{\"name\":\"id\",\"type\":\"uint256\"},{\"name\":\"txBytes\",\"type\":\"bytes\"}],\"name\":\"depositNFT\",\"outputs\":[],\"payable\":false,\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"constant\":false,\"inputs\":[{\"name\":\"txBytes\",\"type\":\"bytes\"}],\"name\":\"createSimpleMerkleRoot\",\"outputs\":[{\"name\":\"\",\"type\":\"bytes32\"}],\"payable\":false,\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"constant\":false,\"inputs\":[{\"name\":\"blocknum\",\"type\":\"uint256\"},{\"name\":\"txindex\",\"type\":\"uint256\"},{\"name\":\"oindex\",\"type\":\"uint256\"},{\"name\":\"txBytes\",\"type\":\"bytes\"},{\"name\":\"proof\",\"type\":\"bytes\"},{\"name\":\"preimage\",\"type\":\"bytes\"},{\"name\":\"sigs\",\"type\":\"bytes\"}],\"name\":\"startClaimExit\",\"outputs\":[],\"payable\":false,\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"constant\":false,\"inputs\":[{\"name\":\"blocknum\",\"type\":\"uint256\"},{\"name\":\"txindex\",\"type\":\"uint256\"},{\"name\":\"oindex\",\"type\":\"uint256\"},{\"name\":\"txBytes\",\"type\":\"bytes\"},{\"name\":\"proof\",\"type\":\"bytes\"}],\"name\":\"startExit\",\"outputs\":[],\"payable\":false,\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"constant\":false,\"inputs\":[{\"name\":\"blocknum\",\"type\":\"uint256\"},{\"name\":\"txindex\",\"type\":\"uint256\"},{\"name\":\"oindex\",\"type\":\"uint256\"},{\"name\":\"txBytes\",\"type\":\"bytes\"},{\"name\":\"proof\",\"type\":\"bytes\"},{\"name\":\"sigs\",\"type\":\"bytes\"}],\"name\":\"startMultisigExit\",\"outputs\":[],\"payable\":false,\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"constant\":true,\"inputs\":[{\"name\":\"blocknum\",\"type\":\"uint256\"},{\"name\":\"txindex\",\"type\":\"uint256\"},{\"name\":\"oindex\",\"type\":\"uint256\"}],\"name\":\"exitHash\",\"outputs\":[{\"name\":\"\",\"type\":\"bytes32\"}],\"payable\":false,\"stateMutability\":\"view\",\"type\":\"function\"},{\"constant\":true,\"inputs\":[{\"name\":\"exitId\",\"type\":\"uint256\"}],\"name\":\"getExit\",\"outputs\":[{\"name\":\"\",\"type\":\"address\"},{\"name\":\"\",\"type\":\"uint256\"},{\"name\":\"\",\"type\":\"uint256\"},{\"name\":\"\",\"type\":\"uint256\"},{\"name\":\"\",\"type\":\"uint256\"},{\"name\":\"\",\"type\":\"uint256\"}],\"payable\":false,\"stateMutability\":\"view\",\"type\":\"function\"},{\"constant\":false,\"inputs\":[{\"name\":\"exitId\",\"type\":\"uint256\"},{\"name\":\"blocknum\",\"type\":\"uint256\"},{\"name\":\"txindex\",\"type\":\"uint256\"},{\"name\":\"txBytes\",\"type\":\"bytes\"},{\"name\":\"proof\",\"type\":\"bytes\"}],\"name\":\"challengeExit\",\"outputs\":[],\"payable\":false,\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"constant\":false,\"inputs\":[{\"name\":\"blocknum\",\"type\":\"uint256\"},{\"name\":\"txindex\",\"type\":\"uint256\"},{\"name\":\"txBytes\",\"type\":\"bytes\"},{\"name\":\"proof\",\"type\":\"bytes\"}],\"name\":\"checkProof\",\"outputs\":[{\"name\":\"\",\"type\":\"bool\"}],\"payable\":false,\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"constant\":false,\"inputs\":[],\"name\":\"finalize\",\"outputs\":[],\"payable\":false,\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"constant\":false,\"inputs\":[{\"name\":\"tokenId\",\"type\":\"uint64\"}],\"name\":\"withdrawToken\",\"outputs\":[],\"payable\":false,\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"constant\":true,\"inputs\":[],\"name\":\"shouldFinalize\",\"outputs\":[{\"name\":\"\",\"type\":\"bool\"}],\"payable\":false,\"stateMutability\":\"view\",\"type\":\"function\"},{\"constant\":true,\"inputs\":[{\"name\":\"timestamp\",\"type\":\"uint256\"}],\"name\":\"isFinalizableTime\",\"outputs\":[{\"name\":\"\",\"type\":\"bool\"}],\"payable\":false,\"stateMutability\":\"view\",\"type\":\"function\"},{\"constant\":true,\"inputs\":[{\"name\":\"blocknum\",\"type\":\"uint256\"},{\"name\":\"txindex\",\"type\":\"uint256\"},{\"name\":\"oindex\",\"type\":\"uint256\"}],\"name\":\"calcPriority\",\"outputs\":[{\"name\":\"\",\"type\":\"uint256\"}],\"payable\":false,\"stateMutability\":\"view\",\"type\":\"function\"}]"
 
 // Plasma is an auto generated Go binding around an Ethereum contract.
 type Plasma struct {
@@ -214,14 +214,16 @@ func (_Plasma *PlasmaCallerSession) CalcPriority(blocknum *big.Int, txindex *big
 
 // ChildChain is a free data retrieval call binding the contract method 0xf95643b1.
 //
-// Solidity: function childChain( uint256) constant returns(root bytes32, created_at uint256)
+// Solidity: function childChain( uint256) constant returns(root bytes32, created_at uint256, tokenRoot bytes32)
 func (_Plasma *PlasmaCaller) ChildChain(opts *bind.CallOpts, arg0 *big.Int) (struct {
 	Root      [32]byte
 	CreatedAt *big.Int
+	TokenRoot [32]byte
 }, error) {
 	ret := new(struct {
 		Root      [32]byte
 		CreatedAt *big.Int
+		TokenRoot [32]byte
 	})
 	out := ret
 	err := _Plasma.contract.Call(opts, out, "childChain", arg0)
@@ -230,20 +232,22 @@ func (_Plasma *PlasmaCaller) ChildChain(opts *bind.CallOpts, arg0 *big.Int) (str
 
 // ChildChain is a free data retrieval call binding the contract method 0xf95643b1.
 //
-// Solidity: function childChain( uint256) constant returns(root bytes32, created_at uint256)
+// Solidity: function childChain( uint256) constant returns(root bytes32, created_at uint256, tokenRoot bytes32)
 func (_Plasma *PlasmaSession) ChildChain(arg0 *big.Int) (struct {
 	Root      [32]byte
 	CreatedAt *big.Int
+	TokenRoot [32]byte
 }, error) {
 	return _Plasma.Contract.ChildChain(&_Plasma.CallOpts, arg0)
 }
 
 // ChildChain is a free data retrieval call binding the contract method 0xf95643b1.
 //
-// Solidity: function childChain( uint256) constant returns(root bytes32, created_at uint256)
+// Solidity: function childChain( uint256) constant returns(root bytes32, created_at uint256, tokenRoot bytes32)
 func (_Plasma *PlasmaCallerSession) ChildChain(arg0 *big.Int) (struct {
 	Root      [32]byte
 	CreatedAt *big.Int
+	TokenRoot [32]byte
 }, error) {
 	return _Plasma.Contract.ChildChain(&_Plasma.CallOpts, arg0)
 }
@@ -274,6 +278,58 @@ func (_Plasma *PlasmaCallerSession) CurrentChildBlock() (*big.Int, error) {
 	return _Plasma.Contract.CurrentChildBlock(&_Plasma.CallOpts)
 }
 
+// CurrentDepositBlock is a free data retrieval call binding the contract method 0xa98c7f2c.
+//
+// Solidity: function currentDepositBlock() constant returns(uint256)
+func (_Plasma *PlasmaCaller) CurrentDepositBlock(opts *bind.CallOpts) (*big.Int, error) {
+	var (
+		ret0 = new(*big.Int)
+	)
+	out := ret0
+	err := _Plasma.contract.Call(opts, out, "currentDepositBlock")
+	return *ret0, err
+}
+
+// CurrentDepositBlock is a free data retrieval call binding the contract method 0xa98c7f2c.
+//
+// Solidity: function currentDepositBlock() constant returns(uint256)
+func (_Plasma *PlasmaSession) CurrentDepositBlock() (*big.Int, error) {
+	return _Plasma.Contract.CurrentDepositBlock(&_Plasma.CallOpts)
+}
+
+// CurrentDepositBlock is a free data retrieval call binding the contract method 0xa98c7f2c.
+//
+// Solidity: function currentDepositBlock() constant returns(uint256)
+func (_Plasma *PlasmaCallerSession) CurrentDepositBlock() (*big.Int, error) {
+	return _Plasma.Contract.CurrentDepositBlock(&_Plasma.CallOpts)
+}
+
+// ExitHash is a free data retrieval call binding the contract method 0x911d2b89.
+//
+// Solidity: function exitHash(blocknum uint256, txindex uint256, oindex uint256) constant returns(bytes32)
+func (_Plasma *PlasmaCaller) ExitHash(opts *bind.CallOpts, blocknum *big.Int, txindex *big.Int, oindex *big.Int) ([32]byte, error) {
+	var (
+		ret0 = new([32]byte)
+	)
+	out := ret0
+	err := _Plasma.contract.Call(opts, out, "exitHash", blocknum, txindex, oindex)
+	return *ret0, err
+}
+
+// ExitHash is a free data retrieval call binding the contract method 0x911d2b89.
+//
+// Solidity: function exitHash(blocknum uint256, txindex uint256, oindex uint256) constant returns(bytes32)
+func (_Plasma *PlasmaSession) ExitHash(blocknum *big.Int, txindex *big.Int, oindex *big.Int) ([32]byte, error) {
+	return _Plasma.Contract.ExitHash(&_Plasma.CallOpts, blocknum, txindex, oindex)
+}
+
+// ExitHash is a free data retrieval call binding the contract method 0x911d2b89.
+//
+// Solidity: function exitHash(blocknum uint256, txindex uint256, oindex uint256) constant returns(bytes32)
+func (_Plasma *PlasmaCallerSession) ExitHash(blocknum *big.Int, txindex *big.Int, oindex *big.Int) ([32]byte, error) {
+	return _Plasma.Contract.ExitHash(&_Plasma.CallOpts, blocknum, txindex, oindex)
+}
+
 // ExitQueue is a free data retrieval call binding the contract method 0xffed4bf5.
 //
 // Solidity: function exitQueue() constant returns(address)
@@ -354,31 +410,33 @@ func (_Plasma *PlasmaCallerSession) Exits(arg0 *big.Int) (struct {
 
 // GetBlock is a free data retrieval call binding the contract method 0x04c07569.
 //
-// Solidity: function getBlock(blocknum uint256) constant returns(bytes32, uint256)
-func (_Plasma *PlasmaCaller) GetBlock(opts *bind.CallOpts, blocknum *big.Int) ([32]byte, *big.Int, error) {
+// Solidity: function getBlock(blocknum uint256) constant returns(bytes32, uint256, bytes32)
+func (_Plasma *PlasmaCaller) GetBlock(opts *bind.CallOpts, blocknum *big.Int) ([32]byte, *big.Int, [32]byte, error) {
 	var (
 		ret0 = new([32]byte)
 		ret1 = new(*big.Int)
+		ret2 = new([32]byte)
 	)
 	out := &[]interface{}{
 		ret0,
 		ret1,
+		ret2,
 	}
 	err := _Plasma.contract.Call(opts, out, "getBlock", blocknum)
-	return *ret0, *ret1, err
+	return *ret0, *ret1, *ret2, err
 }
 
 // GetBlock is a free data retrieval call binding the contract method 0x04c07569.
 //
-// Solidity: function getBlock(blocknum uint256) constant returns(bytes32, uint256)
-func (_Plasma *PlasmaSession) GetBlock(blocknum *big.Int) ([32]byte, *big.Int, error) {
+// Solidity: function getBlock(blocknum uint256) constant returns(bytes32, uint256, bytes32)
+func (_Plasma *PlasmaSession) GetBlock(blocknum *big.Int) ([32]byte, *big.Int, [32]byte, error) {
 	return _Plasma.Contract.GetBlock(&_Plasma.CallOpts, blocknum)
 }
 
 // GetBlock is a free data retrieval call binding the contract method 0x04c07569.
 //
-// Solidity: function getBlock(blocknum uint256) constant returns(bytes32, uint256)
-func (_Plasma *PlasmaCallerSession) GetBlock(blocknum *big.Int) ([32]byte, *big.Int, error) {
+// Solidity: function getBlock(blocknum uint256) constant returns(bytes32, uint256, bytes32)
+func (_Plasma *PlasmaCallerSession) GetBlock(blocknum *big.Int) ([32]byte, *big.Int, [32]byte, error) {
 	return _Plasma.Contract.GetBlock(&_Plasma.CallOpts, blocknum)
 }
 
@@ -608,6 +666,27 @@ func (_Plasma *PlasmaTransactorSession) Deposit(txBytes []byte) (*types.Transact
 	return _Plasma.Contract.Deposit(&_Plasma.TransactOpts, txBytes)
 }
 
+// DepositNFT is a paid mutator transaction binding the contract method 0x9ac98aec.
+//
+// Solidity: function depositNFT(token address, id uint256, txBytes bytes) returns()
+func (_Plasma *PlasmaTransactor) DepositNFT(opts *bind.TransactOpts, token common.Address, id *big.Int, txBytes []byte) (*types.Transaction, error) {
+	return _Plasma.contract.Transact(opts, "depositNFT", token, id, txBytes)
+}
+
+// DepositNFT is a paid mutator transaction binding the contract method 0x9ac98aec.
+//
+// Solidity: function depositNFT(token address, id uint256, txBytes bytes) returns()
+func (_Plasma *PlasmaSession) DepositNFT(token common.Address, id *big.Int, txBytes []byte) (*types.Transaction, error) {
+	return _Plasma.Contract.DepositNFT(&_Plasma.TransactOpts, token, id, txBytes)
+}
+
+// DepositNFT is a paid mutator transaction binding the contract method 0x9ac98aec.
+//
+// Solidity: function depositNFT(token address, id uint256, txBytes bytes) returns()
+func (_Plasma *PlasmaTransactorSession) DepositNFT(token common.Address, id *big.Int, txBytes []byte) (*types.Transaction, error) {
+	return _Plasma.Contract.DepositNFT(&_Plasma.TransactOpts, token, id, txBytes)
+}
+
 // Finalize is a paid mutator transaction binding the contract method 0x4bb278f3.
 //
 // Solidity: function finalize() returns()
@@ -629,6 +708,27 @@ func (_Plasma *PlasmaTransactorSession) Finalize() (*types.Transaction, error) {
 	return _Plasma.Contract.Finalize(&_Plasma.TransactOpts)
 }
 
+// StartClaimExit is a paid mutator transaction binding the contract method 0x96bbef25.
+//
+// Solidity: function startClaimExit(blocknum uint256, txindex uint256, oindex uint256, txBytes bytes, proof bytes, preimage bytes, sigs bytes) returns()
+func (_Plasma *PlasmaTransactor) StartClaimExit(opts *bind.TransactOpts, blocknum *big.Int, txindex *big.Int, oindex *big.Int, txBytes []byte, proof []byte, preimage []byte, sigs []byte) (*types.Transaction, error) {
+	return _Plasma.contract.Transact(opts, "startClaimExit", blocknum, txindex, oindex, txBytes, proof, preimage, sigs)
+}
+
+// StartClaimExit is a paid mutator transaction binding the contract method 0x96bbef25.
+//
+// Solidity: function startClaimExit(blocknum uint256, txindex uint256, oindex uint256, txBytes bytes, proof bytes, preimage bytes, sigs bytes) returns()
+func (_Plasma *PlasmaSession) StartClaimExit(blocknum *big.Int, txindex *big.Int, oindex *big.Int, txBytes []byte, proof []byte, preimage []byte, sigs []byte) (*types.Transaction, error) {
+	return _Plasma.Contract.StartClaimExit(&_Plasma.TransactOpts, blocknum, txindex, oindex, txBytes, proof, preimage, sigs)
+}
+
+// StartClaimExit is a paid mutator transaction binding the contract method 0x96bbef25.
+//
+// Solidity: function startClaimExit(blocknum uint256, txindex uint256, oindex uint256, txBytes bytes, proof bytes, preimage bytes, sigs bytes) returns()
+func (_Plasma *PlasmaTransactorSession) StartClaimExit(blocknum *big.Int, txindex *big.Int, oindex *big.Int, txBytes []byte, proof []byte, preimage []byte, sigs []byte) (*types.Transaction, error) {
+	return _Plasma.Contract.StartClaimExit(&_Plasma.TransactOpts, blocknum, txindex, oindex, txBytes, proof, preimage, sigs)
+}
+
 // StartExit is a paid mutator transaction binding the contract method 0xacef16c5.
 //
 // Solidity: function startExit(blocknum uint256, txindex uint256, oindex uint256, txBytes bytes, proof bytes) returns()
@@ -650,25 +750,67 @@ func (_Plasma *PlasmaTransactorSession) StartExit(blocknum *big.Int, txindex *bi
 	return _Plasma.Contract.StartExit(&_Plasma.TransactOpts, blocknum, txindex, oindex, txBytes, proof)
 }
 
-// SubmitBlock is a paid mutator transaction binding the contract method 0xbaa47694.
+// StartMultisigExit is a paid mutator transaction binding the contract method 0x7ac8f1af.
 //
-// Solidity: function submitBlock(root bytes32) returns()
-func (_Plasma *PlasmaTransactor) SubmitBlock(opts *bind.TransactOpts, root [32]byte) (*types.Transaction, error) {
-	return _Plasma.contract.Transact(opts, "submitBlock", root)
+// Solidity: function startMultisigExit(blocknum uint256, txindex uint256, oindex uint256, txBytes bytes, proof bytes, sigs bytes) returns()
+func (_Plasma *PlasmaTransactor) StartMultisigExit(opts *bind.TransactOpts, blocknum *big.Int, txindex *big.Int, oindex *big.Int, txBytes []byte, proof []byte, sigs []byte) (*types.Transaction, error) {
+	return _Plasma.contract.Transact(opts, "startMultisigExit", blocknum, txindex, oindex, txBytes, proof, sigs)
 }
 
-// SubmitBlock is a paid mutator transaction binding the contract method 0xbaa47694.
+// StartMultisigExit is a paid mutator transaction binding the contract method 0x7ac8f1af.
 //
-// Solidity: function submitBlock(root bytes32) returns()
-func (_Plasma *PlasmaSession) SubmitBlock(root [32]byte) (*types.Transaction, error) {
-	return _Plasma.Contract.SubmitBlock(&_Plasma.TransactOpts, root)
+// Solidity: function startMultisigExit(blocknum uint256, txindex uint256, oindex uint256, txBytes bytes, proof bytes, sigs bytes) returns()
+func (_Plasma *PlasmaSession) StartMultisigExit(blocknum *big.Int, txindex *big.Int, oindex *big.Int, txBytes []byte, proof []byte, sigs []byte) (*types.Transaction, error) {
+	return _Plasma.Contract.StartMultisigExit(&_Plasma.TransactOpts, blocknum, txindex, oindex, txBytes, proof, sigs)
 }
 
-// SubmitBlock is a paid mutator transaction binding the contract method 0xbaa47694.
+// StartMultisigExit is a paid mutator transaction binding the contract method 0x7ac8f1af.
 //
-// Solidity: function submitBlock(root bytes32) returns()
-func (_Plasma *PlasmaTransactorSession) SubmitBlock(root [32]byte) (*types.Transaction, error) {
-	return _Plasma.Contract.SubmitBlock(&_Plasma.TransactOpts, root)
+// Solidity: function startMultisigExit(blocknum uint256, txindex uint256, oindex uint256, txBytes bytes, proof bytes, sigs bytes) returns()
+func (_Plasma *PlasmaTransactorSession) StartMultisigExit(blocknum *big.Int, txindex *big.Int, oindex *big.Int, txBytes []byte, proof []byte, sigs []byte) (*types.Transaction, error) {
+	return _Plasma.Contract.StartMultisigExit(&_Plasma.TransactOpts, blocknum, txindex, oindex, txBytes, proof, sigs)
+}
+
+// SubmitBlock is a paid mutator transaction binding the contract method 0xe9573ffc.
+//
+// Solidity: function submitBlock(root bytes32, tokenRoot bytes32) returns()
+func (_Plasma *PlasmaTransactor) SubmitBlock(opts *bind.TransactOpts, root [32]byte, tokenRoot [32]byte) (*types.Transaction, error) {
+	return _Plasma.contract.Transact(opts, "submitBlock", root, tokenRoot)
+}
+
+// SubmitBlock is a paid mutator transaction binding the contract method 0xe9573ffc.
+//
+// Solidity: function submitBlock(root bytes32, tokenRoot bytes32) returns()
+func (_Plasma *PlasmaSession) SubmitBlock(root [32]byte, tokenRoot [32]byte) (*types.Transaction, error) {
+	return _Plasma.Contract.SubmitBlock(&_Plasma.TransactOpts, root, tokenRoot)
+}
+
+// SubmitBlock is a paid mutator transaction binding the contract method 0xe9573ffc.
+//
+// Solidity: function submitBlock(root bytes32, tokenRoot bytes32) returns()
+func (_Plasma *PlasmaTransactorSession) SubmitBlock(root [32]byte, tokenRoot [32]byte) (*types.Transaction, error) {
+	return _Plasma.Contract.SubmitBlock(&_Plasma.TransactOpts, root, tokenRoot)
+}
+
+// WithdrawToken is a paid mutator transaction binding the contract method 0x4165b765.
+//
+// Solidity: function withdrawToken(tokenId uint64) returns()
+func (_Plasma *PlasmaTransactor) WithdrawToken(opts *bind.TransactOpts, tokenId uint64) (*types.Transaction, error) {
+	return _Plasma.contract.Transact(opts, "withdrawToken", tokenId)
+}
+
+// WithdrawToken is a paid mutator transaction binding the contract method 0x4165b765.
+//
+// Solidity: function withdrawToken(tokenId uint64) returns()
+func (_Plasma *PlasmaSession) WithdrawToken(tokenId uint64) (*types.Transaction, error) {
+	return _Plasma.Contract.WithdrawToken(&_Plasma.TransactOpts, tokenId)
+}
+
+// WithdrawToken is a paid mutator transaction binding the contract method 0x4165b765.
+//
+// Solidity: function withdrawToken(tokenId uint64) returns()
+func (_Plasma *PlasmaTransactorSession) WithdrawToken(tokenId uint64) (*types.Transaction, error) {
+	return _Plasma.Contract.WithdrawToken(&_Plasma.TransactOpts, tokenId)
 }
 
 // PlasmaChallengeFailureIterator is returned from FilterChallengeFailure and is used to iterate over the raw logs and unpacked data for ChallengeFailure events raised by the Plasma contract.
@@ -1601,14 +1743,16 @@ func (it *PlasmaDepositIterator) Close() error {
 
 // PlasmaDeposit represents a Deposit event raised by the Plasma contract.
 type PlasmaDeposit struct {
-	Sender common.Address
-	Value  *big.Int
-	Raw    types.Log // Blockchain specific contextual infos
+	Sender   common.Address
+	Value    *big.Int
+	Blocknum *big.Int
+	TokenId  *big.Int
+	Raw      types.Log // Blockchain specific contextual infos
 }
 
-// FilterDeposit is a free log retrieval operation binding the contract event 0xe1fffcc4923d04b559f4d29a8bfc6cda04eb5b0d3c460751c2402c5c5cc9109c.
+// FilterDeposit is a free log retrieval operation binding the contract event 0x36af321ec8d3c75236829c5317affd40ddb308863a1236d2d277a4025cccee1e.
 //
-// Solidity: e Deposit(sender address, value uint256)
+// Solidity: e Deposit(sender address, value uint256, blocknum uint256, tokenId uint256)
 func (_Plasma *PlasmaFilterer) FilterDeposit(opts *bind.FilterOpts) (*PlasmaDepositIterator, error) {
 
 	logs, sub, err := _Plasma.contract.FilterLogs(opts, "Deposit")
@@ -1618,9 +1762,9 @@ func (_Plasma *PlasmaFilterer) FilterDeposit(opts *bind.FilterOpts) (*PlasmaDepo
 	return &PlasmaDepositIterator{contract: _Plasma.contract, event: "Deposit", logs: logs, sub: sub}, nil
 }
 
-// WatchDeposit is a free log subscription operation binding the contract event 0xe1fffcc4923d04b559f4d29a8bfc6cda04eb5b0d3c460751c2402c5c5cc9109c.
+// WatchDeposit is a free log subscription operation binding the contract event 0x36af321ec8d3c75236829c5317affd40ddb308863a1236d2d277a4025cccee1e.
 //
-// Solidity: e Deposit(sender address, value uint256)
+// Solidity: e Deposit(sender address, value uint256, blocknum uint256, tokenId uint256)
 func (_Plasma *PlasmaFilterer) WatchDeposit(opts *bind.WatchOpts, sink chan<- *PlasmaDeposit) (event.Subscription, error) {
 
 	logs, sub, err := _Plasma.contract.WatchLogs(opts, "Deposit")
@@ -16,5 +16,59 @@ contract('Plasma', async (accounts) => {
         value: 100000
       });
     });
+
+    it('should number deposits between submitted blocks', async () => {
+      const depositTx = '0xf838808080808080808094627306090abab3a6e1400e9345bc60c78a8bef57830186a09400000000000000000000000000000000000000008080';
+      const interval = 1000;
+      const blocknum = (await deployed.currentChildBlock()).toNumber();
+      const offset = (await deployed.currentDepositBlock()).toNumber();
+
+      const res = await deployed.deposit(depositTx, {
+        from: accounts[0],
+        value: 100000
+      });
+
+      assert.equal(res.logs[0].event, 'Deposit');
+      assert.equal(res.logs[0].args.blocknum.toNumber(), blocknum - interval + offset);
+      assert.equal((await deployed.currentChildBlock()).toNumber(), blocknum);
+      assert.equal((await deployed.currentDepositBlock()).toNumber(), offset + 1);
+    });
+
+    it('should not give a deposit the number of the next submitted block', async () => {
+      const depositTx = '0xf838808080808080808094627306090abab3a6e1400e9345bc60c78a8bef57830186a09400000000000000000000000000000000000000008080';
+      const root = '0x' + '11'.repeat(32);
+      const tokenRoot = '0x' + '22'.repeat(32);
+      const blocknum = (await deployed.currentChildBlock()).toNumber();
+
+      // The root node packaged the block before the deposit, and submits it
+      // after the deposit is mined.
+      const res = await deployed.deposit(depositTx, {
+        from: accounts[0],
+        value: 100000
+      });
+      await deployed.submitBlock(root, tokenRoot, { from: accounts[0] });
+
+      assert.isBelow(res.logs[0].args.blocknum.toNumber(), blocknum);
+      assert.equal((await deployed.getBlock(blocknum))[0], root);
+      assert.equal((await deployed.getBlock(blocknum))[2], tokenRoot);
+      assert.equal((await deployed.currentDepositBlock()).toNumber(), 1);
+    });
+
+    it('should reject deposits that credit a second output', async () => {
+      // Output 1 pays 100000 to accounts[1] on top of the deposit.
+      const depositTx = '0xf83b808080808080808094627306090abab3a6e1400e9345bc60c78a8bef57830186a094f17f52151ebef6c7334fad080c5704d77216b732830186a080';
+      let reverted = false;
+
+      try {
+        await deployed.deposit(depositTx, {
+          from: accounts[0],
+          value: 100000
+        });
+      } catch (err) {
+        reverted = true;
+      }
+
+      assert.isTrue(reverted);
+    });
   });
 });
\ No newline at end of file
@@ -38,7 +38,7 @@ func (dao *LevelAddressDao) Balance(addr *common.Address) (*big.Int, error) {
 }
 
 func (dao *LevelAddressDao) SpendableTxs(addr *common.Address) ([]chain.Transaction, error) {
-	prefix := earnKey(addr)
+	prefix := earnPrefixKey(addr)
 	iter := dao.db.NewIterator(levelutil.BytesPrefix(prefix), nil)
 
 	earnedMap := make(map[string]*chain.Flow)
@@ -54,7 +54,7 @@ func (dao *LevelAddressDao) SpendableTxs(addr *common.Address) ([]chain.Transact
 		earnedMap[common.ToHex(flow.Hash)] = &flow
 	}
 
-	prefix = spendKey(addr)
+	prefix = spendPrefixKey(addr)
 	iter = dao.db.NewIterator(levelutil.BytesPrefix(prefix), nil)
 
 	for iter.Next() {
@@ -71,8 +71,18 @@ func (dao *LevelAddressDao) SpendableTxs(addr *common.Address) ([]chain.Transact
 		}
 	}
 
+	// A transaction paying addr several outputs is returned once.
 	var ret []chain.Transaction
+	seen := make(map[chain.Input]bool)
+
 	for _, flow := range earnedMap {
+		key := chain.Input{BlkNum: flow.BlkNum, TxIdx: flow.TxIdx}
+
+		if seen[key] {
+			continue
+		}
+
+		seen[key] = true
 		tx, err := dao.txDao.FindByBlockNumTxIdx(flow.BlkNum, flow.TxIdx)
 
 		if err != nil {
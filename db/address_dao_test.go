@@ -0,0 +1,66 @@
+package db
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/kyokan/plasma/chain"
+	"github.com/stretchr/testify/require"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/storage"
+)
+
+func testDeposit(owner common.Address, blkNum uint64) chain.Transaction {
+	tx := chain.NewTransaction(nil, []*chain.Output{chain.NewOutput(owner, big.NewInt(100))}, big.NewInt(0))
+	tx.BlkNum = blkNum
+	return *tx
+}
+
+func utxoBlocks(t *testing.T, dao AddressDao, addr common.Address) map[uint64]bool {
+	txs, err := dao.UTXOs(&addr)
+	require.NoError(t, err)
+
+	blocks := make(map[uint64]bool)
+
+	for _, tx := range txs {
+		blocks[tx.BlkNum] = true
+	}
+
+	return blocks
+}
+
+func Test_AddressDaoKeepsEveryOutput(t *testing.T) {
+	level, err := leveldb.Open(storage.NewMemStorage(), nil)
+	require.NoError(t, err)
+	defer level.Close()
+
+	txDao := &LevelTransactionDao{db: level}
+	addressDao := &LevelAddressDao{db: level, txDao: txDao}
+	alice, bob := common.Address{1}, common.Address{2}
+
+	require.NoError(t, txDao.SaveMany([]chain.Transaction{testDeposit(alice, 1), testDeposit(alice, 2)}))
+
+	pay := chain.NewTransaction(
+		[]*chain.Input{chain.NewInput(1, 0, 0)},
+		[]*chain.Output{chain.NewOutput(bob, big.NewInt(40)), chain.NewOutput(alice, big.NewInt(60))},
+		big.NewInt(0),
+	)
+	pay.BlkNum = 3
+	require.NoError(t, txDao.Save(pay))
+
+	require.Equal(t, map[uint64]bool{2: true, 3: true}, utxoBlocks(t, addressDao, alice))
+	require.Equal(t, map[uint64]bool{3: true}, utxoBlocks(t, addressDao, bob))
+
+	// Indexes written by address only are rebuilt.
+	batch := new(leveldb.Batch)
+	batch.Delete(addressIndexVersionKey)
+	batch.Put(prefixKey(earnKeyPrefix, "stale"), []byte{})
+	require.NoError(t, level.Write(batch, nil))
+	require.NoError(t, txDao.ReindexAddresses())
+
+	has, err := level.Has(prefixKey(earnKeyPrefix, "stale"), nil)
+	require.NoError(t, err)
+	require.False(t, has)
+	require.Equal(t, map[uint64]bool{2: true, 3: true}, utxoBlocks(t, addressDao, alice))
+}
@@ -1,11 +1,13 @@
 package db
 
 import (
+	"sort"
 	"strconv"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/kyokan/plasma/chain"
 	"github.com/syndtr/goleveldb/leveldb"
+	levelutil "github.com/syndtr/goleveldb/leveldb/util"
 	"github.com/ethereum/go-ethereum/rlp"
 )
 
@@ -13,10 +15,15 @@ const blockKeyPrefix = "blk"
 
 const latestKey = "LATEST_BLOCK"
 
+const submissionStatusKeyPrefix = "blksub"
+
 type BlockDao interface {
 	BlockAtHeight(num uint64) (*chain.Block, error)
 	Save(blk *chain.Block) error
 	Latest() (*chain.Block, error)
+	SaveSubmission(sub *chain.BlockSubmission) error
+	Submission(num uint64) (*chain.BlockSubmission, error)
+	UnminedSubmissions() ([]chain.BlockSubmission, error)
 }
 
 type LevelBlockDao struct {
@@ -40,11 +47,18 @@ func (dao *LevelBlockDao) Save(blk *chain.Block) error {
 
 	gd := &GuardedDb{db: dao.db}
 	gd.Put(key, enc, nil)
-	gd.Put(blockPrefixKey(latestKey), key, nil)
 	gd.Put(blockNumKey(blk.Header.Number), key, nil)
 
+	// Deposit blocks are numbered below the next submitted block, and do
+	// not move the latest block.
+	if chain.IsDepositBlock(blk.Header.Number) {
+		return gd.err
+	}
+
+	gd.Put(blockPrefixKey(latestKey), key, nil)
+
 	if gd.err != nil {
-		return err
+		return gd.err
 	}
 
 	dao.height = blk.Header.Number
@@ -52,6 +66,7 @@ func (dao *LevelBlockDao) Save(blk *chain.Block) error {
 	return nil
 }
 
+// Latest returns the latest block that is not a deposit block.
 func (dao *LevelBlockDao) Latest() (*chain.Block, error) {
 	key := blockPrefixKey(latestKey)
 
@@ -117,6 +132,72 @@ func (dao *LevelBlockDao) blockHeight() (uint64, error) {
 	return dao.height, nil
 }
 
+func (dao *LevelBlockDao) SaveSubmission(sub *chain.BlockSubmission) error {
+	enc, err := rlp.EncodeToBytes(sub)
+
+	if err != nil {
+		return err
+	}
+
+	gd := &GuardedDb{db: dao.db}
+	gd.Put(submissionStatusKey(sub.Number), enc, nil)
+	return gd.err
+}
+
+func (dao *LevelBlockDao) Submission(num uint64) (*chain.BlockSubmission, error) {
+	gd := &GuardedDb{db: dao.db}
+	data := gd.Get(submissionStatusKey(num), nil)
+
+	if gd.err != nil {
+		return nil, gd.err
+	}
+
+	var sub chain.BlockSubmission
+	err := rlp.DecodeBytes(data, &sub)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &sub, nil
+}
+
+// UnminedSubmissions returns the submissions that are pending or failed,
+// ordered by block number.
+func (dao *LevelBlockDao) UnminedSubmissions() ([]chain.BlockSubmission, error) {
+	iter := dao.db.NewIterator(levelutil.BytesPrefix(prefixKey(submissionStatusKeyPrefix)), nil)
+	defer iter.Release()
+
+	var subs []chain.BlockSubmission
+
+	for iter.Next() {
+		var sub chain.BlockSubmission
+		err := rlp.DecodeBytes(iter.Value(), &sub)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if sub.Status != chain.SubmissionMined {
+			subs = append(subs, sub)
+		}
+	}
+
+	if err := iter.Error(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(subs, func(i, j int) bool {
+		return subs[i].Number < subs[j].Number
+	})
+
+	return subs, nil
+}
+
+func submissionStatusKey(num uint64) []byte {
+	return prefixKey(submissionStatusKeyPrefix, strconv.FormatUint(num, 10))
+}
+
 func blockNumKey(num uint64) []byte {
 	return blockPrefixKey(strconv.FormatUint(num, 10))
 }
@@ -0,0 +1,31 @@
+package db
+
+// EventCheckpoint stores an event watcher's checkpoint as the index of the
+// last event a listener saw.
+type EventCheckpoint struct {
+	load func() (uint64, error)
+	save func(idx uint64) error
+}
+
+func DepositCheckpoint(dao DepositDao) *EventCheckpoint {
+	return &EventCheckpoint{load: dao.LastDepositEventIdx, save: dao.SaveDepositEventIdx}
+}
+
+func ExitCheckpoint(dao ExitDao) *EventCheckpoint {
+	return &EventCheckpoint{load: dao.LastExitEventIdx, save: dao.SaveExitEventIdx}
+}
+
+// Checkpoint returns 0 if no checkpoint was saved yet.
+func (c *EventCheckpoint) Checkpoint() (uint64, error) {
+	idx, err := c.load()
+
+	if err != nil && err.Error() == "leveldb: not found" {
+		return 0, nil
+	}
+
+	return idx, err
+}
+
+func (c *EventCheckpoint) SaveCheckpoint(blockNum uint64) error {
+	return c.save(blockNum)
+}
@@ -15,6 +15,9 @@ type Database struct {
 	DepositDao      DepositDao
 	ExitDao         ExitDao
 	InvalidBlockDao InvalidBlockDao
+	SubmissionDao   SubmissionDao
+	SwapDao         SwapDao
+	TokenDao        TokenDao
 }
 
 func CreateLevelDatabase(location string) (*leveldb.DB, *Database, error) {
@@ -33,6 +36,13 @@ func CreateLevelDatabase(location string) (*leveldb.DB, *Database, error) {
 	depositDao := LevelDepositDao{db: level}
 	exitDao := LevelExitDao{db: level}
 	invalidBlockDao := LevelInvalidBlockDao{db: level}
+	submissionDao := LevelSubmissionDao{db: level}
+	swapDao := LevelSwapDao{db: level}
+	tokenDao := LevelTokenDao{db: level}
+
+	if err := txDao.ReindexAddresses(); err != nil {
+		return nil, nil, err
+	}
 
 	return level, &Database{
 		TxDao:           &txDao,
@@ -42,5 +52,8 @@ func CreateLevelDatabase(location string) (*leveldb.DB, *Database, error) {
 		DepositDao:      &depositDao,
 		ExitDao:         &exitDao,
 		InvalidBlockDao: &invalidBlockDao,
+		SubmissionDao:   &submissionDao,
+		SwapDao:         &swapDao,
+		TokenDao:        &tokenDao,
 	}, nil
 }
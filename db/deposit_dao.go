@@ -1,14 +1,23 @@
 package db
 
 import (
+	"strconv"
+
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/syndtr/goleveldb/leveldb"
+	levelutil "github.com/syndtr/goleveldb/leveldb/util"
 )
 
 const latestDepositIdxKey = "LATEST_DEPOSIT_IDX"
 
+const depositCreditKeyPrefix = "depcredit"
+
 type DepositDao interface {
 	LastDepositEventIdx() (uint64, error)
 	SaveDepositEventIdx(idx uint64) error
+	CreditedBlock(txHash common.Hash, logIndex uint) (uint64, bool, error)
+	SaveCredit(txHash common.Hash, logIndex uint, blkNum uint64) error
+	DeleteUnsavedCredits() (int, error)
 }
 
 type LevelDepositDao struct {
@@ -40,3 +49,71 @@ func (dao *LevelDepositDao) LastDepositEventIdx() (uint64, error) {
 
 	return bytesToUint64(b), nil
 }
+
+// CreditedBlock returns the plasma block a deposit, identified by the hash of
+// the Ethereum transaction and the index of its log, was credited in.
+func (dao *LevelDepositDao) CreditedBlock(txHash common.Hash, logIndex uint) (uint64, bool, error) {
+	key := depositCreditKey(txHash, logIndex)
+
+	exists, err := dao.db.Has(key, nil)
+
+	if err != nil {
+		return 0, false, err
+	}
+
+	if !exists {
+		return 0, false, nil
+	}
+
+	gd := &GuardedDb{db: dao.db}
+	b := gd.Get(key, nil)
+
+	if gd.err != nil {
+		return 0, false, gd.err
+	}
+
+	return bytesToUint64(b), true, nil
+}
+
+func (dao *LevelDepositDao) SaveCredit(txHash common.Hash, logIndex uint, blkNum uint64) error {
+	gd := &GuardedDb{db: dao.db}
+	gd.Put(depositCreditKey(txHash, logIndex), uint64ToBytes(blkNum), nil)
+	return gd.err
+}
+
+// DeleteUnsavedCredits removes credits recorded for blocks that were never
+// saved. A credit is saved before its block.
+func (dao *LevelDepositDao) DeleteUnsavedCredits() (int, error) {
+	iter := dao.db.NewIterator(levelutil.BytesPrefix(prefixKey(depositCreditKeyPrefix)), nil)
+	defer iter.Release()
+
+	batch := new(leveldb.Batch)
+
+	for iter.Next() {
+		saved, err := dao.db.Has(blockNumKey(bytesToUint64(iter.Value())), nil)
+
+		if err != nil {
+			return 0, err
+		}
+
+		if !saved {
+			key := make([]byte, len(iter.Key()))
+			copy(key, iter.Key())
+			batch.Delete(key)
+		}
+	}
+
+	if err := iter.Error(); err != nil {
+		return 0, err
+	}
+
+	if batch.Len() == 0 {
+		return 0, nil
+	}
+
+	return batch.Len(), dao.db.Write(batch, nil)
+}
+
+func depositCreditKey(txHash common.Hash, logIndex uint) []byte {
+	return prefixKey(depositCreditKeyPrefix, txHash.Hex(), strconv.FormatUint(uint64(logIndex), 10))
+}
@@ -69,3 +69,63 @@ func (_m *BlockDao) Save(blk *chain.Block) error {
 
 	return r0
 }
+
+// SaveSubmission provides a mock function with given fields: sub
+func (_m *BlockDao) SaveSubmission(sub *chain.BlockSubmission) error {
+	ret := _m.Called(sub)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*chain.BlockSubmission) error); ok {
+		r0 = rf(sub)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Submission provides a mock function with given fields: num
+func (_m *BlockDao) Submission(num uint64) (*chain.BlockSubmission, error) {
+	ret := _m.Called(num)
+
+	var r0 *chain.BlockSubmission
+	if rf, ok := ret.Get(0).(func(uint64) *chain.BlockSubmission); ok {
+		r0 = rf(num)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*chain.BlockSubmission)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(uint64) error); ok {
+		r1 = rf(num)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UnminedSubmissions provides a mock function with given fields:
+func (_m *BlockDao) UnminedSubmissions() ([]chain.BlockSubmission, error) {
+	ret := _m.Called()
+
+	var r0 []chain.BlockSubmission
+	if rf, ok := ret.Get(0).(func() []chain.BlockSubmission); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]chain.BlockSubmission)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
@@ -1,6 +1,7 @@
 // Code generated by mockery v1.0.0. DO NOT EDIT.
 package mocks
 
+import common "github.com/ethereum/go-ethereum/common"
 import mock "github.com/stretchr/testify/mock"
 
 // DepositDao is an autogenerated mock type for the DepositDao type
@@ -8,6 +9,55 @@ type DepositDao struct {
 	mock.Mock
 }
 
+// CreditedBlock provides a mock function with given fields: txHash, logIndex
+func (_m *DepositDao) CreditedBlock(txHash common.Hash, logIndex uint) (uint64, bool, error) {
+	ret := _m.Called(txHash, logIndex)
+
+	var r0 uint64
+	if rf, ok := ret.Get(0).(func(common.Hash, uint) uint64); ok {
+		r0 = rf(txHash, logIndex)
+	} else {
+		r0 = ret.Get(0).(uint64)
+	}
+
+	var r1 bool
+	if rf, ok := ret.Get(1).(func(common.Hash, uint) bool); ok {
+		r1 = rf(txHash, logIndex)
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(common.Hash, uint) error); ok {
+		r2 = rf(txHash, logIndex)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// DeleteUnsavedCredits provides a mock function with given fields:
+func (_m *DepositDao) DeleteUnsavedCredits() (int, error) {
+	ret := _m.Called()
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func() int); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // LastDepositEventIdx provides a mock function with given fields:
 func (_m *DepositDao) LastDepositEventIdx() (uint64, error) {
 	ret := _m.Called()
@@ -42,3 +92,17 @@ func (_m *DepositDao) SaveDepositEventIdx(idx uint64) error {
 
 	return r0
 }
+
+// SaveCredit provides a mock function with given fields: txHash, logIndex, blkNum
+func (_m *DepositDao) SaveCredit(txHash common.Hash, logIndex uint, blkNum uint64) error {
+	ret := _m.Called(txHash, logIndex, blkNum)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(common.Hash, uint, uint64) error); ok {
+		r0 = rf(txHash, logIndex, blkNum)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
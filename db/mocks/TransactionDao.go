@@ -56,6 +56,29 @@ func (_m *TransactionDao) FindByBlockNumTxIdx(blkNum uint64, txIdx uint32) (*cha
 	return r0, r1
 }
 
+// FindByMetadata provides a mock function with given fields: metadata
+func (_m *TransactionDao) FindByMetadata(metadata []byte) ([]chain.Transaction, error) {
+	ret := _m.Called(metadata)
+
+	var r0 []chain.Transaction
+	if rf, ok := ret.Get(0).(func([]byte) []chain.Transaction); ok {
+		r0 = rf(metadata)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]chain.Transaction)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func([]byte) error); ok {
+		r1 = rf(metadata)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Save provides a mock function with given fields: tx
 func (_m *TransactionDao) Save(tx *chain.Transaction) error {
 	ret := _m.Called(tx)
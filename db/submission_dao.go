@@ -0,0 +1,64 @@
+package db
+
+import (
+	"strconv"
+
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/kyokan/plasma/eth"
+	"github.com/syndtr/goleveldb/leveldb"
+	levelutil "github.com/syndtr/goleveldb/leveldb/util"
+)
+
+const submissionKeyPrefix = "ethsub"
+
+// SubmissionDao persists the Ethereum transactions the transaction manager
+// is still tracking, keyed by nonce.
+type SubmissionDao interface {
+	SaveSubmission(record *eth.SubmissionRecord) error
+	DeleteSubmission(nonce uint64) error
+	Submissions() ([]eth.SubmissionRecord, error)
+}
+
+type LevelSubmissionDao struct {
+	db *leveldb.DB
+}
+
+func (dao *LevelSubmissionDao) SaveSubmission(record *eth.SubmissionRecord) error {
+	enc, err := rlp.EncodeToBytes(record)
+
+	if err != nil {
+		return err
+	}
+
+	gd := &GuardedDb{db: dao.db}
+	gd.Put(submissionPrefixKey(strconv.FormatUint(record.Nonce, 10)), enc, nil)
+	return gd.err
+}
+
+func (dao *LevelSubmissionDao) DeleteSubmission(nonce uint64) error {
+	return dao.db.Delete(submissionPrefixKey(strconv.FormatUint(nonce, 10)), nil)
+}
+
+func (dao *LevelSubmissionDao) Submissions() ([]eth.SubmissionRecord, error) {
+	iter := dao.db.NewIterator(levelutil.BytesPrefix(submissionPrefixKey()), nil)
+	defer iter.Release()
+
+	var records []eth.SubmissionRecord
+
+	for iter.Next() {
+		var record eth.SubmissionRecord
+		err := rlp.DecodeBytes(iter.Value(), &record)
+
+		if err != nil {
+			return nil, err
+		}
+
+		records = append(records, record)
+	}
+
+	return records, iter.Error()
+}
+
+func submissionPrefixKey(parts ...string) []byte {
+	return prefixKey(submissionKeyPrefix, parts...)
+}
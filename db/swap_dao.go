@@ -0,0 +1,104 @@
+package db
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/kyokan/plasma/chain"
+	"github.com/syndtr/goleveldb/leveldb"
+	levelutil "github.com/syndtr/goleveldb/leveldb/util"
+)
+
+const (
+	swapKeyPrefix             = "swap"
+	swapCounterpartyKeyPrefix = "swapfor"
+)
+
+// SwapDao persists swap proposals until the counterparty accepts them,
+// keyed by ID and indexed by counterparty.
+type SwapDao interface {
+	SaveSwap(swap *chain.Swap) error
+	FindSwap(id common.Hash) (*chain.Swap, error)
+	SwapsFor(counterparty common.Address) ([]chain.Swap, error)
+	DeleteSwap(swap *chain.Swap) error
+}
+
+type LevelSwapDao struct {
+	db *leveldb.DB
+}
+
+func (dao *LevelSwapDao) SaveSwap(swap *chain.Swap) error {
+	enc, err := rlp.EncodeToBytes(swap)
+
+	if err != nil {
+		return err
+	}
+
+	id := swap.ID()
+	batch := new(leveldb.Batch)
+	batch.Put(swapKey(id), enc)
+	batch.Put(swapCounterpartyKey(swap.Counterparty, id), id.Bytes())
+	return dao.db.Write(batch, nil)
+}
+
+// FindSwap returns the proposal with the given ID, or nil if there is none.
+func (dao *LevelSwapDao) FindSwap(id common.Hash) (*chain.Swap, error) {
+	data, err := dao.db.Get(swapKey(id), nil)
+
+	if err == leveldb.ErrNotFound {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	var swap chain.Swap
+	err = rlp.DecodeBytes(data, &swap)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &swap, nil
+}
+
+func (dao *LevelSwapDao) SwapsFor(counterparty common.Address) ([]chain.Swap, error) {
+	iter := dao.db.NewIterator(levelutil.BytesPrefix(swapCounterpartyPrefixKey(counterparty)), nil)
+	defer iter.Release()
+
+	var swaps []chain.Swap
+
+	for iter.Next() {
+		swap, err := dao.FindSwap(common.BytesToHash(iter.Value()))
+
+		if err != nil {
+			return nil, err
+		}
+
+		if swap != nil {
+			swaps = append(swaps, *swap)
+		}
+	}
+
+	return swaps, iter.Error()
+}
+
+func (dao *LevelSwapDao) DeleteSwap(swap *chain.Swap) error {
+	id := swap.ID()
+	batch := new(leveldb.Batch)
+	batch.Delete(swapKey(id))
+	batch.Delete(swapCounterpartyKey(swap.Counterparty, id))
+	return dao.db.Write(batch, nil)
+}
+
+func swapKey(id common.Hash) []byte {
+	return prefixKey(swapKeyPrefix, id.Hex())
+}
+
+func swapCounterpartyKey(counterparty common.Address, id common.Hash) []byte {
+	return prefixKey(swapCounterpartyKeyPrefix, counterparty.Hex(), id.Hex())
+}
+
+func swapCounterpartyPrefixKey(counterparty common.Address) []byte {
+	return append(prefixKey(swapCounterpartyKeyPrefix, counterparty.Hex()), []byte("::")...)
+}
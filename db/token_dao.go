@@ -0,0 +1,147 @@
+package db
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/kyokan/plasma/chain"
+	"github.com/kyokan/plasma/util"
+	"github.com/syndtr/goleveldb/leveldb"
+	levelutil "github.com/syndtr/goleveldb/leveldb/util"
+)
+
+const (
+	tokenKeyPrefix      = "token"
+	tokenLeafKeyPrefix  = "tokenleaf"
+	tokenBlockKeyPrefix = "tokenblk"
+)
+
+// TokenDao indexes the blocks that send non-fungible tokens, so that a
+// token's history can be proven block by block.
+type TokenDao interface {
+	// SaveTransfers records the tokens sent by txs, the transactions of
+	// block blkNum.
+	SaveTransfers(blkNum uint64, txs []chain.Transaction) error
+	// Transfers returns the outputs tokenID was sent to, oldest first.
+	Transfers(tokenID uint64) ([]chain.Flow, error)
+	// Leaves returns the leaves of the token tree of block blkNum.
+	Leaves(blkNum uint64) (map[uint64]util.Hash, error)
+	// TokenBlocks returns the numbers of the blocks from blkNum on that
+	// send tokens.
+	TokenBlocks(blkNum uint64) ([]uint64, error)
+}
+
+type LevelTokenDao struct {
+	db *leveldb.DB
+}
+
+func (dao *LevelTokenDao) SaveTransfers(blkNum uint64, txs []chain.Transaction) error {
+	batch := new(leveldb.Batch)
+
+	for i := range txs {
+		tx := &txs[i]
+
+		for j, output := range tx.Outputs {
+			if !output.IsToken() {
+				continue
+			}
+
+			flowEnc, err := rlp.EncodeToBytes(chain.NewFlow(blkNum, tx.TxIdx, uint8(j)))
+
+			if err != nil {
+				return err
+			}
+
+			batch.Put(tokenKey(output.TokenID, blkNum), flowEnc)
+			batch.Put(tokenLeafKey(blkNum, output.TokenID), tx.Hash())
+			batch.Put(tokenBlockKey(blkNum), []byte{})
+		}
+	}
+
+	return dao.db.Write(batch, nil)
+}
+
+func (dao *LevelTokenDao) Transfers(tokenID uint64) ([]chain.Flow, error) {
+	iter := dao.db.NewIterator(levelutil.BytesPrefix(tokenPrefixKey(tokenID)), nil)
+	defer iter.Release()
+
+	var flows []chain.Flow
+
+	for iter.Next() {
+		var flow chain.Flow
+		err := rlp.DecodeBytes(iter.Value(), &flow)
+
+		if err != nil {
+			return nil, err
+		}
+
+		flows = append(flows, flow)
+	}
+
+	return flows, iter.Error()
+}
+
+func (dao *LevelTokenDao) Leaves(blkNum uint64) (map[uint64]util.Hash, error) {
+	prefix := tokenLeafPrefixKey(blkNum)
+	iter := dao.db.NewIterator(levelutil.BytesPrefix(prefix), nil)
+	defer iter.Release()
+
+	leaves := make(map[uint64]util.Hash)
+
+	for iter.Next() {
+		var tokenID uint64
+
+		if _, err := fmt.Sscanf(string(iter.Key()[len(prefix):]), "%016x", &tokenID); err != nil {
+			return nil, err
+		}
+
+		leaves[tokenID] = append(util.Hash(nil), iter.Value()...)
+	}
+
+	return leaves, iter.Error()
+}
+
+func (dao *LevelTokenDao) TokenBlocks(blkNum uint64) ([]uint64, error) {
+	prefix := append(prefixKey(tokenBlockKeyPrefix), []byte("::")...)
+	keys := levelutil.BytesPrefix(prefix)
+	keys.Start = tokenBlockKey(blkNum)
+
+	iter := dao.db.NewIterator(keys, nil)
+	defer iter.Release()
+
+	var blocks []uint64
+
+	for iter.Next() {
+		var num uint64
+
+		if _, err := fmt.Sscanf(string(iter.Key()[len(prefix):]), "%016x", &num); err != nil {
+			return nil, err
+		}
+
+		blocks = append(blocks, num)
+	}
+
+	return blocks, iter.Error()
+}
+
+// Keys sort by block number.
+
+func tokenKey(tokenID uint64, blkNum uint64) []byte {
+	return prefixKey(tokenKeyPrefix, fmt.Sprintf("%016x", tokenID), fmt.Sprintf("%016x", blkNum))
+}
+
+func tokenPrefixKey(tokenID uint64) []byte {
+	return prefixKey(tokenKeyPrefix, fmt.Sprintf("%016x", tokenID), "")
+}
+
+func tokenLeafKey(blkNum uint64, tokenID uint64) []byte {
+	return prefixKey(tokenLeafKeyPrefix, fmt.Sprintf("%016x", blkNum), fmt.Sprintf("%016x", tokenID))
+}
+
+func tokenLeafPrefixKey(blkNum uint64) []byte {
+	return prefixKey(tokenLeafKeyPrefix, fmt.Sprintf("%016x", blkNum), "")
+}
+
+func tokenBlockKey(blkNum uint64) []byte {
+	return prefixKey(tokenBlockKeyPrefix, fmt.Sprintf("%016x", blkNum))
+}
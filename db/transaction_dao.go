@@ -2,25 +2,31 @@ package db
 
 import (
 	"errors"
+	"fmt"
 	"log"
 	"strconv"
+	"strings"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/kyokan/plasma/chain"
 	"github.com/kyokan/plasma/util"
 	"github.com/syndtr/goleveldb/leveldb"
+	levelutil "github.com/syndtr/goleveldb/leveldb/util"
 )
 
 const txKeyPrefix = "tx"
 const earnKeyPrefix = "earn"
 const spendKeyPrefix = "spend"
 
+var addressIndexVersionKey = []byte("index::address::v1")
+
 type TransactionDao interface {
 	Save(tx *chain.Transaction) error
 	SaveMany(txs []chain.Transaction) error
 	FindByBlockNum(blkNum uint64) ([]chain.Transaction, error)
 	FindByBlockNumTxIdx(blkNum uint64, txIdx uint32) (*chain.Transaction, error)
+	FindByMetadata(metadata []byte) ([]chain.Transaction, error)
 }
 
 type LevelTransactionDao struct {
@@ -112,17 +118,51 @@ func (dao *LevelTransactionDao) FindByBlockNumTxIdx(blkNum uint64, txIdx uint32)
 	return &tx, nil
 }
 
-func (dao *LevelTransactionDao) FindPreviousTx(tx *chain.Transaction, inputIdx uint8) (*chain.Transaction, error) {
-	var input *chain.Input
+// FindByMetadata returns the transactions whose metadata is exactly
+// metadata, in the order they were included.
+func (dao *LevelTransactionDao) FindByMetadata(metadata []byte) ([]chain.Transaction, error) {
+	iter := dao.db.NewIterator(levelutil.BytesPrefix(metadataPrefixKey(metadata)), nil)
+	defer iter.Release()
+
+	var flows []chain.Flow
+
+	for iter.Next() {
+		var flow chain.Flow
+		err := rlp.DecodeBytes(iter.Value(), &flow)
+
+		if err != nil {
+			return nil, err
+		}
 
-	if inputIdx != 0 && inputIdx != 1 {
-		panic("inputIdx must be 0 or 1")
+		flows = append(flows, flow)
 	}
 
-	if inputIdx == 0 {
-		input = tx.Input0
-	} else {
-		input = tx.Input1
+	if err := iter.Error(); err != nil {
+		return nil, err
+	}
+
+	var txs []chain.Transaction
+
+	for _, flow := range flows {
+		tx, err := dao.FindByBlockNumTxIdx(flow.BlkNum, flow.TxIdx)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if tx != nil {
+			txs = append(txs, *tx)
+		}
+	}
+
+	return txs, nil
+}
+
+func (dao *LevelTransactionDao) FindPreviousTx(tx *chain.Transaction, inputIdx uint8) (*chain.Transaction, error) {
+	input := tx.InputAt(inputIdx)
+
+	if input == nil {
+		return nil, fmt.Errorf("transaction has no input %d", inputIdx)
 	}
 
 	prevTx, err := dao.FindByBlockNumTxIdx(input.BlkNum, input.TxIdx)
@@ -149,6 +189,17 @@ func (dao *LevelTransactionDao) save(batch *leveldb.Batch, tx *chain.Transaction
 	batch.Put(blkNumHashkey(tx.BlkNum, hexHash), cbor)
 	batch.Put(blkNumTxIdxKey(tx.BlkNum, tx.TxIdx), cbor)
 
+	if len(tx.Metadata) > 0 {
+		flow := chain.NewFlow(tx.BlkNum, tx.TxIdx, 0)
+		flowEnc, err := rlp.EncodeToBytes(&flow)
+
+		if err != nil {
+			return err
+		}
+
+		batch.Put(metadataKey(tx.Metadata, tx.BlkNum, tx.TxIdx), flowEnc)
+	}
+
 	if tx.IsDeposit() {
 		flow := chain.NewFlow(tx.BlkNum, 0, 0)
 		flowEnc, err := rlp.EncodeToBytes(&flow)
@@ -157,7 +208,7 @@ func (dao *LevelTransactionDao) save(batch *leveldb.Batch, tx *chain.Transaction
 			return err
 		}
 
-		batch.Put(earnKey(&tx.Output0.NewOwner), flowEnc)
+		batch.Put(earnKey(&tx.OutputAt(0).NewOwner, flow), flowEnc)
 		return nil
 	}
 
@@ -173,20 +224,14 @@ func (dao *LevelTransactionDao) save(batch *leveldb.Batch, tx *chain.Transaction
 }
 
 func (dao *LevelTransactionDao) recordEarns(batch *leveldb.Batch, tx *chain.Transaction) error {
-	err := dao.recordEarn(batch, tx, 0)
-
-	if err != nil {
-		return err
-	}
-
-	if tx.Output1.IsZeroOutput() {
-		return nil
-	}
-
-	err = dao.recordEarn(batch, tx, 1)
+	for i, output := range tx.Outputs {
+		if i > 0 && output.IsZeroOutput() {
+			continue
+		}
 
-	if err != nil {
-		return err
+		if err := dao.recordEarn(batch, tx, uint8(i)); err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -201,25 +246,19 @@ func (dao *LevelTransactionDao) recordEarn(batch *leveldb.Batch, tx *chain.Trans
 	}
 
 	output := tx.OutputAt(outIdx)
-	batch.Put(earnKey(&output.NewOwner), flowEnc)
+	batch.Put(earnKey(&output.NewOwner, flow), flowEnc)
 	return nil
 }
 
 func (dao *LevelTransactionDao) recordSpends(batch *leveldb.Batch, tx *chain.Transaction) error {
-	err := dao.recordSpend(batch, tx, 0)
-
-	if err != nil {
-		return err
-	}
-
-	if tx.Input1.IsZeroInput() {
-		return nil
-	}
-
-	err = dao.recordSpend(batch, tx, 1)
+	for i, input := range tx.Inputs {
+		if i > 0 && input.IsZeroInput() {
+			continue
+		}
 
-	if err != nil {
-		return err
+		if err := dao.recordSpend(batch, tx, uint8(i)); err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -245,17 +284,122 @@ func (dao *LevelTransactionDao) recordSpend(batch *leveldb.Batch, tx *chain.Tran
 		return err
 	}
 
-	batch.Put(spendKey(&prevTx.OutputAt(input.OutIdx).NewOwner), flowEnc)
+	prevOutput := prevTx.OutputAt(input.OutIdx)
+
+	if prevOutput == nil {
+		return errors.New("expected to find an output")
+	}
+
+	batch.Put(spendKey(&prevOutput.NewOwner, flow), flowEnc)
 
 	return nil
 }
 
-func earnKey(addr *common.Address) []byte {
-	return prefixKey(earnKeyPrefix, util.AddressToHex(addr))
+// earnKey and spendKey index every output an address earns or spends, so
+// that an address can have many unspent outputs.
+func earnKey(addr *common.Address, flow *chain.Flow) []byte {
+	return prefixKey(earnKeyPrefix, util.AddressToHex(addr), flowKeyPart(flow))
+}
+
+func earnPrefixKey(addr *common.Address) []byte {
+	return prefixKey(earnKeyPrefix, util.AddressToHex(addr), "")
+}
+
+func spendKey(addr *common.Address, flow *chain.Flow) []byte {
+	return prefixKey(spendKeyPrefix, util.AddressToHex(addr), flowKeyPart(flow))
 }
 
-func spendKey(addr *common.Address) []byte {
-	return prefixKey(spendKeyPrefix, util.AddressToHex(addr))
+func spendPrefixKey(addr *common.Address) []byte {
+	return prefixKey(spendKeyPrefix, util.AddressToHex(addr), "")
+}
+
+func flowKeyPart(flow *chain.Flow) string {
+	return fmt.Sprintf("%016x::%08x::%02x", flow.BlkNum, flow.TxIdx, flow.OutIdx)
+}
+
+// ReindexAddresses rebuilds the earn and spend indexes from the saved
+// transactions, once. Databases written before the indexes were keyed by
+// output kept a single earn and spend per address.
+func (dao *LevelTransactionDao) ReindexAddresses() error {
+	done, err := dao.db.Has(addressIndexVersionKey, nil)
+
+	if err != nil || done {
+		return err
+	}
+
+	batch := new(leveldb.Batch)
+
+	for _, prefix := range []string{earnKeyPrefix, spendKeyPrefix} {
+		iter := dao.db.NewIterator(levelutil.BytesPrefix(prefixKey(prefix, "")), nil)
+
+		for iter.Next() {
+			batch.Delete(append([]byte(nil), iter.Key()...))
+		}
+
+		iter.Release()
+
+		if err := iter.Error(); err != nil {
+			return err
+		}
+	}
+
+	var txs []chain.Transaction
+	iter := dao.db.NewIterator(levelutil.BytesPrefix(txPrefixKey("blkNum", "")), nil)
+
+	for iter.Next() {
+		var blkNum, txIdx uint64
+		var kind string
+
+		if _, err := fmt.Sscanf(strings.Replace(string(iter.Key()), "::", " ", -1), "tx blkNum %d %s %d", &blkNum, &kind, &txIdx); err != nil || kind != "txIdx" {
+			continue
+		}
+
+		var tx chain.Transaction
+
+		if err := rlp.DecodeBytes(iter.Value(), &tx); err != nil {
+			iter.Release()
+			return err
+		}
+
+		tx.BlkNum = blkNum
+		tx.TxIdx = uint32(txIdx)
+		txs = append(txs, tx)
+	}
+
+	iter.Release()
+
+	if err := iter.Error(); err != nil {
+		return err
+	}
+
+	log.Printf("Reindexing the outputs of %d transactions.", len(txs))
+
+	for i := range txs {
+		tx := &txs[i]
+
+		if tx.IsDeposit() {
+			flow := chain.NewFlow(tx.BlkNum, 0, 0)
+			flowEnc, err := rlp.EncodeToBytes(&flow)
+
+			if err != nil {
+				return err
+			}
+
+			batch.Put(earnKey(&tx.OutputAt(0).NewOwner, flow), flowEnc)
+			continue
+		}
+
+		if err := dao.recordEarns(batch, tx); err != nil {
+			return err
+		}
+
+		if err := dao.recordSpends(batch, tx); err != nil {
+			return err
+		}
+	}
+
+	batch.Put(addressIndexVersionKey, []byte{1})
+	return dao.db.Write(batch, nil)
 }
 
 func blkNumHashkey(blkNum uint64, hexHash string) []byte {
@@ -266,6 +410,16 @@ func blkNumTxIdxKey(blkNum uint64, txIdx uint32) []byte {
 	return txPrefixKey("blkNum", strconv.FormatUint(blkNum, 10), "txIdx", strconv.FormatUint(uint64(txIdx), 10))
 }
 
+// metadataKey sorts the transactions with the same metadata by block
+// number and index.
+func metadataKey(metadata []byte, blkNum uint64, txIdx uint32) []byte {
+	return txPrefixKey("metadata", common.Bytes2Hex(metadata), fmt.Sprintf("%016x", blkNum), fmt.Sprintf("%08x", txIdx))
+}
+
+func metadataPrefixKey(metadata []byte) []byte {
+	return txPrefixKey("metadata", common.Bytes2Hex(metadata), "")
+}
+
 func txPrefixKey(parts ...string) []byte {
 	return prefixKey(txKeyPrefix, parts...)
 }
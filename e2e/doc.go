@@ -0,0 +1,5 @@
+// Package e2e runs the root node, a validator and the RPC server in process
+// against a simulated Ethereum backend. The tests deploy the Plasma contract
+// built by truffle, so run `truffle compile` in contracts first; they are
+// skipped when the build is missing.
+package e2e
@@ -0,0 +1,283 @@
+package e2e
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"math/big"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/kyokan/plasma/chain"
+	"github.com/kyokan/plasma/contracts/gen/contracts"
+	"github.com/kyokan/plasma/db"
+	"github.com/kyokan/plasma/eth"
+	"github.com/kyokan/plasma/node"
+	"github.com/kyokan/plasma/rpc"
+	"github.com/kyokan/plasma/rpc/rpcclient"
+	"github.com/kyokan/plasma/signer"
+	"github.com/kyokan/plasma/txtype"
+	"github.com/kyokan/plasma/validator"
+	"github.com/stretchr/testify/require"
+)
+
+const plasmaArtifact = "../contracts/build/contracts/Plasma.json"
+
+// dataDir holds the databases of all tests, and is removed once they are
+// done.
+var dataDir string
+
+func TestMain(m *testing.M) {
+	var err error
+	dataDir, err = ioutil.TempDir("", "plasma-e2e")
+
+	if err != nil {
+		log.Fatalf("Failed to create data directory: %v", err)
+	}
+
+	code := m.Run()
+	os.RemoveAll(dataDir)
+	os.Exit(code)
+}
+
+// simBackend adds the head block to the simulated backend, which does not
+// serve headers.
+type simBackend struct {
+	*backends.SimulatedBackend
+	mtx  sync.Mutex
+	head int64
+}
+
+func (b *simBackend) Commit() {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	b.SimulatedBackend.Commit()
+	b.head++
+}
+
+func (b *simBackend) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	if number == nil {
+		number = big.NewInt(b.head)
+	}
+
+	return &types.Header{Number: number}, nil
+}
+
+type account struct {
+	key     *ecdsa.PrivateKey
+	signer  *signer.MemorySigner
+	address common.Address
+	plasma  *eth.PlasmaClient
+}
+
+type harness struct {
+	t        *testing.T
+	backend  *simBackend
+	contract common.Address
+	domain   chain.Domain
+
+	operator  *account
+	validator *account
+	alice     *account
+	bob       *account
+
+	root        *rpcclient.Client
+	rootURL     string
+	validatorDB *db.Database
+
+	cleanups []func()
+}
+
+func newAccount(t *testing.T) *account {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	return &account{
+		key:     key,
+		signer:  signer.NewMemorySigner(key),
+		address: crypto.PubkeyToAddress(key.PublicKey),
+	}
+}
+
+// startHarness deploys the Plasma contract to a simulated backend that mines
+// a block every 50ms, and starts a root node, its RPC server and a
+// validator. The caller must call stop when done.
+func startHarness(t *testing.T) *harness {
+	artifact, err := ioutil.ReadFile(plasmaArtifact)
+
+	if os.IsNotExist(err) {
+		t.Skipf("%s is missing, run truffle compile in contracts", plasmaArtifact)
+	}
+
+	require.NoError(t, err)
+
+	var build struct {
+		Bytecode string `json:"bytecode"`
+	}
+
+	require.NoError(t, json.Unmarshal(artifact, &build))
+
+	h := &harness{
+		t:         t,
+		operator:  newAccount(t),
+		validator: newAccount(t),
+		alice:     newAccount(t),
+		bob:       newAccount(t),
+	}
+
+	funds := new(big.Int).Mul(big.NewInt(100), big.NewInt(1e18))
+	alloc := core.GenesisAlloc{}
+
+	for _, acct := range h.accounts() {
+		alloc[acct.address] = core.GenesisAccount{Balance: funds}
+	}
+
+	h.backend = &simBackend{SimulatedBackend: backends.NewSimulatedBackend(alloc)}
+
+	plasmaAbi, err := abi.JSON(strings.NewReader(contracts.PlasmaABI))
+	require.NoError(t, err)
+
+	h.contract, _, _, err = bind.DeployContract(
+		bind.NewKeyedTransactor(h.operator.key),
+		plasmaAbi,
+		common.FromHex(build.Bytecode),
+		h.backend,
+	)
+	require.NoError(t, err)
+	h.backend.Commit()
+
+	// The simulated backend runs with the chain id of a dev chain.
+	h.domain = chain.Domain{ChainID: big.NewInt(1337), VerifyingContract: h.contract}
+
+	stop := make(chan struct{})
+	h.onStop(func() { close(stop) })
+
+	go func() {
+		tick := time.NewTicker(50 * time.Millisecond)
+		defer tick.Stop()
+
+		for {
+			select {
+			case <-tick.C:
+				h.backend.Commit()
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	// The simulated backend rejects a reused nonce, so gas is never bumped.
+	txConfig := eth.DefaultTxManagerConfig()
+	txConfig.BumpInterval = 0
+	txConfig.PollInterval = 50 * time.Millisecond
+
+	for _, acct := range h.accounts() {
+		acct.plasma, err = eth.NewPlasmaClient(h.backend, h.contract, acct.signer, txConfig)
+		require.NoError(t, err)
+	}
+
+	watcherConfig := eth.WatcherConfig{PollInterval: 100 * time.Millisecond}
+
+	h.startRootNode(watcherConfig)
+	h.startValidator(watcherConfig)
+	return h
+}
+
+func (h *harness) onStop(fn func()) {
+	h.cleanups = append(h.cleanups, fn)
+}
+
+// stop stops the simulated miner and the RPC server. The nodes' goroutines
+// are left to the end of the test binary.
+func (h *harness) stop() {
+	for i := len(h.cleanups) - 1; i >= 0; i-- {
+		h.cleanups[i]()
+	}
+}
+
+func (h *harness) accounts() []*account {
+	return []*account{h.operator, h.validator, h.alice, h.bob}
+}
+
+func (h *harness) startRootNode(watcherConfig eth.WatcherConfig) {
+	level := h.database("root")
+	plasma := h.operator.plasma
+
+	notifier := node.NewNotifier()
+	sink := node.NewTransactionSink(level, notifier, h.domain)
+
+	submitter := node.NewBlockSubmitter(level, plasma, 1, 100*time.Millisecond)
+	go submitter.Start()
+
+	p := node.NewPlasmaNode(level, sink, plasma, notifier, submitter)
+	p.PackageInterval = 200 * time.Millisecond
+	p.Start()
+
+	server := httptest.NewServer(rpc.NewHandler(rpc.Config{}, level, sink, notifier))
+	h.onStop(server.Close)
+
+	h.rootURL = server.URL + "/rpc"
+	h.root = rpcclient.NewClient(h.rootURL)
+
+	go node.StartDepositListener(level, sink, plasma, watcherConfig)
+	go node.StartExitListener(level, plasma, notifier, watcherConfig)
+}
+
+func (h *harness) startValidator(watcherConfig eth.WatcherConfig) {
+	level := h.database("validator")
+	h.validatorDB = level
+
+	go validator.RootNodeListener(h.rootURL, level, h.validator.plasma, h.validator.address.Hex(), txtype.Default(), h.domain)
+	go validator.ExitStartedListener(h.rootURL, level, h.validator.plasma, watcherConfig)
+}
+
+// database creates a database under dataDir. It is left open, since the
+// nodes keep running until the test binary exits.
+func (h *harness) database(name string) *db.Database {
+	dir, err := ioutil.TempDir(dataDir, name)
+	require.NoError(h.t, err)
+
+	_, level, err := db.CreateLevelDatabase(dir)
+	require.NoError(h.t, err)
+	return level
+}
+
+// wait waits for a submission to be mined and fails the test if it reverts.
+func (h *harness) wait(sub *eth.Submission, err error) {
+	require.NoError(h.t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	_, err = sub.Wait(ctx)
+	require.NoError(h.t, err)
+}
+
+// eventually calls cond until it returns true, or fails the test after
+// timeout.
+func (h *harness) eventually(timeout time.Duration, message string, cond func() bool) {
+	deadline := time.Now().Add(timeout)
+
+	for !cond() {
+		if time.Now().After(deadline) {
+			h.t.Fatalf("timed out after %s: %s", timeout, message)
+		}
+
+		time.Sleep(100 * time.Millisecond)
+	}
+}
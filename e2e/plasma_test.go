@@ -0,0 +1,161 @@
+package e2e
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/kyokan/plasma/chain"
+	"github.com/kyokan/plasma/eth"
+	plasma_rpc "github.com/kyokan/plasma/rpc"
+	"github.com/kyokan/plasma/userclient"
+	"github.com/kyokan/plasma/util"
+	"github.com/stretchr/testify/require"
+)
+
+type utxo struct {
+	blkNum uint64
+	txIdx  uint32
+	oIdx   uint8
+	amount *big.Int
+}
+
+func (u utxo) exitId() *big.Int {
+	id := new(big.Int).Mul(util.NewUint64(u.blkNum), big.NewInt(1000000000))
+	id.Add(id, new(big.Int).Mul(util.NewUint32(u.txIdx), big.NewInt(10000)))
+	return id.Add(id, big.NewInt(int64(u.oIdx)))
+}
+
+func (h *harness) utxos(owner common.Address) []utxo {
+	res, err := h.root.GetUTXOs(owner.Hex())
+
+	if err != nil {
+		return nil
+	}
+
+	var utxos []utxo
+
+	for _, tx := range res.Transactions {
+		for oIdx, out := range tx.Outputs {
+			if out.NewOwner == owner && out.Amount.Sign() > 0 {
+				utxos = append(utxos, utxo{tx.BlkNum, tx.TxIdx, uint8(oIdx), out.Amount})
+			}
+		}
+	}
+
+	return utxos
+}
+
+// onContract reports whether the contract has the child block.
+func (h *harness) onContract(blkNum uint64) bool {
+	_, err := h.operator.plasma.GetBlock(context.Background(), util.NewUint64(blkNum))
+	return err == nil
+}
+
+func (h *harness) startExit(acct *account, u utxo) {
+	res, err := h.root.GetBlock(u.blkNum)
+	require.NoError(h.t, err)
+
+	h.wait(acct.plasma.StartExit(
+		context.Background(),
+		res.Block,
+		res.Transactions,
+		util.NewUint64(u.blkNum),
+		util.NewUint32(u.txIdx),
+		big.NewInt(int64(u.oIdx)),
+	))
+}
+
+func Test_DepositTransferExitChallengeFinalize(t *testing.T) {
+	h := startHarness(t)
+	defer h.stop()
+
+	ctx := context.Background()
+
+	// Deposit
+	depositTx := *chain.NewTransaction(
+		nil,
+		[]*chain.Output{{NewOwner: h.alice.address, Amount: big.NewInt(1000)}},
+		big.NewInt(0),
+	)
+
+	h.wait(h.alice.plasma.Deposit(ctx, 1000, &depositTx))
+
+	var deposit utxo
+
+	h.eventually(30*time.Second, "deposit credited", func() bool {
+		utxos := h.utxos(h.alice.address)
+
+		if len(utxos) != 1 {
+			return false
+		}
+
+		deposit = utxos[0]
+		return true
+	})
+
+	require.Equal(t, big.NewInt(1000), deposit.amount)
+
+	// Transfer
+	tx, err := userclient.BuildSignedSend(h.root, h.bob.address, big.NewInt(400), h.alice.signer, h.domain)
+	require.NoError(t, err)
+
+	_, err = h.root.Send(&plasma_rpc.SendArgs{Transaction: *tx})
+	require.NoError(t, err)
+
+	var payment utxo
+
+	h.eventually(30*time.Second, "transfer packaged", func() bool {
+		utxos := h.utxos(h.bob.address)
+
+		if len(utxos) != 1 {
+			return false
+		}
+
+		payment = utxos[0]
+		return true
+	})
+
+	require.Equal(t, big.NewInt(400), payment.amount)
+
+	h.eventually(30*time.Second, "transfer block submitted", func() bool {
+		return h.onContract(payment.blkNum)
+	})
+
+	// Exit
+	h.startExit(h.bob, payment)
+
+	exit, err := h.operator.plasma.GetExit(ctx, payment.exitId())
+	require.NoError(t, err)
+	require.Equal(t, h.bob.address, exit.Owner)
+	require.Equal(t, big.NewInt(400), exit.Amount)
+
+	// Challenge: the deposit was spent by the transfer, so the validator
+	// challenges an exit of it.
+	h.eventually(2*time.Minute, "validator synced the transfer block", func() bool {
+		latest, err := h.validatorDB.BlockDao.Latest()
+		return err == nil && latest != nil && latest.Header.Number >= payment.blkNum
+	})
+
+	h.startExit(h.alice, deposit)
+
+	h.eventually(time.Minute, "double spending exit challenged", func() bool {
+		_, err := h.operator.plasma.GetExit(ctx, deposit.exitId())
+		return eth.IsNotFound(err)
+	})
+
+	// Finalize
+	before, err := h.backend.BalanceAt(ctx, h.bob.address, nil)
+	require.NoError(t, err)
+
+	h.wait(h.operator.plasma.Finalize(ctx))
+
+	_, err = h.operator.plasma.GetExit(ctx, payment.exitId())
+	require.True(t, eth.IsNotFound(err))
+
+	after, err := h.backend.BalanceAt(ctx, h.bob.address, nil)
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(400), new(big.Int).Sub(after, before))
+}
@@ -4,10 +4,7 @@ import (
 	"context"
 	"log"
 	"math/big"
-	"strings"
 
-	"github.com/ethereum/go-ethereum"
-	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
@@ -18,9 +15,6 @@ import (
 	plasma_common "github.com/kyokan/plasma/common"
 )
 
-const depositFilter = "0xe1fffcc4923d04b559f4d29a8bfc6cda04eb5b0d3c460751c2402c5c5cc9109c"
-const depositDescription = `[{"anonymous":false,"inputs":[{"indexed":false,"name":"sender","type":"address"},{"indexed":false,"name":"value","type":"uint256"}],"name":"Deposit","type":"event"}]`
-
 const GWEI = 1000000000
 
 var nonce int64 = 0
@@ -28,6 +22,17 @@ var nonce int64 = 0
 type DepositEvent struct {
 	Sender common.Address
 	Value  *big.Int
+	// Blocknum is the child block the contract created for the deposit.
+	Blocknum *big.Int
+	// TokenID is the plasma id of a deposited non-fungible token, or zero
+	// for a deposit of ether.
+	TokenID uint64
+
+	// A deposit is identified by the Ethereum transaction and the index of
+	// its log within the block.
+	BlockNumber uint64
+	TxHash      common.Hash
+	LogIndex    uint
 }
 
 type clientState struct {
@@ -88,51 +93,3 @@ func (c *clientState) NewGethTransactor(keyAddr common.Address) *bind.TransactOp
 		GasPrice: gweiPrice.Mul(gweiPrice, big.NewInt(GWEI)),
 	}
 }
-
-func (c *clientState) SubscribeDeposits(address common.Address, resChan chan<- DepositEvent) error {
-	query := ethereum.FilterQuery{
-		FromBlock: nil,
-		ToBlock:   nil,
-		Topics:    [][]common.Hash{{common.HexToHash(depositFilter)}},
-		Addresses: []common.Address{address},
-	}
-
-	ch := make(chan types.Log)
-	_, err := c.typedClient.SubscribeFilterLogs(context.TODO(), query, ch)
-
-	if err != nil {
-		return err
-	}
-
-	log.Printf("Watching for deposits on address %s.", util.AddressToHex(&address))
-
-	depositAbi, err := abi.JSON(strings.NewReader(depositDescription))
-
-	if err != nil {
-		return err
-	}
-
-	go func() {
-		for {
-			select {
-			case event := <-ch:
-				parseDepositEvent(&depositAbi, resChan, &event)
-			}
-		}
-	}()
-
-	return nil
-}
-
-func parseDepositEvent(depositAbi *abi.ABI, resChan chan<- DepositEvent, raw *types.Log) {
-	event := DepositEvent{}
-	err := depositAbi.Unpack(&event, "Deposit", raw.Data)
-
-	if err != nil {
-		log.Print("Failed to unpack deposit: ", err)
-		return
-	}
-
-	log.Printf("Received %s wei deposit from %s.", event.Value.String(), util.AddressToHex(&event.Sender))
-	resChan <- event
-}
@@ -0,0 +1,84 @@
+package eth
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/kyokan/plasma/chain"
+	"gopkg.in/urfave/cli.v1"
+)
+
+func TxManagerConfigFromCLI(c *cli.Context) TxManagerConfig {
+	maxGasPrice, _ := new(big.Float).Mul(
+		big.NewFloat(c.GlobalFloat64("eth-max-gas-price")),
+		big.NewFloat(GWEI),
+	).Int(nil)
+
+	return TxManagerConfig{
+		Confirmations:  uint64(c.GlobalInt("eth-confirmations")),
+		BumpInterval:   c.GlobalDuration("eth-gas-bump-interval"),
+		BumpPercent:    int64(c.GlobalInt("eth-gas-bump-percent")),
+		MaxGasPrice:    maxGasPrice,
+		GasLimitMargin: int64(c.GlobalInt("eth-gas-limit-margin")),
+		PollInterval:   c.GlobalDuration("eth-poll-interval"),
+	}
+}
+
+// DomainFromCLI returns the domain transactions are signed for: the plasma
+// contract at contract-addr on the Ethereum chain chain-id. When chain-id is
+// not set, it is read from the Ethereum node at node-url.
+func DomainFromCLI(c *cli.Context) (chain.Domain, error) {
+	contract := c.GlobalString("contract-addr")
+
+	if !common.IsHexAddress(contract) {
+		return chain.Domain{}, fmt.Errorf("invalid contract address %q", contract)
+	}
+
+	chainID := big.NewInt(int64(c.GlobalInt("chain-id")))
+
+	if chainID.Sign() == 0 {
+		client, err := rpc.Dial(c.GlobalString("node-url"))
+
+		if err != nil {
+			return chain.Domain{}, err
+		}
+
+		defer client.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		chainID, err = ChainID(ctx, client)
+
+		if err != nil {
+			return chain.Domain{}, fmt.Errorf("failed to read the chain id: %v", err)
+		}
+	}
+
+	return chain.Domain{ChainID: chainID, VerifyingContract: common.HexToAddress(contract)}, nil
+}
+
+// ChainID returns the EIP-155 chain id of the Ethereum node. It can differ
+// from the network id, which is what signatures must not be bound to.
+func ChainID(ctx context.Context, client *rpc.Client) (*big.Int, error) {
+	var chainID hexutil.Big
+
+	if err := client.CallContext(ctx, &chainID, "eth_chainId"); err != nil {
+		return nil, err
+	}
+
+	return (*big.Int)(&chainID), nil
+}
+
+func WatcherConfigFromCLI(c *cli.Context) WatcherConfig {
+	return WatcherConfig{
+		WSURL:             c.GlobalString("node-ws-url"),
+		PollInterval:      c.GlobalDuration("event-poll-interval"),
+		ReconnectInterval: c.GlobalDuration("event-reconnect-interval"),
+	}
+}
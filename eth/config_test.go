@@ -0,0 +1,37 @@
+package eth
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/require"
+)
+
+// ChainService answers eth_chainId and net_version like a node whose
+// network id differs from its chain id, as on Ethereum Classic.
+type ChainService struct{}
+
+func (ChainService) ChainId() *hexutil.Big {
+	return (*hexutil.Big)(big.NewInt(61))
+}
+
+type NetService struct{}
+
+func (NetService) Version() string {
+	return "1"
+}
+
+func Test_ChainIDReadsEthChainId(t *testing.T) {
+	server := rpc.NewServer()
+	require.NoError(t, server.RegisterName("eth", ChainService{}))
+	require.NoError(t, server.RegisterName("net", NetService{}))
+	client := rpc.DialInProc(server)
+	defer client.Close()
+
+	chainID, err := ChainID(context.Background(), client)
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(61), chainID)
+}
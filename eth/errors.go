@@ -0,0 +1,130 @@
+package eth
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+)
+
+// Kinds of errors returned by PlasmaClient.
+var (
+	// ErrReverted is returned when a contract call or transaction reverts.
+	ErrReverted = errors.New("contract reverted")
+
+	// ErrConnectionLost is returned when the Ethereum node cannot be
+	// reached. Calls that fail with it can be retried.
+	ErrConnectionLost = errors.New("connection to the Ethereum node lost")
+
+	// ErrNotFound is returned when the contract has no such block or exit.
+	ErrNotFound = errors.New("not found")
+)
+
+// Error is returned by PlasmaClient methods. Kind is one of the errors above,
+// or nil when the error could not be classified.
+type Error struct {
+	Op   string
+	Kind error
+	Err  error
+}
+
+func (e *Error) Error() string {
+	return e.Op + ": " + e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func IsReverted(err error) bool {
+	return kindOf(err) == ErrReverted
+}
+
+func IsConnectionLost(err error) bool {
+	return kindOf(err) == ErrConnectionLost
+}
+
+func IsNotFound(err error) bool {
+	return kindOf(err) == ErrNotFound
+}
+
+func kindOf(err error) error {
+	if e, ok := err.(*Error); ok {
+		return e.Kind
+	}
+
+	return classify(err)
+}
+
+func wrapError(op string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if e, ok := err.(*Error); ok {
+		return e
+	}
+
+	return &Error{Op: op, Kind: classify(err), Err: err}
+}
+
+func classify(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	switch err {
+	case ErrReverted, ErrConnectionLost, ErrNotFound:
+		return err
+	case ErrTransactionFailed:
+		return ErrReverted
+	case ethereum.NotFound:
+		return ErrNotFound
+	case io.EOF, io.ErrUnexpectedEOF, context.DeadlineExceeded:
+		return ErrConnectionLost
+	}
+
+	if _, ok := err.(net.Error); ok {
+		return ErrConnectionLost
+	}
+
+	msg := err.Error()
+
+	switch {
+	case strings.Contains(msg, "revert"),
+		strings.Contains(msg, "invalid opcode"),
+		strings.Contains(msg, "gas required exceeds allowance"),
+		strings.Contains(msg, "always failing transaction"):
+		return ErrReverted
+	case strings.Contains(msg, "connection refused"),
+		strings.Contains(msg, "connection reset"),
+		strings.Contains(msg, "broken pipe"),
+		strings.Contains(msg, "no such host"),
+		strings.Contains(msg, "i/o timeout"):
+		return ErrConnectionLost
+	}
+
+	return nil
+}
+
+// Retry calls fn until it succeeds, fails with an error other than a lost
+// connection, or ctx is done.
+func Retry(ctx context.Context, interval time.Duration, fn func() error) error {
+	for {
+		err := fn()
+
+		if err == nil || !IsConnectionLost(err) {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(interval):
+		}
+	}
+}
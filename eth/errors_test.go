@@ -0,0 +1,23 @@
+package eth
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_WrapErrorClassifies(t *testing.T) {
+	err := wrapError("SubmitBlock", errors.New("VM Exception while processing transaction: revert"))
+	require.True(t, IsReverted(err))
+	require.Equal(t, "SubmitBlock: VM Exception while processing transaction: revert", err.Error())
+
+	err = wrapError("GetBlock", errors.New("dial tcp 127.0.0.1:8545: connect: connection refused"))
+	require.True(t, IsConnectionLost(err))
+
+	require.True(t, IsNotFound(wrapError("GetExit", ethereum.NotFound)))
+	require.True(t, IsReverted(ErrTransactionFailed))
+	require.False(t, IsReverted(wrapError("Deposit", errors.New("insufficient funds"))))
+	require.Nil(t, wrapError("Finalize", nil))
+}
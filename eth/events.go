@@ -2,25 +2,25 @@ package eth
 
 import (
 	"context"
-	"log"
 
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/kyokan/plasma/contracts/gen/contracts"
 )
 
 func (p *PlasmaClient) DepositFilter(
+	ctx context.Context,
 	start uint64,
-) ([]contracts.PlasmaDeposit, uint64) {
+) ([]contracts.PlasmaDeposit, uint64, error) {
 	opts := bind.FilterOpts{
 		Start:   start,
 		End:     nil, // TODO: end doesn't seem to work
-		Context: context.Background(),
+		Context: ctx,
 	}
 
 	itr, err := p.plasma.FilterDeposit(&opts)
 
 	if err != nil {
-		log.Fatalf("Failed to filter deposit events: %v", err)
+		return nil, 0, wrapError("depositFilter", err)
 	}
 
 	next := true
@@ -37,22 +37,27 @@ func (p *PlasmaClient) DepositFilter(
 		next = itr.Next()
 	}
 
-	return events, lastBlockNumber
+	if err := itr.Error(); err != nil {
+		return nil, 0, wrapError("depositFilter", err)
+	}
+
+	return events, lastBlockNumber, nil
 }
 
 func (p *PlasmaClient) ExitStartedFilter(
+	ctx context.Context,
 	start uint64,
-) ([]contracts.PlasmaExitStarted, uint64) {
+) ([]contracts.PlasmaExitStarted, uint64, error) {
 	opts := bind.FilterOpts{
 		Start:   start,
 		End:     nil, // TODO: end doesn't seem to work
-		Context: context.Background(),
+		Context: ctx,
 	}
 
 	itr, err := p.plasma.FilterExitStarted(&opts)
 
 	if err != nil {
-		log.Fatalf("Failed to filter exit started events: %v", err)
+		return nil, 0, wrapError("exitStartedFilter", err)
 	}
 
 	next := true
@@ -69,22 +74,27 @@ func (p *PlasmaClient) ExitStartedFilter(
 		next = itr.Next()
 	}
 
-	return events, lastBlockNumber
+	if err := itr.Error(); err != nil {
+		return nil, 0, wrapError("exitStartedFilter", err)
+	}
+
+	return events, lastBlockNumber, nil
 }
 
 func (p *PlasmaClient) DebugAddressFilter(
+	ctx context.Context,
 	start uint64,
-) ([]contracts.PlasmaDebugAddress, uint64) {
+) ([]contracts.PlasmaDebugAddress, uint64, error) {
 	opts := bind.FilterOpts{
 		Start:   start,
 		End:     nil, // TODO: end doesn't seem to work
-		Context: context.Background(),
+		Context: ctx,
 	}
 
 	itr, err := p.plasma.FilterDebugAddress(&opts)
 
 	if err != nil {
-		log.Fatalf("Failed to filter debug address events: %v", err)
+		return nil, 0, wrapError("debugAddressFilter", err)
 	}
 
 	next := true
@@ -101,22 +111,27 @@ func (p *PlasmaClient) DebugAddressFilter(
 		next = itr.Next()
 	}
 
-	return events, lastBlockNumber
+	if err := itr.Error(); err != nil {
+		return nil, 0, wrapError("debugAddressFilter", err)
+	}
+
+	return events, lastBlockNumber, nil
 }
 
 func (p *PlasmaClient) DebugUintFilter(
+	ctx context.Context,
 	start uint64,
-) ([]contracts.PlasmaDebugUint, uint64) {
+) ([]contracts.PlasmaDebugUint, uint64, error) {
 	opts := bind.FilterOpts{
 		Start:   start,
 		End:     nil, // TODO: end doesn't seem to work
-		Context: context.Background(),
+		Context: ctx,
 	}
 
 	itr, err := p.plasma.FilterDebugUint(&opts)
 
 	if err != nil {
-		log.Fatalf("Failed to filter debug uint events: %v", err)
+		return nil, 0, wrapError("debugUintFilter", err)
 	}
 
 	next := true
@@ -133,22 +148,27 @@ func (p *PlasmaClient) DebugUintFilter(
 		next = itr.Next()
 	}
 
-	return events, lastBlockNumber
+	if err := itr.Error(); err != nil {
+		return nil, 0, wrapError("debugUintFilter", err)
+	}
+
+	return events, lastBlockNumber, nil
 }
 
 func (p *PlasmaClient) DebugBoolFilter(
+	ctx context.Context,
 	start uint64,
-) ([]contracts.PlasmaDebugBool, uint64) {
+) ([]contracts.PlasmaDebugBool, uint64, error) {
 	opts := bind.FilterOpts{
 		Start:   start,
 		End:     nil, // TODO: end doesn't seem to work
-		Context: context.Background(),
+		Context: ctx,
 	}
 
 	itr, err := p.plasma.FilterDebugBool(&opts)
 
 	if err != nil {
-		log.Fatalf("Failed to filter debug bool events: %v", err)
+		return nil, 0, wrapError("debugBoolFilter", err)
 	}
 
 	next := true
@@ -165,22 +185,27 @@ func (p *PlasmaClient) DebugBoolFilter(
 		next = itr.Next()
 	}
 
-	return events, lastBlockNumber
+	if err := itr.Error(); err != nil {
+		return nil, 0, wrapError("debugBoolFilter", err)
+	}
+
+	return events, lastBlockNumber, nil
 }
 
 func (p *PlasmaClient) ChallengeSuccessFilter(
+	ctx context.Context,
 	start uint64,
-) ([]contracts.PlasmaChallengeSuccess, uint64) {
+) ([]contracts.PlasmaChallengeSuccess, uint64, error) {
 	opts := bind.FilterOpts{
 		Start:   start,
 		End:     nil, // TODO: end doesn't seem to work
-		Context: context.Background(),
+		Context: ctx,
 	}
 
 	itr, err := p.plasma.FilterChallengeSuccess(&opts)
 
 	if err != nil {
-		log.Fatalf("Failed to filter challenge success events: %v", err)
+		return nil, 0, wrapError("challengeSuccessFilter", err)
 	}
 
 	next := true
@@ -197,22 +222,27 @@ func (p *PlasmaClient) ChallengeSuccessFilter(
 		next = itr.Next()
 	}
 
-	return events, lastBlockNumber
+	if err := itr.Error(); err != nil {
+		return nil, 0, wrapError("challengeSuccessFilter", err)
+	}
+
+	return events, lastBlockNumber, nil
 }
 
 func (p *PlasmaClient) ChallengeFailureFilter(
+	ctx context.Context,
 	start uint64,
-) ([]contracts.PlasmaChallengeFailure, uint64) {
+) ([]contracts.PlasmaChallengeFailure, uint64, error) {
 	opts := bind.FilterOpts{
 		Start:   start,
 		End:     nil, // TODO: end doesn't seem to work
-		Context: context.Background(),
+		Context: ctx,
 	}
 
 	itr, err := p.plasma.FilterChallengeFailure(&opts)
 
 	if err != nil {
-		log.Fatalf("Failed to filter challenge failure events: %v", err)
+		return nil, 0, wrapError("challengeFailureFilter", err)
 	}
 
 	next := true
@@ -229,5 +259,9 @@ func (p *PlasmaClient) ChallengeFailureFilter(
 		next = itr.Next()
 	}
 
-	return events, lastBlockNumber
+	if err := itr.Error(); err != nil {
+		return nil, 0, wrapError("challengeFailureFilter", err)
+	}
+
+	return events, lastBlockNumber, nil
 }
@@ -1,28 +1,41 @@
 package eth
 
 import (
-	"crypto/ecdsa"
+	"context"
+	"fmt"
 	"log"
 	"math/big"
+	"strings"
 
+	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/rlp"
 	"gopkg.in/urfave/cli.v1"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/kyokan/plasma/chain"
 	"github.com/kyokan/plasma/contracts/gen/contracts"
+	"github.com/kyokan/plasma/signer"
 	"github.com/kyokan/plasma/util"
-	plasma_common "github.com/kyokan/plasma/common"
 )
 
 type PlasmaClient struct {
-	plasma      *contracts.Plasma
-	privateKey  *ecdsa.PrivateKey
-	userAddress string
-	ethClient   plasma_common.Client
-	useGeth     bool
+	plasma          *contracts.Plasma
+	plasmaAbi       abi.ABI
+	contractAddress common.Address
+	backend         Backend
+	userAddress     string
+	txManager       *TxManager
+}
+
+// Backend is the Ethereum client a PlasmaClient talks to. *ethclient.Client
+// implements it.
+type Backend interface {
+	bind.ContractBackend
+	TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
 }
 
 type Exit struct {
@@ -37,179 +50,284 @@ type Exit struct {
 type Block struct {
 	Root      []byte
 	StartedAt *big.Int
+	// TokenRoot is the root of the block's token tree, all zeros when it
+	// sends no tokens or is a deposit block.
+	TokenRoot []byte
 }
 
 func CreatePlasmaClientCLI(c *cli.Context) *PlasmaClient {
 	contractAddress := c.GlobalString("contract-addr")
 	nodeURL := c.GlobalString("node-url")
-	keystoreDir := c.GlobalString("keystore-dir")
-	keystoreFile := c.GlobalString("keystore-file")
-	userAddress := c.GlobalString("user-address")
-	privateKey := c.GlobalString("private-key")
-	signPassphrase := c.GlobalString("sign-passphrase")
-	useGeth := c.GlobalBool("use-geth")
-
-	privateKeyECDSA := util.CreatePrivateKeyECDSA(
-		userAddress,
-		privateKey,
-		keystoreDir,
-		keystoreFile,
-		signPassphrase,
-	)
 
-	return CreatePlasmaClient(
+	s, err := signer.FromCLI(c)
+
+	if err != nil {
+		log.Fatalf("Failed to create signer: %v", err)
+	}
+
+	plasma, err := CreatePlasmaClient(
 		nodeURL,
 		contractAddress,
-		userAddress,
-		privateKeyECDSA,
-		useGeth,
+		s,
+		TxManagerConfigFromCLI(c),
 	)
+
+	if err != nil {
+		log.Fatalf("Failed to create plasma client: %v", err)
+	}
+
+	return plasma
 }
 
 func CreatePlasmaClient(
 	nodeUrl string,
 	contractAddress string,
-	userAddress string,
-	privateKeyECDSA *ecdsa.PrivateKey,
-	useGeth bool,
-) *PlasmaClient {
+	s signer.Signer,
+	txConfig TxManagerConfig,
+) (*PlasmaClient, error) {
 	conn, err := ethclient.Dial(nodeUrl)
 
 	if err != nil {
-		log.Fatalf("Failed to start ETH client: %v", err)
+		return nil, wrapError("dial", err)
 	}
 
-	plasma, err := contracts.NewPlasma(common.HexToAddress(contractAddress), conn)
+	return NewPlasmaClient(conn, common.HexToAddress(contractAddress), s, txConfig)
+}
+
+// NewPlasmaClient creates a client for the plasma contract at
+// contractAddress that sends transactions from the signer's account.
+func NewPlasmaClient(
+	backend Backend,
+	contractAddress common.Address,
+	s signer.Signer,
+	txConfig TxManagerConfig,
+) (*PlasmaClient, error) {
+	plasma, err := contracts.NewPlasma(contractAddress, backend)
 
 	if err != nil {
-		log.Fatalf("Failed to instantiate a Token contract: %v", err)
-	}
-
-	if privateKeyECDSA == nil {
-		log.Fatalln("Private key ecdsa not found")
+		return nil, err
 	}
 
-	// TODO: this is a duplicate eth client, might be able to merge them.
-	ethClient, err := NewClient(nodeUrl)
+	plasmaAbi, err := abi.JSON(strings.NewReader(contracts.PlasmaABI))
 
 	if err != nil {
-		log.Fatalf("Failed to create a new eth client: %v", err)
+		return nil, err
 	}
 
+	from := s.Address()
+
 	return &PlasmaClient{
-		plasma,
-		privateKeyECDSA,
-		userAddress,
-		ethClient,
-		useGeth,
-	}
+		plasma:          plasma,
+		plasmaAbi:       plasmaAbi,
+		contractAddress: contractAddress,
+		backend:         backend,
+		userAddress:     from.Hex(),
+		txManager:       NewTxManager(backend, from, signer.TransactorFn(s), nil, txConfig),
+	}, nil
+}
+
+// ResumeSubmissions persists submissions to store from now on, and resumes
+// tracking the submissions that were in flight when the process stopped.
+func (p *PlasmaClient) ResumeSubmissions(store SubmissionStore) ([]*Submission, error) {
+	p.txManager.store = store
+	return p.txManager.Resume()
 }
 
-func (p *PlasmaClient) SubmitBlock(
-	merkle util.MerkleTree,
-) {
-	var opts *bind.TransactOpts
+// transact calls method on the plasma contract through the transaction
+// manager.
+func (p *PlasmaClient) transact(
+	ctx context.Context,
+	description string,
+	value *big.Int,
+	method string,
+	args ...interface{},
+) (*Submission, error) {
+	data, err := p.plasmaAbi.Pack(method, args...)
 
-	if p.useGeth {
-		opts = p.ethClient.NewGethTransactor(common.HexToAddress(p.userAddress))
-	} else {
-		opts = util.CreateAuth(p.privateKey)
+	if err != nil {
+		return nil, wrapError(method, err)
 	}
 
-	var root [32]byte
-	copy(root[:], merkle.Root.Hash[:32])
-	tx, err := p.plasma.SubmitBlock(opts, root)
+	sub, err := p.txManager.Submit(ctx, description, p.contractAddress, value, data)
 
 	if err != nil {
-		log.Fatalf("Failed to submit block: %v", err)
+		return nil, wrapError(method, err)
 	}
 
-	log.Printf("Submit block pending: 0x%x\n", tx.Hash())
+	return sub, nil
+}
+
+func (p *PlasmaClient) callOpts(ctx context.Context) *bind.CallOpts {
+	opts := util.CreateCallOpts(p.userAddress)
+	opts.Context = ctx
+	return opts
+}
+
+// SubmitBlock sends the next child block's merkle root of transactions and
+// its token root, the merkle root of the tokens the block sends, to the
+// plasma contract. A nil tokenRoot, for a block that sends no tokens, is
+// sent as 32 zero bytes. The returned submission reports whether the
+// transaction was mined.
+func (p *PlasmaClient) SubmitBlock(ctx context.Context, merkleRoot util.Hash, tokenRoot util.Hash) (*Submission, error) {
+	var root, tokens [32]byte
+	copy(root[:], merkleRoot[:32])
+	copy(tokens[:], tokenRoot)
+
+	return p.transact(ctx, "Submit block", nil, "submitBlock", root, tokens)
 }
 
 func (p *PlasmaClient) Deposit(
+	ctx context.Context,
 	value uint64,
 	t *chain.Transaction,
-) {
-	var opts *bind.TransactOpts
+) (*Submission, error) {
+	bytes, err := rlp.EncodeToBytes(&t)
 
-	if p.useGeth {
-		opts = p.ethClient.NewGethTransactor(common.HexToAddress(p.userAddress))
-	} else {
-		opts = util.CreateAuth(p.privateKey)
+	if err != nil {
+		return nil, err
 	}
 
-	opts.Value = util.NewUint64(value)
+	return p.transact(ctx, "Deposit", util.NewUint64(value), "deposit", bytes)
+}
 
+// DepositNFT deposits token id of the ERC-721 contract token, which must
+// have approved the plasma contract to transfer it. t credits the token's
+// plasma id to its owner.
+func (p *PlasmaClient) DepositNFT(
+	ctx context.Context,
+	token common.Address,
+	id *big.Int,
+	t *chain.Transaction,
+) (*Submission, error) {
 	bytes, err := rlp.EncodeToBytes(&t)
 
 	if err != nil {
-		log.Fatalf("Failed to encode tx to rlp bytes: %v", err)
+		return nil, err
 	}
 
-	tx, err := p.plasma.Deposit(opts, bytes)
+	return p.transact(ctx, "DepositNFT", big.NewInt(0), "depositNFT", token, id, bytes)
+}
+
+func (p *PlasmaClient) StartExit(
+	ctx context.Context,
+	block *chain.Block,
+	txs []chain.Transaction,
+	blocknum *big.Int,
+	txindex *big.Int,
+	oindex *big.Int,
+) (*Submission, error) {
+	bytes, proof, err := exitProof("startExit", txs, blocknum, txindex)
 
 	if err != nil {
-		log.Fatalf("Failed to deposit (in eth): %v", err)
+		return nil, err
 	}
 
-	log.Printf("Deposit pending: 0x%x\n", tx.Hash())
+	return p.transact(
+		ctx,
+		"Start Exit",
+		nil,
+		"startExit",
+		blocknum,
+		txindex,
+		oindex,
+		bytes,
+		proof,
+	)
 }
 
-func (p *PlasmaClient) StartExit(
+// StartMultisigExit starts the exit of a multisig output with sigs, the
+// signatures of threshold of its owners over its ExitHash as returned by
+// Output.ExitSignatures.
+func (p *PlasmaClient) StartMultisigExit(
+	ctx context.Context,
 	block *chain.Block,
 	txs []chain.Transaction,
 	blocknum *big.Int,
 	txindex *big.Int,
 	oindex *big.Int,
-) {
-	var opts *bind.TransactOpts
+	sigs []byte,
+) (*Submission, error) {
+	bytes, proof, err := exitProof("startMultisigExit", txs, blocknum, txindex)
 
-	if p.useGeth {
-		opts = p.ethClient.NewGethTransactor(common.HexToAddress(p.userAddress))
-	} else {
-		opts = util.CreateAuth(p.privateKey)
+	if err != nil {
+		return nil, err
 	}
 
-	tx := txs[txindex.Int64()]
+	return p.transact(
+		ctx,
+		"Start Multisig Exit",
+		nil,
+		"startMultisigExit",
+		blocknum,
+		txindex,
+		oindex,
+		bytes,
+		proof,
+		sigs,
+	)
+}
 
-	bytes, err := rlp.EncodeToBytes(&tx)
+// StartClaimExit starts the exit of a hash-locked output to its owner with
+// preimage, before the chain is past its time lock. sigs are only needed
+// when the output is multisig, as for StartMultisigExit.
+func (p *PlasmaClient) StartClaimExit(
+	ctx context.Context,
+	block *chain.Block,
+	txs []chain.Transaction,
+	blocknum *big.Int,
+	txindex *big.Int,
+	oindex *big.Int,
+	preimage []byte,
+	sigs []byte,
+) (*Submission, error) {
+	bytes, proof, err := exitProof("startClaimExit", txs, blocknum, txindex)
 
 	if err != nil {
-		log.Fatalf("Failed to encode tx to rlp bytes: %v", err)
+		return nil, err
 	}
 
-	merkle := CreateMerkleTree(txs)
-	proof := util.CreateMerkleProof(merkle, txindex)
-
-	res, err := p.plasma.StartExit(
-		opts,
+	return p.transact(
+		ctx,
+		"Start Claim Exit",
+		nil,
+		"startClaimExit",
 		blocknum,
 		txindex,
 		oindex,
 		bytes,
 		proof,
+		preimage,
+		sigs,
 	)
+}
+
+// exitProof returns the encoded transaction at txindex and its Merkle proof.
+func exitProof(op string, txs []chain.Transaction, blocknum *big.Int, txindex *big.Int) ([]byte, []byte, error) {
+	if txindex.Int64() >= int64(len(txs)) {
+		return nil, nil, &Error{Op: op, Kind: ErrNotFound, Err: fmt.Errorf("transaction %d not found in block %d", txindex, blocknum)}
+	}
+
+	tx := txs[txindex.Int64()]
+
+	bytes, err := rlp.EncodeToBytes(&tx)
 
 	if err != nil {
-		log.Fatalf("Failed to start exit: %v", err)
+		return nil, nil, err
 	}
 
-	log.Printf("Start Exit pending: 0x%x\n", res.Hash())
+	merkle := CreateMerkleTree(txs)
+	return bytes, util.CreateMerkleProof(merkle, txindex), nil
 }
 
 func (p *PlasmaClient) ChallengeExit(
+	ctx context.Context,
 	exitId *big.Int,
 	txs []chain.Transaction,
 	blocknum *big.Int,
 	txindex *big.Int,
-) {
-	var opts *bind.TransactOpts
-
-	if p.useGeth {
-		opts = p.ethClient.NewGethTransactor(common.HexToAddress(p.userAddress))
-	} else {
-		opts = util.CreateAuth(p.privateKey)
+) (*Submission, error) {
+	if txindex.Int64() >= int64(len(txs)) {
+		return nil, &Error{Op: "challengeExit", Kind: ErrNotFound, Err: fmt.Errorf("transaction %d not found in block %d", txindex, blocknum)}
 	}
 
 	tx := txs[txindex.Int64()]
@@ -217,53 +335,45 @@ func (p *PlasmaClient) ChallengeExit(
 	bytes, err := rlp.EncodeToBytes(&tx)
 
 	if err != nil {
-		log.Fatalf("Failed to encode tx to rlp bytes: %v", err)
+		return nil, err
 	}
 
 	merkle := CreateMerkleTree(txs)
 	proof := util.CreateMerkleProof(merkle, txindex)
 
-	res, err := p.plasma.ChallengeExit(
-		opts,
+	return p.transact(
+		ctx,
+		"Challenge Exit",
+		nil,
+		"challengeExit",
 		exitId,
 		blocknum,
 		txindex,
 		bytes,
 		proof,
 	)
-
-	if err != nil {
-		log.Fatalf("Failed to challenge exit: %v", err)
-	}
-
-	log.Printf("Challenge Exit pending: 0x%x\n", res.Hash())
 }
 
-func (p *PlasmaClient) Finalize() {
-	var opts *bind.TransactOpts
+func (p *PlasmaClient) Finalize(ctx context.Context) (*Submission, error) {
+	return p.transact(ctx, "Finalize", nil, "finalize")
+}
 
-	if p.useGeth {
-		opts = p.ethClient.NewGethTransactor(common.HexToAddress(p.userAddress))
-	} else {
-		opts = util.CreateAuth(p.privateKey)
-	}
+// WithdrawToken sends a token whose exit is finalized to the exit's owner.
+func (p *PlasmaClient) WithdrawToken(ctx context.Context, tokenID uint64) (*Submission, error) {
+	return p.transact(ctx, "Withdraw token", nil, "withdrawToken", tokenID)
+}
 
-	res, err := p.plasma.Finalize(opts)
+// GetExit returns an exit, or an error of kind ErrNotFound if it does not
+// exist.
+func (p *PlasmaClient) GetExit(ctx context.Context, exitId *big.Int) (Exit, error) {
+	owner, amount, blocknum, txindex, oindex, startedAt, err := p.plasma.GetExit(p.callOpts(ctx), exitId)
 
 	if err != nil {
-		log.Fatalf("Failed to finalize exits: %v", err)
+		return Exit{}, wrapError("getExit", err)
 	}
 
-	log.Printf("Finalize pending: 0x%x\n", res.Hash())
-}
-
-func (p *PlasmaClient) GetExit(exitId *big.Int) Exit {
-	opts := util.CreateCallOpts(p.userAddress)
-
-	owner, amount, blocknum, txindex, oindex, startedAt, err := p.plasma.GetExit(opts, exitId)
-
-	if err != nil {
-		log.Fatalf("Failed to get exit: %v", err)
+	if owner == (common.Address{}) {
+		return Exit{}, &Error{Op: "getExit", Kind: ErrNotFound, Err: fmt.Errorf("exit %s not found", exitId)}
 	}
 
 	return Exit{
@@ -273,28 +383,37 @@ func (p *PlasmaClient) GetExit(exitId *big.Int) Exit {
 		txindex,
 		oindex,
 		startedAt,
-	}
+	}, nil
 }
 
-func (p *PlasmaClient) GetBlock(blocknum *big.Int) Block {
-	opts := util.CreateCallOpts(p.userAddress)
-
-	log.Printf("GetBlock for address 0x%x\n", opts.From)
-	root, startedAt, err := p.plasma.GetBlock(opts, blocknum)
+// GetBlock returns a child block, or an error of kind ErrNotFound if the
+// contract does not have it yet.
+func (p *PlasmaClient) GetBlock(ctx context.Context, blocknum *big.Int) (Block, error) {
+	root, startedAt, tokenRoot, err := p.plasma.GetBlock(p.callOpts(ctx), blocknum)
 
 	if err != nil {
-		log.Fatalf("Failed to get block: %v", err)
+		return Block{}, wrapError("getBlock", err)
+	}
+
+	if startedAt == nil || startedAt.Sign() == 0 {
+		return Block{}, &Error{Op: "getBlock", Kind: ErrNotFound, Err: fmt.Errorf("child block %s not found", blocknum)}
 	}
 
 	return Block{
 		root[:],
 		startedAt,
-	}
+		tokenRoot[:],
+	}, nil
 }
 
-func (p *PlasmaClient) CurrentChildBlock() (*big.Int, error) {
-	opts := util.CreateCallOpts(p.userAddress)
-	return p.plasma.CurrentChildBlock(opts)
+func (p *PlasmaClient) CurrentChildBlock(ctx context.Context) (*big.Int, error) {
+	current, err := p.plasma.CurrentChildBlock(p.callOpts(ctx))
+
+	if err != nil {
+		return nil, wrapError("currentChildBlock", err)
+	}
+
+	return current, nil
 }
 
 // Note this prevents import cycle with utils.
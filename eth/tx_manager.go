@@ -0,0 +1,441 @@
+package eth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/kyokan/plasma/metrics"
+)
+
+// TxBackend is the part of an Ethereum client the transaction manager needs.
+// *ethclient.Client implements it.
+type TxBackend interface {
+	PendingNonceAt(ctx context.Context, account common.Address) (uint64, error)
+	SuggestGasPrice(ctx context.Context) (*big.Int, error)
+	EstimateGas(ctx context.Context, call ethereum.CallMsg) (uint64, error)
+	SendTransaction(ctx context.Context, tx *types.Transaction) error
+	TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+}
+
+// SubmissionStore persists in-flight submissions, so tracking resumes after a
+// restart.
+type SubmissionStore interface {
+	SaveSubmission(record *SubmissionRecord) error
+	DeleteSubmission(nonce uint64) error
+	Submissions() ([]SubmissionRecord, error)
+}
+
+type TxManagerConfig struct {
+	// Blocks on top of the block containing a transaction before it is
+	// considered final.
+	Confirmations uint64
+
+	// A transaction that is not mined within BumpInterval is resent with a
+	// gas price raised by BumpPercent, up to MaxGasPrice.
+	BumpInterval time.Duration
+	BumpPercent  int64
+	MaxGasPrice  *big.Int
+
+	// Percentage added on top of the estimated gas.
+	GasLimitMargin int64
+
+	PollInterval time.Duration
+}
+
+func DefaultTxManagerConfig() TxManagerConfig {
+	return TxManagerConfig{
+		Confirmations:  1,
+		BumpInterval:   2 * time.Minute,
+		BumpPercent:    20,
+		MaxGasPrice:    new(big.Int).Mul(big.NewInt(200), big.NewInt(GWEI)),
+		GasLimitMargin: 20,
+		PollInterval:   5 * time.Second,
+	}
+}
+
+// SubmissionRecord is the persisted state of a submission. Hashes holds every
+// transaction sent for the nonce, since any of them may end up mined.
+type SubmissionRecord struct {
+	Description string
+	Nonce       uint64
+	To          common.Address
+	Value       *big.Int
+	Data        []byte
+	GasLimit    uint64
+	GasPrice    *big.Int
+	Hashes      []common.Hash
+	LastSent    uint64
+}
+
+// Submission tracks a transaction until it is confirmed or fails.
+type Submission struct {
+	mtx     sync.Mutex
+	record  SubmissionRecord
+	done    chan struct{}
+	receipt *types.Receipt
+	err     error
+	// When the submission was first sent, unknown for resumed submissions.
+	sentAt time.Time
+}
+
+var ErrTransactionFailed = errors.New("transaction failed")
+
+func (s *Submission) Nonce() uint64 {
+	return s.record.Nonce
+}
+
+// Hash returns the hash of the transaction sent last.
+func (s *Submission) Hash() common.Hash {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return s.record.Hashes[len(s.record.Hashes)-1]
+}
+
+// Done is closed once the submission is confirmed or has failed.
+func (s *Submission) Done() <-chan struct{} {
+	return s.done
+}
+
+// Wait blocks until the submission is confirmed or has failed. A reverted
+// transaction returns its receipt along with ErrTransactionFailed.
+func (s *Submission) Wait(ctx context.Context) (*types.Receipt, error) {
+	select {
+	case <-s.done:
+		return s.receipt, s.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// TxManager sends transactions from a single account. It assigns nonces
+// locally so that submissions can be made concurrently, estimates gas,
+// raises the gas price of transactions that are not mined and waits for
+// confirmations.
+type TxManager struct {
+	mtx         sync.Mutex
+	backend     TxBackend
+	from        common.Address
+	signer      bind.SignerFn
+	store       SubmissionStore
+	config      TxManagerConfig
+	nonce       uint64
+	nonceLoaded bool
+}
+
+func NewTxManager(
+	backend TxBackend,
+	from common.Address,
+	signer bind.SignerFn,
+	store SubmissionStore,
+	config TxManagerConfig,
+) *TxManager {
+	return &TxManager{
+		backend: backend,
+		from:    from,
+		signer:  signer,
+		store:   store,
+		config:  config,
+	}
+}
+
+// Resume resumes tracking the submissions that were in flight when the
+// process stopped.
+func (m *TxManager) Resume() ([]*Submission, error) {
+	if m.store == nil {
+		return nil, nil
+	}
+
+	records, err := m.store.Submissions()
+
+	if err != nil {
+		return nil, err
+	}
+
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	var subs []*Submission
+
+	for _, record := range records {
+		if record.Nonce >= m.nonce {
+			m.nonce = record.Nonce + 1
+		}
+
+		log.Printf("Resuming tracking of %s with nonce %d.", record.Description, record.Nonce)
+
+		sub := &Submission{record: record, done: make(chan struct{})}
+		subs = append(subs, sub)
+		go m.track(sub)
+	}
+
+	return subs, nil
+}
+
+// Submit sends a transaction calling to with data and starts tracking it.
+func (m *TxManager) Submit(
+	ctx context.Context,
+	description string,
+	to common.Address,
+	value *big.Int,
+	data []byte,
+) (*Submission, error) {
+	if value == nil {
+		value = new(big.Int)
+	}
+
+	gasPrice, err := m.backend.SuggestGasPrice(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if m.config.MaxGasPrice != nil && gasPrice.Cmp(m.config.MaxGasPrice) > 0 {
+		gasPrice = new(big.Int).Set(m.config.MaxGasPrice)
+	}
+
+	gasLimit, err := m.backend.EstimateGas(ctx, ethereum.CallMsg{
+		From:     m.from,
+		To:       &to,
+		GasPrice: gasPrice,
+		Value:    value,
+		Data:     data,
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to estimate gas for %s: %v", description, err)
+	}
+
+	gasLimit += gasLimit * uint64(m.config.GasLimitMargin) / 100
+
+	// Nonces are assigned and transactions sent under the lock, so a failed
+	// send can hand its nonce back.
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	if err := m.loadNonce(ctx); err != nil {
+		return nil, err
+	}
+
+	record := SubmissionRecord{
+		Description: description,
+		Nonce:       m.nonce,
+		To:          to,
+		Value:       value,
+		Data:        data,
+		GasLimit:    gasLimit,
+		GasPrice:    gasPrice,
+	}
+
+	tx, err := m.send(ctx, &record)
+
+	if err != nil && isNonceError(err) {
+		// Another process used the account, start over from the node's nonce.
+		m.nonceLoaded = false
+
+		if err := m.loadNonce(ctx); err != nil {
+			return nil, err
+		}
+
+		record.Nonce = m.nonce
+		tx, err = m.send(ctx, &record)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	m.nonce++
+
+	log.Printf("%s pending: 0x%x\n", description, tx.Hash())
+
+	sub := &Submission{record: record, done: make(chan struct{}), sentAt: time.Now()}
+	go m.track(sub)
+	return sub, nil
+}
+
+func (m *TxManager) loadNonce(ctx context.Context) error {
+	if m.nonceLoaded {
+		return nil
+	}
+
+	nonce, err := m.backend.PendingNonceAt(ctx, m.from)
+
+	if err != nil {
+		return err
+	}
+
+	if nonce > m.nonce {
+		m.nonce = nonce
+	}
+
+	m.nonceLoaded = true
+	return nil
+}
+
+// send signs and sends the transaction described by record, and persists
+// the record with the new hash.
+func (m *TxManager) send(ctx context.Context, record *SubmissionRecord) (*types.Transaction, error) {
+	tx := types.NewTransaction(record.Nonce, record.To, record.Value, record.GasLimit, record.GasPrice, record.Data)
+	signed, err := m.signer(types.HomesteadSigner{}, m.from, tx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := m.backend.SendTransaction(ctx, signed); err != nil {
+		return nil, err
+	}
+
+	record.Hashes = append(record.Hashes, signed.Hash())
+	record.LastSent = uint64(time.Now().Unix())
+
+	if m.store != nil {
+		if err := m.store.SaveSubmission(record); err != nil {
+			log.Printf("Failed to persist %s with nonce %d: %v", record.Description, record.Nonce, err)
+		}
+	}
+
+	return signed, nil
+}
+
+func (m *TxManager) track(sub *Submission) {
+	tick := time.NewTicker(m.config.PollInterval)
+	defer tick.Stop()
+
+	// Head block at the time a receipt was first seen. Receipts do not carry
+	// their block number, so confirmations are counted from here.
+	var minedAt *big.Int
+
+	for range tick.C {
+		receipt, err := m.findReceipt(&sub.record)
+
+		if err != nil {
+			log.Printf("Failed to get receipt for %s: %v", sub.record.Description, err)
+			continue
+		}
+
+		if receipt == nil {
+			// Dropped by a reorg, or not mined yet.
+			minedAt = nil
+			m.bumpIfStuck(sub)
+			continue
+		}
+
+		head, err := m.backend.HeaderByNumber(context.Background(), nil)
+
+		if err != nil {
+			log.Printf("Failed to get head block: %v", err)
+			continue
+		}
+
+		if minedAt == nil {
+			minedAt = head.Number
+		}
+
+		if new(big.Int).Sub(head.Number, minedAt).Uint64()+1 < m.config.Confirmations {
+			continue
+		}
+
+		m.finish(sub, receipt)
+		return
+	}
+}
+
+func (m *TxManager) findReceipt(record *SubmissionRecord) (*types.Receipt, error) {
+	for _, hash := range record.Hashes {
+		receipt, err := m.backend.TransactionReceipt(context.Background(), hash)
+
+		if err == ethereum.NotFound || (err == nil && receipt == nil) {
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		return receipt, nil
+	}
+
+	return nil, nil
+}
+
+func (m *TxManager) bumpIfStuck(sub *Submission) {
+	sub.mtx.Lock()
+	defer sub.mtx.Unlock()
+
+	record := &sub.record
+	lastSent := time.Unix(int64(record.LastSent), 0)
+
+	if m.config.BumpInterval <= 0 || time.Since(lastSent) < m.config.BumpInterval {
+		return
+	}
+
+	gasPrice := new(big.Int).Mul(record.GasPrice, big.NewInt(100+m.config.BumpPercent))
+	gasPrice = gasPrice.Div(gasPrice, big.NewInt(100))
+
+	if m.config.MaxGasPrice != nil && gasPrice.Cmp(m.config.MaxGasPrice) > 0 {
+		gasPrice = new(big.Int).Set(m.config.MaxGasPrice)
+	}
+
+	if gasPrice.Cmp(record.GasPrice) <= 0 {
+		return
+	}
+
+	prevPrice := record.GasPrice
+	record.GasPrice = gasPrice
+	tx, err := m.send(context.Background(), record)
+
+	if err != nil {
+		record.GasPrice = prevPrice
+
+		// A nonce error means one of the sent transactions was mined.
+		if !isNonceError(err) {
+			log.Printf("Failed to bump gas price of %s: %v", record.Description, err)
+		}
+
+		return
+	}
+
+	log.Printf("%s not mined after %s, resent with gas price %s: 0x%x",
+		record.Description, m.config.BumpInterval, gasPrice, tx.Hash())
+}
+
+func (m *TxManager) finish(sub *Submission, receipt *types.Receipt) {
+	sub.receipt = receipt
+	metrics.GasUsed.WithLabelValues(sub.record.Description).Add(float64(receipt.GasUsed))
+
+	if !sub.sentAt.IsZero() {
+		metrics.SubmissionLatency.WithLabelValues(sub.record.Description).Observe(time.Since(sub.sentAt).Seconds())
+	}
+
+	if receipt.Status == types.ReceiptStatusFailed {
+		sub.err = ErrTransactionFailed
+		log.Printf("%s failed: 0x%x", sub.record.Description, receipt.TxHash)
+	} else {
+		log.Printf("%s confirmed: 0x%x", sub.record.Description, receipt.TxHash)
+	}
+
+	if m.store != nil {
+		if err := m.store.DeleteSubmission(sub.record.Nonce); err != nil {
+			log.Printf("Failed to delete %s with nonce %d: %v", sub.record.Description, sub.record.Nonce, err)
+		}
+	}
+
+	close(sub.done)
+}
+
+func isNonceError(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "nonce too low") || strings.Contains(msg, "known transaction")
+}
@@ -0,0 +1,177 @@
+package eth
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeBackend struct {
+	mtx      sync.Mutex
+	nonce    uint64
+	head     int64
+	sent     []*types.Transaction
+	receipts map[common.Hash]*types.Receipt
+}
+
+func newFakeBackend() *fakeBackend {
+	return &fakeBackend{receipts: make(map[common.Hash]*types.Receipt)}
+}
+
+func (b *fakeBackend) PendingNonceAt(ctx context.Context, account common.Address) (uint64, error) {
+	return b.nonce, nil
+}
+
+func (b *fakeBackend) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
+	return big.NewInt(GWEI), nil
+}
+
+func (b *fakeBackend) EstimateGas(ctx context.Context, call ethereum.CallMsg) (uint64, error) {
+	return 100000, nil
+}
+
+func (b *fakeBackend) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	b.sent = append(b.sent, tx)
+	return nil
+}
+
+func (b *fakeBackend) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	if receipt, ok := b.receipts[txHash]; ok {
+		return receipt, nil
+	}
+
+	return nil, ethereum.NotFound
+}
+
+func (b *fakeBackend) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	return &types.Header{Number: big.NewInt(b.head)}, nil
+}
+
+func (b *fakeBackend) mine(hash common.Hash, status uint64) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	b.head++
+	b.receipts[hash] = &types.Receipt{TxHash: hash, Status: status}
+}
+
+func (b *fakeBackend) sentTxs() []*types.Transaction {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	return append([]*types.Transaction{}, b.sent...)
+}
+
+func newTestManager(t *testing.T, backend TxBackend, config TxManagerConfig) *TxManager {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	auth := bind.NewKeyedTransactor(key)
+	return NewTxManager(backend, auth.From, auth.Signer, nil, config)
+}
+
+func Test_TxManagerAssignsNonces(t *testing.T) {
+	backend := newFakeBackend()
+	backend.nonce = 7
+	config := DefaultTxManagerConfig()
+	config.PollInterval = time.Millisecond
+	manager := newTestManager(t, backend, config)
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+			_, err := manager.Submit(context.Background(), "Test", common.Address{}, nil, nil)
+			require.NoError(t, err)
+		}()
+	}
+
+	wg.Wait()
+
+	nonces := make(map[uint64]bool)
+
+	for _, tx := range backend.sentTxs() {
+		nonces[tx.Nonce()] = true
+		require.Equal(t, uint64(120000), tx.Gas())
+	}
+
+	for nonce := uint64(7); nonce < 17; nonce++ {
+		require.True(t, nonces[nonce])
+	}
+}
+
+func Test_TxManagerWaitsForConfirmations(t *testing.T) {
+	backend := newFakeBackend()
+	config := DefaultTxManagerConfig()
+	config.Confirmations = 3
+	config.PollInterval = time.Millisecond
+	manager := newTestManager(t, backend, config)
+
+	sub, err := manager.Submit(context.Background(), "Test", common.Address{}, nil, nil)
+	require.NoError(t, err)
+
+	backend.mine(sub.Hash(), types.ReceiptStatusSuccessful)
+	time.Sleep(20 * time.Millisecond)
+
+	select {
+	case <-sub.Done():
+		t.Fatal("submission finished before it was confirmed")
+	default:
+	}
+
+	backend.mine(common.Hash{}, types.ReceiptStatusSuccessful)
+	backend.mine(common.Hash{}, types.ReceiptStatusSuccessful)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	receipt, err := sub.Wait(ctx)
+	require.NoError(t, err)
+	require.Equal(t, sub.Hash(), receipt.TxHash)
+}
+
+func Test_TxManagerBumpsGasPrice(t *testing.T) {
+	backend := newFakeBackend()
+	config := DefaultTxManagerConfig()
+	config.PollInterval = time.Millisecond
+	config.BumpInterval = time.Nanosecond
+	config.MaxGasPrice = big.NewInt(2 * GWEI)
+	manager := newTestManager(t, backend, config)
+
+	sub, err := manager.Submit(context.Background(), "Test", common.Address{}, nil, nil)
+	require.NoError(t, err)
+
+	time.Sleep(50 * time.Millisecond)
+
+	sent := backend.sentTxs()
+	require.Len(t, sent, 5)
+	require.Equal(t, sent[0].Nonce(), sent[1].Nonce())
+	require.Equal(t, big.NewInt(1200000000), sent[1].GasPrice())
+	require.Equal(t, config.MaxGasPrice, sent[4].GasPrice())
+
+	// The first transaction gets mined after all.
+	backend.mine(sent[0].Hash(), types.ReceiptStatusFailed)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	receipt, err := sub.Wait(ctx)
+	require.Equal(t, ErrTransactionFailed, err)
+	require.Equal(t, sent[0].Hash(), receipt.TxHash)
+}
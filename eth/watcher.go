@@ -0,0 +1,456 @@
+package eth
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/kyokan/plasma/contracts/gen/contracts"
+	"github.com/kyokan/plasma/metrics"
+)
+
+// The generated ABI is always valid.
+var plasmaEvents, _ = abi.JSON(strings.NewReader(contracts.PlasmaABI))
+
+// LogBackend is the part of an Ethereum client the event watcher needs.
+// *ethclient.Client implements it.
+type LogBackend interface {
+	FilterLogs(ctx context.Context, query ethereum.FilterQuery) ([]types.Log, error)
+	SubscribeFilterLogs(ctx context.Context, query ethereum.FilterQuery, ch chan<- types.Log) (ethereum.Subscription, error)
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+}
+
+// CheckpointStore persists the Ethereum block a watcher has delivered events
+// up to.
+type CheckpointStore interface {
+	Checkpoint() (uint64, error)
+	SaveCheckpoint(blockNum uint64) error
+}
+
+type WatcherConfig struct {
+	// WebSocket URL of the Ethereum node. Events are polled over the plasma
+	// client's connection when it is empty or cannot be reached.
+	WSURL string
+
+	PollInterval time.Duration
+
+	// Interval at which the WebSocket is redialed while polling.
+	ReconnectInterval time.Duration
+}
+
+func DefaultWatcherConfig() WatcherConfig {
+	return WatcherConfig{
+		PollInterval:      10 * time.Second,
+		ReconnectInterval: 30 * time.Second,
+	}
+}
+
+// EventWatcher delivers plasma contract events to typed channels. It
+// subscribes to the contract's logs over WebSocket, and polls for them when
+// the subscription is down. After a disconnect or restart, it resumes from
+// the checkpoint in its store. The name is used in logs.
+//
+// The channels are unbuffered, and the checkpoint is the Ethereum block of
+// the last event delivered. A consumer that handles events one at a time has
+// therefore finished with every block before the checkpoint. Events in the
+// checkpoint block may be delivered again after a restart, so consumers
+// must tolerate duplicates. Logs removed by reorgs are ignored.
+type EventWatcher struct {
+	name     string
+	store    CheckpointStore
+	config   WatcherConfig
+	address  common.Address
+	contract *bind.BoundContract
+	http     LogBackend
+	dial     func(ctx context.Context) (LogBackend, error)
+
+	deposits          chan contracts.PlasmaDeposit
+	submittedBlocks   chan contracts.PlasmaSubmitBlock
+	startedExits      chan contracts.PlasmaExitStarted
+	challengeSuccess  chan contracts.PlasmaChallengeSuccess
+	challengeFailures chan contracts.PlasmaChallengeFailure
+	finalizedExits    chan contracts.PlasmaFinalizeExit
+
+	// First Ethereum block that has not been scanned yet.
+	next uint64
+	// Position of the last event delivered.
+	last *types.Log
+	// Latest Ethereum block seen, to measure how far events lag behind.
+	head uint64
+}
+
+func NewEventWatcher(
+	plasma *PlasmaClient,
+	name string,
+	store CheckpointStore,
+	config WatcherConfig,
+) *EventWatcher {
+	var dial func(ctx context.Context) (LogBackend, error)
+
+	if config.WSURL != "" {
+		dial = func(ctx context.Context) (LogBackend, error) {
+			return ethclient.DialContext(ctx, config.WSURL)
+		}
+	}
+
+	return newEventWatcher(plasma.contractAddress, plasma.backend, dial, name, store, config)
+}
+
+func newEventWatcher(
+	address common.Address,
+	http LogBackend,
+	dial func(ctx context.Context) (LogBackend, error),
+	name string,
+	store CheckpointStore,
+	config WatcherConfig,
+) *EventWatcher {
+	return &EventWatcher{
+		name:     name,
+		store:    store,
+		config:   config,
+		address:  address,
+		contract: bind.NewBoundContract(address, plasmaEvents, nil, nil, nil),
+		http:     http,
+		dial:     dial,
+	}
+}
+
+// The channels must be requested before the watcher is started. Events
+// without a channel are not watched.
+
+func (w *EventWatcher) Deposits() <-chan contracts.PlasmaDeposit {
+	if w.deposits == nil {
+		w.deposits = make(chan contracts.PlasmaDeposit)
+	}
+
+	return w.deposits
+}
+
+func (w *EventWatcher) SubmittedBlocks() <-chan contracts.PlasmaSubmitBlock {
+	if w.submittedBlocks == nil {
+		w.submittedBlocks = make(chan contracts.PlasmaSubmitBlock)
+	}
+
+	return w.submittedBlocks
+}
+
+func (w *EventWatcher) StartedExits() <-chan contracts.PlasmaExitStarted {
+	if w.startedExits == nil {
+		w.startedExits = make(chan contracts.PlasmaExitStarted)
+	}
+
+	return w.startedExits
+}
+
+func (w *EventWatcher) ChallengeSuccesses() <-chan contracts.PlasmaChallengeSuccess {
+	if w.challengeSuccess == nil {
+		w.challengeSuccess = make(chan contracts.PlasmaChallengeSuccess)
+	}
+
+	return w.challengeSuccess
+}
+
+func (w *EventWatcher) ChallengeFailures() <-chan contracts.PlasmaChallengeFailure {
+	if w.challengeFailures == nil {
+		w.challengeFailures = make(chan contracts.PlasmaChallengeFailure)
+	}
+
+	return w.challengeFailures
+}
+
+func (w *EventWatcher) FinalizedExits() <-chan contracts.PlasmaFinalizeExit {
+	if w.finalizedExits == nil {
+		w.finalizedExits = make(chan contracts.PlasmaFinalizeExit)
+	}
+
+	return w.finalizedExits
+}
+
+// Start watches for events until ctx is done.
+func (w *EventWatcher) Start(ctx context.Context) error {
+	checkpoint, err := w.store.Checkpoint()
+
+	if err != nil {
+		return err
+	}
+
+	w.next = checkpoint
+	log.Printf("Watching %s events from block %d.", w.name, w.next)
+
+	for ctx.Err() == nil {
+		if w.dial != nil {
+			err := w.subscribe(ctx)
+
+			if ctx.Err() != nil {
+				break
+			}
+
+			log.Printf("Lost %s event subscription, polling until reconnected: %v", w.name, err)
+		}
+
+		w.poll(ctx)
+	}
+
+	return ctx.Err()
+}
+
+// subscribe delivers events over WebSocket until the subscription fails.
+func (w *EventWatcher) subscribe(ctx context.Context) error {
+	client, err := w.dial(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	if closer, ok := client.(interface{ Close() }); ok {
+		defer closer.Close()
+	}
+
+	logs := make(chan types.Log, 128)
+	sub, err := client.SubscribeFilterLogs(ctx, w.query(nil, nil), logs)
+
+	if err != nil {
+		return err
+	}
+
+	defer sub.Unsubscribe()
+
+	// Events mined while the watcher was not subscribed are filtered, the
+	// overlap with the subscription is dropped by deliver.
+	if err := w.catchUp(ctx, client); err != nil {
+		return err
+	}
+
+	log.Printf("Subscribed to %s events.", w.name)
+
+	for {
+		select {
+		case raw := <-logs:
+			if raw.BlockNumber > w.head {
+				w.head = raw.BlockNumber
+			}
+
+			if err := w.deliver(ctx, raw); err != nil {
+				return err
+			}
+
+			// The rest of the block may still be on its way.
+			if raw.BlockNumber > w.next {
+				w.next = raw.BlockNumber
+			}
+		case err := <-sub.Err():
+			if err == nil {
+				err = errors.New("subscription closed")
+			}
+
+			return err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// poll delivers events over the plasma client's connection. It returns when
+// it is time to redial the WebSocket, or never if there is none.
+func (w *EventWatcher) poll(ctx context.Context) {
+	tick := time.NewTicker(w.config.PollInterval)
+	defer tick.Stop()
+
+	var reconnect <-chan time.Time
+
+	if w.dial != nil {
+		timer := time.NewTimer(w.config.ReconnectInterval)
+		defer timer.Stop()
+		reconnect = timer.C
+	}
+
+	for {
+		if err := w.catchUp(ctx, w.http); err != nil && ctx.Err() == nil {
+			log.Printf("Failed to poll %s events: %v", w.name, err)
+		}
+
+		select {
+		case <-tick.C:
+		case <-reconnect:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// catchUp delivers the events from the next unscanned block to the head.
+func (w *EventWatcher) catchUp(ctx context.Context, backend LogBackend) error {
+	head, err := backend.HeaderByNumber(ctx, nil)
+
+	if err != nil {
+		return wrapError("headerByNumber", err)
+	}
+
+	if head.Number.Uint64() < w.next {
+		return nil
+	}
+
+	logs, err := backend.FilterLogs(ctx, w.query(new(big.Int).SetUint64(w.next), head.Number))
+
+	if err != nil {
+		return wrapError("filterLogs", err)
+	}
+
+	w.head = head.Number.Uint64()
+
+	for _, raw := range logs {
+		if err := w.deliver(ctx, raw); err != nil {
+			return err
+		}
+	}
+
+	w.next = head.Number.Uint64() + 1
+	return nil
+}
+
+func (w *EventWatcher) query(from, to *big.Int) ethereum.FilterQuery {
+	var topics []common.Hash
+
+	add := func(watched bool, event string) {
+		if watched {
+			topics = append(topics, plasmaEvents.Events[event].Id())
+		}
+	}
+
+	add(w.deposits != nil, "Deposit")
+	add(w.submittedBlocks != nil, "SubmitBlock")
+	add(w.startedExits != nil, "ExitStarted")
+	add(w.challengeSuccess != nil, "ChallengeSuccess")
+	add(w.challengeFailures != nil, "ChallengeFailure")
+	add(w.finalizedExits != nil, "FinalizeExit")
+
+	return ethereum.FilterQuery{
+		FromBlock: from,
+		ToBlock:   to,
+		Addresses: []common.Address{w.address},
+		Topics:    [][]common.Hash{topics},
+	}
+}
+
+// deliver sends a log to the channel of its event, unless it was delivered
+// before.
+func (w *EventWatcher) deliver(ctx context.Context, raw types.Log) error {
+	if raw.Removed || len(raw.Topics) == 0 {
+		return nil
+	}
+
+	if w.last != nil && (raw.BlockNumber < w.last.BlockNumber ||
+		(raw.BlockNumber == w.last.BlockNumber && raw.Index <= w.last.Index)) {
+		return nil
+	}
+
+	name := eventName(raw.Topics[0])
+
+	if w.head >= raw.BlockNumber {
+		metrics.EventLag.WithLabelValues(name).Observe(float64(w.head - raw.BlockNumber))
+	}
+
+	switch name {
+	case "Deposit":
+		event := contracts.PlasmaDeposit{Raw: raw}
+
+		if w.unpack(&event, name, raw) {
+			select {
+			case w.deposits <- event:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	case "SubmitBlock":
+		event := contracts.PlasmaSubmitBlock{Raw: raw}
+
+		if w.unpack(&event, name, raw) {
+			select {
+			case w.submittedBlocks <- event:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	case "ExitStarted":
+		event := contracts.PlasmaExitStarted{Raw: raw}
+
+		if w.unpack(&event, name, raw) {
+			select {
+			case w.startedExits <- event:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	case "ChallengeSuccess":
+		event := contracts.PlasmaChallengeSuccess{Raw: raw}
+
+		if w.unpack(&event, name, raw) {
+			select {
+			case w.challengeSuccess <- event:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	case "ChallengeFailure":
+		event := contracts.PlasmaChallengeFailure{Raw: raw}
+
+		if w.unpack(&event, name, raw) {
+			select {
+			case w.challengeFailures <- event:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	case "FinalizeExit":
+		event := contracts.PlasmaFinalizeExit{Raw: raw}
+
+		if w.unpack(&event, name, raw) {
+			select {
+			case w.finalizedExits <- event:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	default:
+		return nil
+	}
+
+	w.last = &raw
+
+	if err := w.store.SaveCheckpoint(raw.BlockNumber); err != nil {
+		log.Printf("Failed to save %s checkpoint at block %d: %v", w.name, raw.BlockNumber, err)
+	}
+
+	return nil
+}
+
+// unpack decodes a log into event. Logs that cannot be decoded are skipped,
+// since decoding them again would fail the same way.
+func (w *EventWatcher) unpack(event interface{}, name string, raw types.Log) bool {
+	if err := w.contract.UnpackLog(event, name, raw); err != nil {
+		log.Printf("Failed to unpack %s event in %s:%d: %v", name, raw.TxHash.Hex(), raw.Index, err)
+		return false
+	}
+
+	return true
+}
+
+func eventName(topic common.Hash) string {
+	for name, event := range plasmaEvents.Events {
+		if event.Id() == topic {
+			return name
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,190 @@
+package eth
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/kyokan/plasma/contracts/gen/contracts"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeSubscription struct {
+	err chan error
+}
+
+func (s *fakeSubscription) Unsubscribe() {}
+
+func (s *fakeSubscription) Err() <-chan error {
+	return s.err
+}
+
+type fakeLogBackend struct {
+	mtx  sync.Mutex
+	head uint64
+	logs []types.Log
+	subs []chan<- types.Log
+	sub  *fakeSubscription
+}
+
+func (b *fakeLogBackend) FilterLogs(ctx context.Context, query ethereum.FilterQuery) ([]types.Log, error) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	var logs []types.Log
+
+	for _, raw := range b.logs {
+		if raw.BlockNumber >= query.FromBlock.Uint64() && raw.BlockNumber <= query.ToBlock.Uint64() {
+			logs = append(logs, raw)
+		}
+	}
+
+	return logs, nil
+}
+
+func (b *fakeLogBackend) SubscribeFilterLogs(ctx context.Context, query ethereum.FilterQuery, ch chan<- types.Log) (ethereum.Subscription, error) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	if b.sub == nil {
+		return nil, errors.New("notifications not supported")
+	}
+
+	b.subs = append(b.subs, ch)
+	return b.sub, nil
+}
+
+func (b *fakeLogBackend) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	return &types.Header{Number: new(big.Int).SetUint64(b.head)}, nil
+}
+
+// mine adds a log to the backend and sends it to subscribers.
+func (b *fakeLogBackend) mine(raw types.Log) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	b.logs = append(b.logs, raw)
+
+	if raw.BlockNumber > b.head {
+		b.head = raw.BlockNumber
+	}
+
+	for _, ch := range b.subs {
+		ch <- raw
+	}
+}
+
+type memCheckpoint struct {
+	mtx      sync.Mutex
+	blockNum uint64
+}
+
+func (c *memCheckpoint) Checkpoint() (uint64, error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return c.blockNum, nil
+}
+
+func (c *memCheckpoint) SaveCheckpoint(blockNum uint64) error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.blockNum = blockNum
+	return nil
+}
+
+func depositLog(t *testing.T, blockNumber uint64, index uint, blocknum int64) types.Log {
+	data, err := plasmaEvents.Events["Deposit"].Inputs.Pack(common.Address{}, big.NewInt(100), big.NewInt(blocknum), big.NewInt(0))
+	require.NoError(t, err)
+
+	return types.Log{
+		Topics:      []common.Hash{plasmaEvents.Events["Deposit"].Id()},
+		Data:        data,
+		BlockNumber: blockNumber,
+		Index:       index,
+	}
+}
+
+func receiveDeposit(t *testing.T, deposits <-chan contracts.PlasmaDeposit) contracts.PlasmaDeposit {
+	select {
+	case deposit := <-deposits:
+		return deposit
+	case <-time.After(time.Second):
+		t.Fatal("no deposit received")
+		return contracts.PlasmaDeposit{}
+	}
+}
+
+func Test_EventWatcherPollsFromCheckpoint(t *testing.T) {
+	backend := &fakeLogBackend{}
+	backend.mine(depositLog(t, 3, 0, 2))
+	backend.mine(depositLog(t, 5, 0, 3))
+	backend.mine(depositLog(t, 7, 1, 4))
+
+	store := &memCheckpoint{blockNum: 5}
+	config := WatcherConfig{PollInterval: time.Millisecond}
+	watcher := newEventWatcher(common.Address{}, backend, nil, "test", store, config)
+	deposits := watcher.Deposits()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go watcher.Start(ctx)
+
+	require.Equal(t, int64(3), receiveDeposit(t, deposits).Blocknum.Int64())
+	require.Equal(t, int64(4), receiveDeposit(t, deposits).Blocknum.Int64())
+
+	backend.mine(depositLog(t, 9, 0, 5))
+	require.Equal(t, int64(5), receiveDeposit(t, deposits).Blocknum.Int64())
+
+	// The checkpoint is saved once the event is received.
+	for i := 0; i < 100; i++ {
+		if checkpoint, _ := store.Checkpoint(); checkpoint == 9 {
+			return
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+
+	t.Fatal("checkpoint not saved")
+}
+
+func Test_EventWatcherFallsBackToPolling(t *testing.T) {
+	http := &fakeLogBackend{}
+	ws := &fakeLogBackend{sub: &fakeSubscription{err: make(chan error, 1)}}
+	ws.mine(depositLog(t, 2, 0, 2))
+
+	dial := func(ctx context.Context) (LogBackend, error) {
+		return ws, nil
+	}
+
+	config := WatcherConfig{PollInterval: time.Millisecond, ReconnectInterval: time.Hour}
+	watcher := newEventWatcher(common.Address{}, http, dial, "test", &memCheckpoint{}, config)
+	deposits := watcher.Deposits()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go watcher.Start(ctx)
+
+	// Caught up over the WebSocket before subscribing.
+	require.Equal(t, int64(2), receiveDeposit(t, deposits).Blocknum.Int64())
+
+	// The subscription repeats a delivered log before the next one.
+	go func() {
+		ws.mine(depositLog(t, 2, 0, 2))
+		ws.mine(depositLog(t, 4, 0, 3))
+	}()
+	require.Equal(t, int64(3), receiveDeposit(t, deposits).Blocknum.Int64())
+
+	ws.sub.err <- errors.New("connection lost")
+
+	http.mine(depositLog(t, 4, 0, 3))
+	http.mine(depositLog(t, 6, 0, 4))
+	require.Equal(t, int64(4), receiveDeposit(t, deposits).Blocknum.Int64())
+}
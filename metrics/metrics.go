@@ -0,0 +1,105 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "plasma"
+
+// Reasons transactions are rejected for.
+const (
+	RejectMalformed   = "malformed"
+	RejectUnsigned    = "unsigned"
+	RejectInvalid     = "invalid"
+	RejectDoubleSpend = "double_spend"
+	RejectTransition  = "transition"
+)
+
+var (
+	MempoolSize = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "mempool_size",
+		Help:      "Transactions waiting to be packaged into a block.",
+	})
+
+	BlockSize = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "block_transactions",
+		Help:      "Transactions in each packaged block.",
+		Buckets:   prometheus.ExponentialBuckets(1, 2, 12),
+	})
+
+	PackageLatency = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "block_package_seconds",
+		Help:      "Time to package, save and queue a block.",
+		Buckets:   prometheus.DefBuckets,
+	})
+
+	TxAccepted = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "transactions_accepted_total",
+		Help:      "Transactions packaged into blocks.",
+	})
+
+	TxRejected = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "transactions_rejected_total",
+		Help:      "Transactions rejected, by reason.",
+	}, []string{"reason"})
+
+	EventLag = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "event_lag_blocks",
+		Help:      "Ethereum blocks between the head and a contract event when it is delivered, by event.",
+		Buckets:   []float64{0, 1, 2, 5, 10, 20, 50, 100, 500},
+	}, []string{"event"})
+
+	SubmissionLatency = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "eth_submission_seconds",
+		Help:      "Time from sending an Ethereum transaction to its confirmation, by submission.",
+		Buckets:   prometheus.ExponentialBuckets(5, 2, 10),
+	}, []string{"submission"})
+
+	GasUsed = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "eth_gas_used_total",
+		Help:      "Gas used by confirmed Ethereum transactions, by submission.",
+	}, []string{"submission"})
+
+	BlockHeight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "block_height",
+		Help:      "Latest block packaged by the root node or synced by a validator.",
+	})
+
+	ContractBlockHeight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "contract_block_height",
+		Help:      "Latest child block on the plasma contract.",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(
+		MempoolSize,
+		BlockSize,
+		PackageLatency,
+		TxAccepted,
+		TxRejected,
+		EventLag,
+		SubmissionLatency,
+		GasUsed,
+		BlockHeight,
+		ContractBlockHeight,
+	)
+}
+
+// Handler serves the metrics in the Prometheus text format.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
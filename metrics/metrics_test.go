@@ -0,0 +1,23 @@
+package metrics
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Handler(t *testing.T) {
+	MempoolSize.Set(3)
+	TxRejected.WithLabelValues(RejectDoubleSpend).Add(2)
+
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	require.Equal(t, 200, rec.Code)
+
+	body, err := ioutil.ReadAll(rec.Body)
+	require.NoError(t, err)
+	require.Contains(t, string(body), "plasma_mempool_size 3")
+	require.Contains(t, string(body), `plasma_transactions_rejected_total{reason="double_spend"} 2`)
+}
@@ -0,0 +1,253 @@
+package node
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/kyokan/plasma/chain"
+	"github.com/kyokan/plasma/db"
+	"github.com/kyokan/plasma/eth"
+	"github.com/kyokan/plasma/util"
+)
+
+// BlockSubmitter submits blocks to the plasma contract one at a time, in
+// block order, and records the status of every submission in the BlockDao.
+// Failed submissions are retried before any later block is submitted, since
+// the contract numbers blocks in the order they arrive.
+type BlockSubmitter struct {
+	mtx           sync.Mutex
+	db            *db.Database
+	plasma        *eth.PlasmaClient
+	maxLag        int
+	retryInterval time.Duration
+	inFlight      map[uint64]*eth.Submission
+	wake          chan struct{}
+}
+
+func NewBlockSubmitter(
+	db *db.Database,
+	plasma *eth.PlasmaClient,
+	maxLag int,
+	retryInterval time.Duration,
+) *BlockSubmitter {
+	if maxLag < 1 {
+		maxLag = 1
+	}
+
+	return &BlockSubmitter{
+		db:            db,
+		plasma:        plasma,
+		maxLag:        maxLag,
+		retryInterval: retryInterval,
+		inFlight:      make(map[uint64]*eth.Submission),
+		wake:          make(chan struct{}, 1),
+	}
+}
+
+// Resume picks up Ethereum transactions that were still in flight when the
+// node stopped, so their blocks are not submitted twice.
+func (s *BlockSubmitter) Resume(subs []*eth.Submission) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	for _, sub := range subs {
+		s.inFlight[sub.Nonce()] = sub
+	}
+}
+
+// Enqueue records a block as pending submission.
+func (s *BlockSubmitter) Enqueue(block *chain.Block, root util.Hash) error {
+	err := s.db.BlockDao.SaveSubmission(&chain.BlockSubmission{
+		Number: block.Header.Number,
+		Root:   root,
+		Status: chain.SubmissionPending,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+// RecordDeposit records a deposit block, which the contract created itself.
+func (s *BlockSubmitter) RecordDeposit(block *chain.Block) error {
+	return s.db.BlockDao.SaveSubmission(&chain.BlockSubmission{
+		Number:  block.Header.Number,
+		Root:    block.Header.RLPMerkleRoot,
+		Status:  chain.SubmissionMined,
+		Deposit: true,
+	})
+}
+
+// CanPackage reports whether the block numbered next may be packaged. Block
+// production pauses while maxLag blocks are waiting to be mined, and while
+// the contract's next child block is not the one that follows them.
+func (s *BlockSubmitter) CanPackage(next uint64) bool {
+	unmined, err := s.db.BlockDao.UnminedSubmissions()
+
+	if err != nil {
+		log.Printf("Failed to get unmined submissions: %v", err)
+		return false
+	}
+
+	if len(unmined) >= s.maxLag {
+		log.Printf("Waiting to package block %d, %d blocks are waiting to be mined.", next, len(unmined))
+		return false
+	}
+
+	current, err := s.plasma.CurrentChildBlock(context.Background())
+
+	if err != nil {
+		log.Printf("Failed to get current child block: %v", err)
+		return false
+	}
+
+	if current.Uint64()+uint64(len(unmined))*chain.ChildBlockInterval != next {
+		log.Printf("Waiting to package block %d, the contract is at child block %d with %d blocks waiting to be mined.",
+			next, current.Uint64(), len(unmined))
+		return false
+	}
+
+	return true
+}
+
+func (s *BlockSubmitter) Start() {
+	tick := time.NewTicker(s.retryInterval)
+	defer tick.Stop()
+
+	for {
+		if !s.submitNext() {
+			select {
+			case <-s.wake:
+			case <-tick.C:
+			}
+		}
+	}
+}
+
+// submitNext advances the submission of the lowest unmined block. It returns
+// true when it should be called again right away.
+func (s *BlockSubmitter) submitNext() bool {
+	unmined, err := s.db.BlockDao.UnminedSubmissions()
+
+	if err != nil {
+		log.Printf("Failed to get unmined submissions: %v", err)
+		return false
+	}
+
+	if len(unmined) == 0 {
+		return false
+	}
+
+	sub := unmined[0]
+
+	s.mtx.Lock()
+	ethSub := s.inFlight[sub.Nonce]
+	s.mtx.Unlock()
+
+	if sub.Status == chain.SubmissionPending && sub.Attempts > 0 && ethSub != nil {
+		return s.await(&sub, ethSub)
+	}
+
+	// The block may have been committed by a transaction that was lost
+	// before its status was recorded.
+	onChain, err := s.plasma.GetBlock(context.Background(), util.NewUint64(sub.Number))
+
+	if err != nil && !eth.IsNotFound(err) {
+		log.Printf("Failed to get child block %d: %v", sub.Number, err)
+		return false
+	}
+
+	if err == nil && bytes.Equal(onChain.Root, sub.Root) {
+		sub.Status = chain.SubmissionMined
+		sub.Error = ""
+		s.save(&sub)
+		return true
+	}
+
+	current, err := s.plasma.CurrentChildBlock(context.Background())
+
+	if err != nil {
+		log.Printf("Failed to get current child block: %v", err)
+		return false
+	}
+
+	if current.Uint64() != sub.Number {
+		s.fail(&sub, fmt.Errorf("the contract is at child block %d", current.Uint64()))
+		return false
+	}
+
+	// The contract commits to the block's token root as well.
+	block, err := s.db.BlockDao.BlockAtHeight(sub.Number)
+
+	if err != nil {
+		log.Printf("Failed to get block %d: %v", sub.Number, err)
+		return false
+	}
+
+	ethSub, err = s.plasma.SubmitBlock(context.Background(), sub.Root, block.Header.TokenRoot)
+	sub.Attempts++
+
+	if err != nil {
+		s.fail(&sub, err)
+		return false
+	}
+
+	s.mtx.Lock()
+	s.inFlight[ethSub.Nonce()] = ethSub
+	s.mtx.Unlock()
+
+	sub.Status = chain.SubmissionPending
+	sub.TxHash = ethSub.Hash()
+	sub.Nonce = ethSub.Nonce()
+	sub.Error = ""
+	s.save(&sub)
+
+	return s.await(&sub, ethSub)
+}
+
+func (s *BlockSubmitter) await(sub *chain.BlockSubmission, ethSub *eth.Submission) bool {
+	receipt, err := ethSub.Wait(context.Background())
+
+	s.mtx.Lock()
+	delete(s.inFlight, sub.Nonce)
+	s.mtx.Unlock()
+
+	if err != nil {
+		s.fail(sub, err)
+		return false
+	}
+
+	log.Printf("Block %d is mined in 0x%x.", sub.Number, receipt.TxHash)
+
+	sub.Status = chain.SubmissionMined
+	sub.TxHash = receipt.TxHash
+	sub.Error = ""
+	s.save(sub)
+	return true
+}
+
+func (s *BlockSubmitter) fail(sub *chain.BlockSubmission, err error) {
+	log.Printf("Submission of block %d failed after %d attempts, retrying in %s: %v",
+		sub.Number, sub.Attempts, s.retryInterval, err)
+
+	sub.Status = chain.SubmissionFailed
+	sub.Error = err.Error()
+	s.save(sub)
+}
+
+func (s *BlockSubmitter) save(sub *chain.BlockSubmission) {
+	if err := s.db.BlockDao.SaveSubmission(sub); err != nil {
+		log.Printf("Failed to save submission status of block %d: %v", sub.Number, err)
+	}
+}
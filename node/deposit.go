@@ -1,51 +1,142 @@
 package node
 
 import (
+	"context"
+	"fmt"
 	"log"
 	"time"
 
+	"github.com/kyokan/plasma/chain"
 	"github.com/kyokan/plasma/db"
 	"github.com/kyokan/plasma/eth"
+	"github.com/kyokan/plasma/util"
 )
 
-func StartDepositListener(level *db.Database, sink *TransactionSink, plasma *eth.PlasmaClient) {
-	ch := make(chan eth.DepositEvent)
-	sink.AcceptDepositEvents(ch)
+// ReconcileDeposits drops deposit credits recorded for blocks that were
+// never saved, e.g. because the node stopped while packaging them. It must
+// run before the node starts producing blocks.
+func ReconcileDeposits(level *db.Database) error {
+	count, err := level.DepositDao.DeleteUnsavedCredits()
 
-	for {
-		idx, err := level.DepositDao.LastDepositEventIdx()
+	if err != nil {
+		return err
+	}
+
+	if count > 0 {
+		log.Printf("Dropped %d deposit credits for blocks that were never saved.", count)
+	}
+
+	return nil
+}
+
+// CheckBlockNumbering verifies that local block numbers line up with the
+// contract's child blocks. The contract may only be behind the local chain
+// by blocks waiting to be mined. Deposits are numbered between submitted
+// blocks and do not move it.
+func CheckBlockNumbering(ctx context.Context, level *db.Database, plasma *eth.PlasmaClient) error {
+	latest, err := level.BlockDao.Latest()
+
+	if err != nil {
+		return err
+	}
+
+	current, err := plasma.CurrentChildBlock(ctx)
 
-		if err != nil && err.Error() != "leveldb: not found" {
-			log.Fatalf("Failed to get last deposit event idx: %v", err)
+	if err != nil {
+		return err
+	}
+
+	// The node creates the genesis block as the contract's first child block.
+	if latest == nil {
+		if current.Uint64() != chain.ChildBlockInterval {
+			return fmt.Errorf("the contract is at child block %d but there are no local blocks", current.Uint64())
 		}
 
-		log.Printf("Looking for deposit events at block number: %d\n", idx)
+		return nil
+	}
 
-		events, lastIdx := plasma.DepositFilter(idx)
+	next := latest.Header.Number + chain.ChildBlockInterval
 
-		if len(events) > 0 {
-			count := uint64(0)
+	// Blocks that are waiting to be mined are resubmitted on startup, and
+	// nothing may have taken their place on the contract.
+	unmined, err := level.BlockDao.UnminedSubmissions()
 
-			for _, event := range events {
-				ch <- eth.DepositEvent{
-					Sender: event.Sender,
-					Value:  event.Value,
-				}
+	if err != nil {
+		return err
+	}
 
-				count++
+	if len(unmined) > 0 {
+		// Submissions that were mined before the node stopped are picked
+		// up by the submitter.
+		if current.Uint64() < unmined[0].Number || current.Uint64() > next {
+			return fmt.Errorf("the contract is at child block %d but block %d is waiting to be mined", current.Uint64(), unmined[0].Number)
+		}
 
-				// TODO: AcceptDepositEvents is not synchronized so sleeps are required.
-				time.Sleep(time.Second * 3)
-			}
+		return nil
+	}
 
-			log.Printf("Found %d deposit events at from blocks %d to %d.\n", count, idx, lastIdx)
+	if current.Uint64() != next {
+		return fmt.Errorf("the contract is at child block %d but block %d exists locally", current.Uint64(), latest.Header.Number)
+	}
 
-			level.DepositDao.SaveDepositEventIdx(lastIdx + 1)
-		} else {
-			log.Printf("No deposit events at block %d.\n", idx)
+	return nil
+}
+
+// checkDepositBlockNum ensures that a deposit is credited in the block the
+// contract created for it. The contract numbers deposits below its next
+// child block, which is at most the block after lastBlock, and a deposit
+// mined while lastBlock waits to be submitted is numbered below lastBlock.
+func checkDepositBlockNum(level *db.Database, lastBlock *chain.Block, deposit *eth.DepositEvent) error {
+	next := chain.NextChildBlock(lastBlock.Header.Number)
+
+	if deposit.Blocknum == nil || !chain.IsDepositBlock(deposit.Blocknum.Uint64()) || deposit.Blocknum.Uint64() > next {
+		return fmt.Errorf("deposit %s:%d belongs to child block %v but the next block is %d",
+			deposit.TxHash.Hex(), deposit.LogIndex, deposit.Blocknum, next)
+	}
+
+	if _, err := level.BlockDao.BlockAtHeight(deposit.Blocknum.Uint64()); err == nil {
+		return fmt.Errorf("deposit %s:%d belongs to child block %d, which already exists",
+			deposit.TxHash.Hex(), deposit.LogIndex, deposit.Blocknum.Uint64())
+	}
+
+	return nil
+}
+
+// StartDepositListener credits deposits as the plasma contract emits them.
+// Deposits are credited in order, so one that cannot be credited is retried
+// before any later deposit.
+func StartDepositListener(level *db.Database, sink *TransactionSink, plasma *eth.PlasmaClient, config eth.WatcherConfig) {
+	watcher := eth.NewEventWatcher(plasma, "deposit", db.DepositCheckpoint(level.DepositDao), config)
+	deposits := watcher.Deposits()
+
+	go func() {
+		if err := watcher.Start(context.Background()); err != nil {
+			log.Fatalf("Failed to watch deposits: %v", err)
+		}
+	}()
+
+	for event := range deposits {
+		deposit := eth.DepositEvent{
+			Sender:      event.Sender,
+			Value:       event.Value,
+			Blocknum:    event.Blocknum,
+			TokenID:     event.TokenId.Uint64(),
+			BlockNumber: event.Raw.BlockNumber,
+			TxHash:      event.Raw.TxHash,
+			LogIndex:    event.Raw.Index,
 		}
 
-		// Every 10 seconds look for deposits
-		time.Sleep(time.Second * 10)
+		for {
+			blkNum, err := sink.CreditDeposit(deposit)
+
+			if err == nil {
+				log.Printf("Deposit %s:%d of %s wei from %s is credited in block %d.",
+					deposit.TxHash.Hex(), deposit.LogIndex, deposit.Value, util.AddressToHex(&deposit.Sender), blkNum)
+				break
+			}
+
+			log.Printf("Failed to credit deposit %s:%d, retrying: %v", deposit.TxHash.Hex(), deposit.LogIndex, err)
+			time.Sleep(10 * time.Second)
+		}
 	}
 }
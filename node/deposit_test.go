@@ -0,0 +1,99 @@
+package node
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/kyokan/plasma/chain"
+	"github.com/kyokan/plasma/db"
+	"github.com/kyokan/plasma/eth"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_DepositBetweenPackagingAndMining(t *testing.T) {
+	dir, err := ioutil.TempDir("", "plasma-node")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	level, database, err := db.CreateLevelDatabase(dir)
+	require.NoError(t, err)
+	defer level.Close()
+
+	notifier := NewNotifier()
+	sink := NewTransactionSink(database, notifier, chain.Domain{ChainID: big.NewInt(1)})
+	node := NewPlasmaNode(database, sink, nil, notifier, NewBlockSubmitter(database, nil, 1, time.Second))
+
+	alice := common.Address{1}
+	genesis := node.createGenesisBlock()
+	require.Equal(t, uint64(chain.ChildBlockInterval), genesis.Header.Number)
+	require.NoError(t, database.BlockDao.SaveSubmission(&chain.BlockSubmission{
+		Number: genesis.Header.Number,
+		Root:   genesis.Header.RLPMerkleRoot,
+		Status: chain.SubmissionMined,
+	}))
+
+	// Block 2000 is packaged, and waits to be mined.
+	payment := *chain.NewTransaction(
+		[]*chain.Input{chain.NewInput(1, 0, 0)},
+		[]*chain.Output{chain.NewOutput(alice, big.NewInt(10))},
+		big.NewInt(0),
+	)
+	packaged := node.packageBlock(*genesis, []chain.Transaction{payment}, nil)
+	require.Equal(t, uint64(2*chain.ChildBlockInterval), packaged.Header.Number)
+
+	go node.awaitTxs(packaged, time.Hour)
+
+	// A deposit mined before block 2000 is numbered after block 1000.
+	blkNum, err := sink.CreditDeposit(eth.DepositEvent{
+		Sender:   alice,
+		Value:    big.NewInt(100),
+		Blocknum: big.NewInt(chain.ChildBlockInterval + 1),
+		TxHash:   common.Hash{1},
+	})
+	require.NoError(t, err)
+	require.Equal(t, uint64(chain.ChildBlockInterval+1), blkNum)
+
+	deposit, err := database.BlockDao.BlockAtHeight(blkNum)
+	require.NoError(t, err)
+	require.Equal(t, packaged.BlockHash, deposit.Header.PrevHash)
+
+	depositTx, err := database.TxDao.FindByBlockNumTxIdx(blkNum, 0)
+	require.NoError(t, err)
+	require.True(t, depositTx.IsDeposit())
+	require.Equal(t, alice, depositTx.Outputs[0].NewOwner)
+
+	// Block 2000 keeps its number and stays queued, and the next block
+	// follows it.
+	latest, err := database.BlockDao.Latest()
+	require.NoError(t, err)
+	require.Equal(t, packaged.BlockHash, latest.BlockHash)
+
+	unmined, err := database.BlockDao.UnminedSubmissions()
+	require.NoError(t, err)
+	require.Len(t, unmined, 1)
+	require.Equal(t, packaged.Header.Number, unmined[0].Number)
+
+	sub, err := database.BlockDao.Submission(blkNum)
+	require.NoError(t, err)
+	require.True(t, sub.Deposit)
+
+	// A deposit cannot take the number of a submitted block, or of a block
+	// that exists.
+	for _, num := range []int64{2 * chain.ChildBlockInterval, chain.ChildBlockInterval + 1, 3*chain.ChildBlockInterval + 1} {
+		_, err = sink.CreditDeposit(eth.DepositEvent{
+			Sender:   alice,
+			Value:    big.NewInt(100),
+			Blocknum: big.NewInt(num),
+			TxHash:   common.BigToHash(big.NewInt(num)),
+		})
+		require.Error(t, err)
+	}
+
+	latest, err = database.BlockDao.Latest()
+	require.NoError(t, err)
+	require.Equal(t, packaged.BlockHash, latest.BlockHash)
+}
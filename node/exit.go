@@ -0,0 +1,32 @@
+package node
+
+import (
+	"context"
+	"log"
+
+	"github.com/kyokan/plasma/db"
+	"github.com/kyokan/plasma/eth"
+)
+
+// StartExitListener watches the plasma contract for started exits and
+// forwards them to exit subscribers.
+func StartExitListener(level *db.Database, plasma *eth.PlasmaClient, notifier *Notifier, config eth.WatcherConfig) {
+	watcher := eth.NewEventWatcher(plasma, "exit", db.ExitCheckpoint(level.ExitDao), config)
+	exits := watcher.StartedExits()
+
+	go func() {
+		if err := watcher.Start(context.Background()); err != nil {
+			log.Fatalf("Failed to watch exits: %v", err)
+		}
+	}()
+
+	for event := range exits {
+		log.Printf("Exit %s started by %s in block %d.", event.ExitId, event.Sender.Hex(), event.Raw.BlockNumber)
+
+		notifier.PublishExit(ExitEvent{
+			Sender:      event.Sender,
+			ExitId:      event.ExitId,
+			EthBlockNum: event.Raw.BlockNumber,
+		})
+	}
+}
@@ -7,9 +7,11 @@ import (
 	"math/big"
 	"time"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/kyokan/plasma/chain"
 	"github.com/kyokan/plasma/db"
 	"github.com/kyokan/plasma/eth"
+	"github.com/kyokan/plasma/metrics"
 	"github.com/kyokan/plasma/util"
 )
 
@@ -17,13 +19,22 @@ type PlasmaNode struct {
 	DB           *db.Database
 	TxSink       *TransactionSink
 	PlasmaClient *eth.PlasmaClient
+	Notifier     *Notifier
+	Submitter    *BlockSubmitter
+
+	// Interval at which the mempool is packaged into a block.
+	PackageInterval time.Duration
 }
 
-func NewPlasmaNode(db *db.Database, sink *TransactionSink, plasmaClient *eth.PlasmaClient) *PlasmaNode {
+func NewPlasmaNode(db *db.Database, sink *TransactionSink, plasmaClient *eth.PlasmaClient, notifier *Notifier, submitter *BlockSubmitter) *PlasmaNode {
 	return &PlasmaNode{
 		DB:           db,
 		TxSink:       sink,
 		PlasmaClient: plasmaClient,
+		Notifier:     notifier,
+		Submitter:    submitter,
+
+		PackageInterval: 10 * time.Second,
 	}
 }
 
@@ -40,59 +51,96 @@ func (node *PlasmaNode) Start() {
 		lastBlock = node.createGenesisBlock()
 	}
 
-	blockChan := make(chan *chain.Block)
-	go node.awaitTxs(blockChan, time.Second * 10)
-	blockChan <- lastBlock
+	go node.awaitTxs(lastBlock, node.PackageInterval)
 }
 
-func (node PlasmaNode) awaitTxs(blks chan *chain.Block, interval time.Duration) {
+// awaitTxs packages blocks one at a time, so that every block is numbered
+// after the one before it and block numbers stay aligned with the child
+// block numbers of the plasma contract. lastBlock is the latest block the
+// node packaged itself; deposit blocks take the numbers the contract gave
+// them.
+func (node PlasmaNode) awaitTxs(lastBlock *chain.Block, interval time.Duration) {
 	log.Print("Awaiting transactions.")
 
-	var lastBlock *chain.Block
 	var mempool []chain.Transaction
 	tick := time.NewTicker(interval)
 
 	for {
 		select {
 		case tx := <-node.TxSink.c:
-			if tx.IsDeposit() {
-				log.Print("Received deposit transaction. Packaging into block.")
-				// Reset ticker, making sure it won't signal while packaging the block
-				tick.Stop()
-				go node.packageBlock(*lastBlock, []chain.Transaction{tx}, blks)
-				tick = time.NewTicker(interval)
-			} else {
-				log.Print("Received regular transaction. Appending to mempool.")
-				mempool = append(mempool, tx)
+			log.Print("Received regular transaction. Appending to mempool.")
+			mempool = append(mempool, tx)
+			metrics.MempoolSize.Set(float64(len(mempool)))
+		case req := <-node.TxSink.deposits:
+			log.Print("Received deposit transaction. Packaging into block.")
+
+			if err := checkDepositBlockNum(node.DB, lastBlock, &req.Event); err != nil {
+				log.Printf("Refusing to credit deposit: %v", err)
+				req.Response <- DepositResponse{Error: err}
+				continue
 			}
-		case block := <-blks:
-			lastBlock = block
+
+			node.packageBlock(*lastBlock, []chain.Transaction{req.Transaction}, &req)
 		case <-tick.C:
-			buffer := make([]chain.Transaction, len(mempool))
-			copy(buffer, mempool)
-			go node.packageBlock(*lastBlock, buffer, blks)
+			if len(mempool) > 0 && !node.Submitter.CanPackage(lastBlock.Header.Number+chain.ChildBlockInterval) {
+				// Keep the mempool until submissions catch up.
+				continue
+			}
+
+			if block := node.packageBlock(*lastBlock, mempool, nil); block != nil {
+				lastBlock = block
+			}
+
 			mempool = nil
+			metrics.MempoolSize.Set(0)
 		}
 	}
 }
 
-func (node PlasmaNode) packageBlock(lastBlock chain.Block, txs []chain.Transaction, blockChan chan<- *chain.Block) {
+// packageBlock builds, saves and submits the block following lastBlock, and
+// returns it. When the block credits a deposit, it is numbered as the
+// contract numbered the deposit, the credit is recorded before the block is
+// saved and the request is answered once the block is saved. Deposit blocks
+// are not submitted, the contract creates them itself. Other blocks are
+// queued for submission.
+func (node PlasmaNode) packageBlock(lastBlock chain.Block, txs []chain.Transaction, deposit *DepositRequest) *chain.Block {
 	if len(txs) == 0 {
 		// Skip for now because it makes logs noisy
 		log.Println("Skipping package blocks because there are no transactions.")
-		return
+		return nil
 	}
 
-	blkNum := lastBlock.Header.Number + 1
+	start := time.Now()
+	blkNum := lastBlock.Header.Number + chain.ChildBlockInterval
+
+	if deposit != nil {
+		blkNum = deposit.Event.Blocknum.Uint64()
+	}
 
 	log.Printf("Packaging block %d containing %d transactions.", blkNum, len(txs))
 
 	accepted, rejected := EnsureNoDoubleSpend(txs)
+	doubleSpends := len(rejected)
+
+	// Each transaction transitions the block's state as its type.
+	accepted, failed := node.TxSink.registry.ApplyBlock(blkNum, accepted)
+	rejected = append(rejected, failed...)
 
 	hashables := make([]util.Hashable, len(accepted))
 
-	log.Printf("Accepted %d of %d transactions. %d rejected due to double spend.",
-		len(accepted), len(txs), len(rejected))
+	log.Printf("Accepted %d of %d transactions. %d rejected due to double spend, %d by their type.",
+		len(accepted), len(txs), doubleSpends, len(failed))
+	metrics.TxRejected.WithLabelValues(metrics.RejectDoubleSpend).Add(float64(doubleSpends))
+	metrics.TxRejected.WithLabelValues(metrics.RejectTransition).Add(float64(len(failed)))
+
+	if deposit != nil {
+		err := node.DB.DepositDao.SaveCredit(deposit.Event.TxHash, deposit.Event.LogIndex, blkNum)
+
+		if err != nil {
+			deposit.Response <- DepositResponse{Error: err}
+			return nil
+		}
+	}
 
 	for i := range accepted {
 		txPtr := &accepted[i]
@@ -115,38 +163,64 @@ func (node PlasmaNode) packageBlock(lastBlock chain.Block, txs []chain.Transacti
 		Number:        blkNum,
 	}
 
+	// Blocks that send tokens commit to them in a token tree.
+	header.TokenRoot = chain.TokenRoot(accepted)
+
+	if header.TokenRoot != nil {
+		if err := node.DB.TokenDao.SaveTransfers(blkNum, accepted); err != nil {
+			log.Printf("Failed to index tokens of block %d: %v", blkNum, err)
+		}
+	}
+
 	block := chain.Block{
 		Header:    &header,
 		BlockHash: header.Hash(),
 	}
 
-	node.DB.BlockDao.Save(&block)
+	if err := node.DB.BlockDao.Save(&block); err != nil {
+		log.Printf("Failed to save block %d: %v", blkNum, err)
+
+		if deposit != nil {
+			deposit.Response <- DepositResponse{Error: err}
+		}
+
+		return nil
+	}
+
+	metrics.PackageLatency.Observe(time.Since(start).Seconds())
+	metrics.BlockSize.Observe(float64(len(accepted)))
+	metrics.TxAccepted.Add(float64(len(accepted)))
+
+	node.Notifier.PublishBlock(&block, accepted, rejected, node.inputOwner)
+
+	if deposit != nil {
+		if err := node.Submitter.RecordDeposit(&block); err != nil {
+			log.Printf("Failed to record deposit block %d: %v", blkNum, err)
+		}
 
-	blockChan <- &block
+		deposit.Response <- DepositResponse{BlkNum: blkNum}
+		return &block
+	}
+
+	metrics.BlockHeight.Set(float64(blkNum))
 
-	if len(accepted) == 1 && accepted[0].IsDeposit() {
-		// Skip reporting block if this is a deposit, because
-		// the plasma contract already creates a plasma block on deposit
-		// Submitting again here would submit duplicate deposit blocks.
-		return
+	if err := node.Submitter.Enqueue(&block, rlpMerkle.Root.Hash); err != nil {
+		log.Printf("Failed to queue block %d for submission: %v", blkNum, err)
 	}
 
-	node.PlasmaClient.SubmitBlock(rlpMerkle)
+	return &block
 }
 
 func (node *PlasmaNode) createGenesisBlock() *chain.Block {
 	log.Println("Creating genesis block.")
 
-	blkNum := 1
+	blkNum := chain.ChildBlockInterval
 
 	txs := []chain.Transaction{
 		chain.Transaction{
-			Input0:  chain.ZeroInput(),
-			Input1:  chain.ZeroInput(),
-			Sig0:    []byte{},
-			Sig1:    []byte{},
-			Output0: chain.ZeroOutput(),
-			Output1: chain.ZeroOutput(),
+			Inputs:  []*chain.Input{chain.ZeroInput(), chain.ZeroInput()},
+			Sigs:    [][]byte{{}, {}},
+			Outputs: []*chain.Output{chain.ZeroOutput(), chain.ZeroOutput()},
 			Fee:     new(big.Int),
 			BlkNum:  uint64(blkNum),
 			TxIdx:   0,
@@ -189,11 +263,23 @@ func (node *PlasmaNode) createGenesisBlock() *chain.Block {
 	fmt.Printf("merkle hash: %s\n", hex.EncodeToString(rlpMerkle.Root.Hash))
 
 	// Report genesis block to plasma
-	node.PlasmaClient.SubmitBlock(rlpMerkle)
+	if err := node.Submitter.Enqueue(&block, rlpMerkle.Root.Hash); err != nil {
+		log.Fatalf("Failed to queue genesis block for submission: %v", err)
+	}
 
 	return &block
 }
 
+func (node *PlasmaNode) inputOwner(input *chain.Input) *common.Address {
+	prevTx, err := node.DB.TxDao.FindByBlockNumTxIdx(input.BlkNum, input.TxIdx)
+
+	if err != nil || prevTx == nil || prevTx.OutputAt(input.OutIdx) == nil {
+		return nil
+	}
+
+	return &prevTx.OutputAt(input.OutIdx).NewOwner
+}
+
 func rlpMerkleTree(accepted []chain.Transaction) util.MerkleTree {
 	hashables := make([]util.RLPHashable, len(accepted))
 
@@ -0,0 +1,238 @@
+package node
+
+import (
+	"log"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/kyokan/plasma/chain"
+	"github.com/kyokan/plasma/util"
+)
+
+const (
+	TopicNewBlocks       = "newBlocks"
+	TopicAddressActivity = "addressActivity"
+	TopicTxStatus        = "txStatus"
+	TopicExits           = "exits"
+)
+
+const (
+	TxStatusPending  = "pending"
+	TxStatusMined    = "mined"
+	TxStatusRejected = "rejected"
+)
+
+// Subscribers that fall this far behind start dropping events rather than
+// stalling block production.
+const subscriptionBufferSize = 256
+
+type Event struct {
+	Topic string
+	Data  interface{}
+}
+
+type BlockEvent struct {
+	Block        *chain.Block        `json:"Block"`
+	Transactions []chain.Transaction `json:"Transactions"`
+}
+
+type AddressActivityEvent struct {
+	Address     common.Address     `json:"Address"`
+	BlkNum      uint64             `json:"BlkNum"`
+	Transaction *chain.Transaction `json:"Transaction"`
+}
+
+type TxStatusEvent struct {
+	Hash   util.Hash `json:"Hash"`
+	Status string    `json:"Status"`
+	BlkNum uint64    `json:"BlkNum"`
+	TxIdx  uint32    `json:"TxIdx"`
+	Reason string    `json:"Reason,omitempty"`
+}
+
+type ExitEvent struct {
+	Sender      common.Address `json:"Sender"`
+	ExitId      *big.Int       `json:"ExitId"`
+	EthBlockNum uint64         `json:"EthBlockNum"`
+}
+
+type Subscription struct {
+	ID     uint64
+	C      <-chan Event
+	c      chan Event
+	filter func(Event) bool
+}
+
+// Notifier fans events from block production and the transaction sink out
+// to subscribers. Publishing never blocks; slow subscribers miss events.
+type Notifier struct {
+	mtx    sync.RWMutex
+	nextID uint64
+	subs   map[uint64]*Subscription
+}
+
+func NewNotifier() *Notifier {
+	return &Notifier{subs: make(map[uint64]*Subscription)}
+}
+
+// Subscribe registers a subscriber for topic. Filter may be nil, in which
+// case every event on the topic is delivered.
+func (n *Notifier) Subscribe(topic string, filter func(Event) bool) *Subscription {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+
+	n.nextID++
+	c := make(chan Event, subscriptionBufferSize)
+	sub := &Subscription{
+		ID: n.nextID,
+		C:  c,
+		c:  c,
+		filter: func(e Event) bool {
+			if e.Topic != topic {
+				return false
+			}
+
+			return filter == nil || filter(e)
+		},
+	}
+	n.subs[sub.ID] = sub
+	return sub
+}
+
+func (n *Notifier) Unsubscribe(id uint64) {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+
+	sub, exists := n.subs[id]
+
+	if !exists {
+		return
+	}
+
+	delete(n.subs, id)
+	close(sub.c)
+}
+
+func (n *Notifier) Publish(e Event) {
+	if n == nil {
+		return
+	}
+
+	n.mtx.RLock()
+	defer n.mtx.RUnlock()
+
+	for _, sub := range n.subs {
+		if !sub.filter(e) {
+			continue
+		}
+
+		select {
+		case sub.c <- e:
+		default:
+			log.Printf("Subscription %d is full, dropping %s event.", sub.ID, e.Topic)
+		}
+	}
+}
+
+// PublishBlock emits the newBlocks, txStatus and addressActivity events for
+// a freshly saved block. Owners maps each spent input to the address that
+// owned it, so spenders are notified along with recipients.
+func (n *Notifier) PublishBlock(block *chain.Block, txs []chain.Transaction, rejected []chain.Transaction, owners func(*chain.Input) *common.Address) {
+	if n == nil {
+		return
+	}
+
+	n.Publish(Event{
+		Topic: TopicNewBlocks,
+		Data:  BlockEvent{Block: block, Transactions: txs},
+	})
+
+	for i := range txs {
+		tx := &txs[i]
+
+		n.Publish(Event{
+			Topic: TopicTxStatus,
+			Data: TxStatusEvent{
+				Hash:   tx.StructHash(),
+				Status: TxStatusMined,
+				BlkNum: tx.BlkNum,
+				TxIdx:  tx.TxIdx,
+			},
+		})
+
+		seen := make(map[common.Address]bool)
+
+		for _, addr := range txAddresses(tx, owners) {
+			if seen[addr] {
+				continue
+			}
+
+			seen[addr] = true
+
+			n.Publish(Event{
+				Topic: TopicAddressActivity,
+				Data: AddressActivityEvent{
+					Address:     addr,
+					BlkNum:      block.Header.Number,
+					Transaction: tx,
+				},
+			})
+		}
+	}
+
+	for i := range rejected {
+		n.PublishTxStatus(&rejected[i], TxStatusRejected, "double spend")
+	}
+}
+
+func (n *Notifier) PublishTxStatus(tx *chain.Transaction, status string, reason string) {
+	if n == nil {
+		return
+	}
+
+	n.Publish(Event{
+		Topic: TopicTxStatus,
+		Data: TxStatusEvent{
+			Hash:   tx.StructHash(),
+			Status: status,
+			BlkNum: tx.BlkNum,
+			TxIdx:  tx.TxIdx,
+			Reason: reason,
+		},
+	})
+}
+
+func (n *Notifier) PublishExit(e ExitEvent) {
+	if n == nil {
+		return
+	}
+
+	n.Publish(Event{Topic: TopicExits, Data: e})
+}
+
+func txAddresses(tx *chain.Transaction, owners func(*chain.Input) *common.Address) []common.Address {
+	var addrs []common.Address
+
+	for _, output := range tx.Outputs {
+		if output != nil && !output.IsZeroOutput() {
+			addrs = append(addrs, output.NewOwner)
+		}
+	}
+
+	if owners == nil || tx.IsDeposit() {
+		return addrs
+	}
+
+	for _, input := range tx.Inputs {
+		if input == nil || input.IsZeroInput() {
+			continue
+		}
+
+		if owner := owners(input); owner != nil {
+			addrs = append(addrs, *owner)
+		}
+	}
+
+	return addrs
+}
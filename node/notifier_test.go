@@ -0,0 +1,46 @@
+package node
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/kyokan/plasma/chain"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_NotifierAddressActivity(t *testing.T) {
+	notifier := NewNotifier()
+	alice := common.HexToAddress("0x627306090abab3a6e1400e9345bc60c78a8bef57")
+	bob := common.HexToAddress("0xf17f52151ebef6c7334fad080c5704d77216b732")
+
+	filterFor := func(addr common.Address) func(Event) bool {
+		return func(e Event) bool {
+			return e.Data.(AddressActivityEvent).Address == addr
+		}
+	}
+
+	aliceSub := notifier.Subscribe(TopicAddressActivity, filterFor(alice))
+	bobSub := notifier.Subscribe(TopicAddressActivity, filterFor(bob))
+	blockSub := notifier.Subscribe(TopicNewBlocks, nil)
+
+	tx := *chain.NewTransaction(
+		[]*chain.Input{chain.NewInput(1, 0, 0)},
+		[]*chain.Output{chain.NewOutput(bob, big.NewInt(10))},
+		big.NewInt(0),
+	)
+	tx.BlkNum = 2
+	block := &chain.Block{Header: &chain.BlockHeader{Number: 2}}
+	owners := func(*chain.Input) *common.Address { return &alice }
+
+	notifier.PublishBlock(block, []chain.Transaction{tx}, nil, owners)
+
+	require.Equal(t, block, (<-blockSub.C).Data.(BlockEvent).Block)
+	require.Equal(t, alice, (<-aliceSub.C).Data.(AddressActivityEvent).Address)
+	require.Equal(t, bob, (<-bobSub.C).Data.(AddressActivityEvent).Address)
+	require.Len(t, aliceSub.C, 0)
+
+	notifier.Unsubscribe(aliceSub.ID)
+	_, open := <-aliceSub.C
+	require.False(t, open)
+}
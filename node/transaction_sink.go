@@ -2,29 +2,28 @@ package node
 
 import (
 	"errors"
+	"fmt"
 	"log"
 	"math/big"
 
-	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/kyokan/plasma/chain"
 	"github.com/kyokan/plasma/db"
 	"github.com/kyokan/plasma/eth"
-	"github.com/kyokan/plasma/util"
-	plasma_common "github.com/kyokan/plasma/common"
+	"github.com/kyokan/plasma/metrics"
+	"github.com/kyokan/plasma/txtype"
 )
 
 type TransactionSink struct {
-	c      chan chain.Transaction
-	db     *db.Database
-	client plasma_common.Client
+	c        chan chain.Transaction
+	deposits chan DepositRequest
+	db       *db.Database
+	notifier *Notifier
+	domain   chain.Domain
+	registry *txtype.Registry
 }
 
 type TransactionRequest struct {
 	chain.Transaction
-	From     common.Address
-	To       common.Address
-	Amount   *big.Int
 	Response *TransactionResponse
 }
 
@@ -33,8 +32,33 @@ type TransactionResponse struct {
 	Transaction *chain.Transaction
 }
 
-func NewTransactionSink(db *db.Database, client plasma_common.Client) *TransactionSink {
-	return &TransactionSink{c: make(chan chain.Transaction), db: db, client: client}
+// DepositRequest asks the node to credit a deposit in a block of its own.
+// The node answers on Response once the block has been saved.
+type DepositRequest struct {
+	Event       eth.DepositEvent
+	Transaction chain.Transaction
+	Response    chan DepositResponse
+}
+
+type DepositResponse struct {
+	Error  error
+	BlkNum uint64
+}
+
+func NewTransactionSink(db *db.Database, notifier *Notifier, domain chain.Domain) *TransactionSink {
+	return &TransactionSink{
+		c:        make(chan chain.Transaction),
+		deposits: make(chan DepositRequest),
+		db:       db,
+		notifier: notifier,
+		domain:   domain,
+		registry: txtype.Default(),
+	}
+}
+
+// Domain returns the domain the sink checks signatures for.
+func (sink *TransactionSink) Domain() chain.Domain {
+	return sink.domain
 }
 
 func (sink *TransactionSink) AcceptTransactions(ch <-chan chain.Transaction) {
@@ -46,10 +70,13 @@ func (sink *TransactionSink) AcceptTransactions(ch <-chan chain.Transaction) {
 
 			if !valid || err != nil {
 				log.Printf("Transaction with hash %s is not valid: %s", tx.Hash(), err)
+				metrics.TxRejected.WithLabelValues(metrics.RejectInvalid).Inc()
+				sink.notifier.PublishTxStatus(&tx, TxStatusRejected, fmt.Sprint(err))
 				continue
 			}
 
 			sink.c <- tx
+			sink.notifier.PublishTxStatus(&tx, TxStatusPending, "")
 		}
 	}()
 }
@@ -59,40 +86,37 @@ func (sink *TransactionSink) AcceptTransactionRequests(chch <-chan chan Transact
 		for {
 			ch := <-chch
 			req := <-ch
-			balance, err := sink.db.AddressDao.Balance(&req.From)
 
-			if err != nil {
-				sendErrorResponse(ch, &req, err)
-				return
+			if err := req.Transaction.CheckFormat(); err != nil {
+				metrics.TxRejected.WithLabelValues(metrics.RejectMalformed).Inc()
+				sendErrorResponse(ch, &req, fmt.Errorf("malformed transaction: %v", err))
+				continue
 			}
 
-			if balance.Cmp(req.Amount) <= 0 {
-				sendErrorResponse(ch, &req, errors.New("insufficient funds"))
-				return
+			// UTXO selection and signing happen on the client, the root
+			// node never signs on a user's behalf.
+			if req.Transaction.IsZeroTransaction() {
+				metrics.TxRejected.WithLabelValues(metrics.RejectUnsigned).Inc()
+				sendErrorResponse(ch, &req, errors.New("unsigned sends are not accepted, the transaction must be built and signed by the sender"))
+				continue
 			}
 
-			txs, err := sink.db.AddressDao.SpendableTxs(&req.From)
+			tx := req.Transaction
+			valid, err := sink.VerifyTransaction(&tx)
 
-			if err != nil {
-				sendErrorResponse(ch, &req, errors.New("insufficient funds"))
-				return
-			}
-			var tx *chain.Transaction
-			if req.Transaction.IsZeroTransaction() {
-				tx, err = chain.FindBestUTXOs(req.From, req.To, req.Amount, txs, sink.client)
-
-				if err != nil {
-					sendErrorResponse(ch, &req, err)
-					return
-				}
-			} else {
-				tx = &req.Transaction
+			if !valid || err != nil {
+				log.Printf("Transaction with hash %s is not valid: %s", tx.Hash(), err)
+				metrics.TxRejected.WithLabelValues(metrics.RejectInvalid).Inc()
+				sink.notifier.PublishTxStatus(&tx, TxStatusRejected, fmt.Sprint(err))
+				sendErrorResponse(ch, &req, err)
+				continue
 			}
 
-			sink.c <- *tx
+			sink.c <- tx
+			sink.notifier.PublishTxStatus(&tx, TxStatusPending, "")
 
 			req.Response = &TransactionResponse{
-				Transaction: tx,
+				Transaction: &tx,
 			}
 
 			ch <- req
@@ -100,91 +124,114 @@ func (sink *TransactionSink) AcceptTransactionRequests(chch <-chan chan Transact
 	}()
 }
 
-func (sink *TransactionSink) AcceptDepositEvents(ch <-chan eth.DepositEvent) {
-	go func() {
-		for {
-			deposit := <-ch
-
-			tx := chain.Transaction{
-				Input0: chain.ZeroInput(),
-				Input1: chain.ZeroInput(),
-				Output0: &chain.Output{
-					NewOwner: deposit.Sender,
-					Amount:   deposit.Value,
-				},
-				Output1: chain.ZeroOutput(),
-				Fee:     big.NewInt(0),
-			}
-			sink.c <- tx
-		}
-	}()
-}
-
-func (sink *TransactionSink) VerifyTransaction(tx *chain.Transaction) (bool, error) {
-	inputTx1, err := sink.db.TxDao.FindByBlockNumTxIdx(tx.Input0.BlkNum, tx.Input0.TxIdx)
+// CreditDeposit credits a deposit unless it has been credited before, and
+// blocks until the node has saved the block containing it. It returns the
+// number of the block the deposit was credited in.
+func (sink *TransactionSink) CreditDeposit(deposit eth.DepositEvent) (uint64, error) {
+	blkNum, credited, err := sink.db.DepositDao.CreditedBlock(deposit.TxHash, deposit.LogIndex)
 
 	if err != nil {
-		return false, err
+		return 0, err
 	}
 
-	if inputTx1 == nil {
-		return false, errors.New("input 1 not found")
+	if credited {
+		return blkNum, nil
 	}
 
-	inputTx2, err := sink.db.TxDao.FindByBlockNumTxIdx(tx.Input1.BlkNum, tx.Input1.TxIdx)
-
-	if err != nil {
-		return false, err
+	req := DepositRequest{
+		Event:       deposit,
+		Transaction: *depositTransaction(deposit),
+		Response:    make(chan DepositResponse, 1),
 	}
 
-	var prevOutput1 *chain.Output
+	sink.deposits <- req
+	res := <-req.Response
+	return res.BlkNum, res.Error
+}
 
-	if tx.Input0.OutIdx == 0 {
-		prevOutput1 = inputTx1.Output0
-	} else {
-		prevOutput1 = inputTx1.Output1
+// depositTransaction returns the transaction crediting deposit. It must
+// match the transaction the depositor sent to the contract.
+func depositTransaction(deposit eth.DepositEvent) *chain.Transaction {
+	if deposit.TokenID != 0 {
+		return chain.NewTokenDeposit(deposit.Sender, deposit.TokenID)
 	}
 
-	var prevOutput2 *chain.Output
-
-	if tx.Input1.OutIdx == 0 {
-		prevOutput2 = inputTx2.Output0
-	} else {
-		prevOutput2 = inputTx2.Output1
-	}
+	return chain.NewTransaction(
+		nil,
+		[]*chain.Output{{NewOwner: deposit.Sender, Amount: deposit.Value}},
+		big.NewInt(0),
+	)
+}
 
-	totalInput := big.NewInt(0).Add(prevOutput1.Amount, prevOutput2.Amount)
-	totalOutput := big.NewInt(0).Add(tx.Output0.Amount, tx.Output1.Amount)
-	totalOutput = totalOutput.Add(totalOutput, tx.Fee)
+// VerifyTransaction checks that the inputs of tx exist and validates tx as
+// its registered type.
+func (sink *TransactionSink) VerifyTransaction(tx *chain.Transaction) (bool, error) {
+	height, err := sink.height()
 
-	if totalInput.Cmp(totalOutput) != 0 {
-		return false, errors.New("inputs and outputs do not have the same sum")
+	if err != nil {
+		return false, err
 	}
 
-	sig1Bytes, err := crypto.Ecrecover(tx.SignatureHash(), tx.Sig0)
+	spends, err := FindSpends(sink.db.TxDao, tx)
 
 	if err != nil {
 		return false, err
 	}
 
-	sig2Bytes, err := crypto.Ecrecover(tx.SignatureHash(), tx.Sig1)
+	ctx := &txtype.Context{
+		Height: height,
+		Domain: sink.domain,
+		Spends: spends,
+	}
 
-	if err != nil {
+	if err := sink.registry.Validate(ctx, tx); err != nil {
 		return false, err
 	}
 
-	sig1Addr := common.BytesToAddress(sig1Bytes)
-	sig2Addr := common.BytesToAddress(sig2Bytes)
+	return true, nil
+}
 
-	if !util.AddressesEqual(&prevOutput1.NewOwner, &sig1Addr) {
-		return false, errors.New("input 1 signature is not valid")
+// FindSpends returns the outputs spent by the inputs of tx, by input index.
+// Zero inputs after the first spend nothing.
+func FindSpends(txDao db.TransactionDao, tx *chain.Transaction) ([]*chain.Output, error) {
+	if tx.InputAt(0) == nil || tx.InputAt(0).IsZeroInput() {
+		return nil, errors.New("input 0 must not be empty")
 	}
 
-	if !util.AddressesEqual(&prevOutput2.NewOwner, &sig2Addr) {
-		return false, errors.New("input 2 signature is not valid")
+	seen := make(map[chain.Input]bool)
+	spends := make([]*chain.Output, len(tx.Inputs))
+
+	for i, input := range tx.Inputs {
+		if i > 0 && input.IsZeroInput() {
+			continue
+		}
+
+		if seen[*input] {
+			return nil, errors.New("inputs must be distinct")
+		}
+
+		seen[*input] = true
+
+		prevTx, err := txDao.FindByBlockNumTxIdx(input.BlkNum, input.TxIdx)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if prevTx == nil {
+			return nil, fmt.Errorf("input %d not found", i)
+		}
+
+		prevOutput := prevTx.OutputAt(input.OutIdx)
+
+		if prevOutput == nil {
+			return nil, fmt.Errorf("input %d has an invalid output index", i)
+		}
+
+		spends[i] = prevOutput
 	}
 
-	return true, nil
+	return spends, nil
 }
 
 func sendErrorResponse(ch chan<- TransactionRequest, req *TransactionRequest, err error) {
@@ -194,3 +241,19 @@ func sendErrorResponse(ch chan<- TransactionRequest, req *TransactionRequest, er
 
 	ch <- *req
 }
+
+// height returns the number of the latest block. Transactions accepted now
+// are included in a later block, so time locks up to it have passed.
+func (sink *TransactionSink) height() (uint64, error) {
+	latest, err := sink.db.BlockDao.Latest()
+
+	if err != nil {
+		return 0, err
+	}
+
+	if latest == nil {
+		return 0, nil
+	}
+
+	return latest.Header.Number, nil
+}
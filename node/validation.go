@@ -59,8 +59,11 @@ func EnsureNoDoubleSpend(txs []chain.Transaction) (okTxs []chain.Transaction, re
 }
 
 func FindMatchingInputs(tx *chain.Transaction, txs []chain.Transaction) (rejections []chain.Transaction) {
-	usedKey0 := fmt.Sprintf("%d::%d::%d", tx.BlkNum, tx.TxIdx, 0)
-	usedKey1 := fmt.Sprintf("%d::%d::%d", tx.BlkNum, tx.TxIdx, 1)
+	usedKeys := make(map[string]bool)
+
+	for i := range tx.Outputs {
+		usedKeys[fmt.Sprintf("%d::%d::%d", tx.BlkNum, tx.TxIdx, i)] = true
+	}
 
 	var used []chain.Transaction
 
@@ -72,7 +75,7 @@ func FindMatchingInputs(tx *chain.Transaction, txs []chain.Transaction) (rejecti
 		keys := txToKeys(&currTx)
 
 		for _, k := range keys {
-			if k == usedKey0 || k == usedKey1 {
+			if usedKeys[k] {
 				used = append(used, currTx)
 			}
 		}
@@ -87,10 +90,11 @@ func txToKeys(tx *chain.Transaction) []string {
 	}
 
 	keys := make([]string, 2)
-	keys = append(keys, fmt.Sprintf("%d::%d::%d", tx.Input0.BlkNum, tx.Input0.TxIdx, tx.Input0.OutIdx))
 
-	if !tx.Input1.IsZeroInput() {
-		keys = append(keys, fmt.Sprintf("%d::%d::%d", tx.Input1.BlkNum, tx.Input1.TxIdx, tx.Input1.OutIdx))
+	for i, input := range tx.Inputs {
+		if i == 0 || !input.IsZeroInput() {
+			keys = append(keys, fmt.Sprintf("%d::%d::%d", input.BlkNum, input.TxIdx, input.OutIdx))
+		}
 	}
 
 	return keys
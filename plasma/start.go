@@ -1,6 +1,7 @@
 package plasma
 
 import (
+	"context"
 	"encoding/hex"
 	"log"
 
@@ -13,7 +14,6 @@ import (
 )
 
 func Start(c *cli.Context) {
-	nodeURL := c.GlobalString("node-url")
 	dburl := c.GlobalString("db")
 
 	plasma := eth.CreatePlasmaClientCLI(c)
@@ -26,25 +26,48 @@ func Start(c *cli.Context) {
 
 	defer db.Close()
 
-	client, err := eth.NewClient(nodeURL)
+	ethSubs, err := plasma.ResumeSubmissions(level.SubmissionDao)
 
 	if err != nil {
-		log.Panic("Failed to start ETH client: ", err)
+		log.Fatalf("Failed to resume Ethereum submissions: %v", err)
 	}
 
-	sink := node.NewTransactionSink(level, client)
+	if err := node.ReconcileDeposits(level); err != nil {
+		log.Fatalf("Failed to reconcile deposits: %v", err)
+	}
+
+	if err := node.CheckBlockNumbering(context.Background(), level, plasma); err != nil {
+		log.Fatalf("Local blocks do not match the plasma contract: %v", err)
+	}
+
+	notifier := node.NewNotifier()
+
+	domain, err := eth.DomainFromCLI(c)
+
+	if err != nil {
+		log.Fatalf("Failed to determine the signing domain: %v", err)
+	}
+
+	sink := node.NewTransactionSink(level, notifier, domain)
 
-	p := node.NewPlasmaNode(level, sink, plasma)
+	submitter := node.NewBlockSubmitter(level, plasma, c.Int("max-submission-lag"), c.Duration("submission-retry-interval"))
+	submitter.Resume(ethSubs)
+
+	go submitter.Start()
+
+	p := node.NewPlasmaNode(level, sink, plasma, notifier, submitter)
 
 	go p.Start()
 
-	go rpc.Start(c.Int("rpc-port"), level, sink)
+	go rpc.Start(rpc.ConfigFromCLI(c), level, sink, notifier)
+
+	watcherConfig := eth.WatcherConfigFromCLI(c)
 
-	// TODO: ensure that 1 deposit tx is always 1 block
-	go node.StartDepositListener(level, sink, plasma)
+	go node.StartDepositListener(level, sink, plasma, watcherConfig)
 
-	// TODO: add an exit listener to make sure to add an exit transaction to root node.
+	// TODO: add an exit transaction to root node.
 	// Also add an exit block to the plasma contract.
+	go node.StartExitListener(level, plasma, notifier, watcherConfig)
 
 	select {}
 }
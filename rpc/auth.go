@@ -0,0 +1,154 @@
+package rpc
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	encoding_json "encoding/json"
+)
+
+var errInvalidToken = errors.New("invalid token")
+
+// Authenticator guards privileged methods. A request is authorized when it
+// carries one of the configured API keys, either in the X-API-Key header or
+// as a bearer token, or a valid HS256 JWT signed with the configured secret.
+type Authenticator struct {
+	apiKeys    [][]byte
+	jwtSecret  []byte
+	privileged map[string]bool
+}
+
+func NewAuthenticator(apiKeys []string, jwtSecret string, privilegedMethods []string) *Authenticator {
+	auth := &Authenticator{
+		privileged: make(map[string]bool),
+	}
+
+	for _, key := range apiKeys {
+		if key != "" {
+			auth.apiKeys = append(auth.apiKeys, []byte(key))
+		}
+	}
+
+	if jwtSecret != "" {
+		auth.jwtSecret = []byte(jwtSecret)
+	}
+
+	for _, method := range privilegedMethods {
+		auth.privileged[method] = true
+	}
+
+	return auth
+}
+
+// Enabled reports whether any credentials are configured. Without
+// credentials privileged methods are left open, matching the previous
+// behavior of the root node.
+func (a *Authenticator) Enabled() bool {
+	return len(a.apiKeys) > 0 || a.jwtSecret != nil
+}
+
+func (a *Authenticator) Intercept(r *http.Request, method string) *Error {
+	if !a.Enabled() || !a.privileged[method] {
+		return nil
+	}
+
+	if a.authorized(r) {
+		return nil
+	}
+
+	return NewError(CodeUnauthorized, "Unauthorized", method)
+}
+
+func (a *Authenticator) authorized(r *http.Request) bool {
+	token := r.Header.Get("X-API-Key")
+
+	if token == "" {
+		header := r.Header.Get("Authorization")
+
+		if !strings.HasPrefix(header, "Bearer ") {
+			return false
+		}
+
+		token = strings.TrimPrefix(header, "Bearer ")
+	}
+
+	for _, key := range a.apiKeys {
+		if subtle.ConstantTimeCompare(key, []byte(token)) == 1 {
+			return true
+		}
+	}
+
+	if a.jwtSecret != nil {
+		return verifyJWT(token, a.jwtSecret, time.Now()) == nil
+	}
+
+	return false
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+}
+
+type jwtClaims struct {
+	ExpiresAt int64 `json:"exp"`
+	NotBefore int64 `json:"nbf"`
+}
+
+func verifyJWT(token string, secret []byte, now time.Time) error {
+	parts := strings.Split(token, ".")
+
+	if len(parts) != 3 {
+		return errInvalidToken
+	}
+
+	var header jwtHeader
+
+	if err := decodeJWTPart(parts[0], &header); err != nil || header.Alg != "HS256" {
+		return errInvalidToken
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+
+	if err != nil {
+		return errInvalidToken
+	}
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+
+	if !hmac.Equal(sig, mac.Sum(nil)) {
+		return errInvalidToken
+	}
+
+	var claims jwtClaims
+
+	if err := decodeJWTPart(parts[1], &claims); err != nil {
+		return errInvalidToken
+	}
+
+	if claims.ExpiresAt != 0 && now.Unix() >= claims.ExpiresAt {
+		return errors.New("token expired")
+	}
+
+	if claims.NotBefore != 0 && now.Unix() < claims.NotBefore {
+		return errors.New("token not yet valid")
+	}
+
+	return nil
+}
+
+func decodeJWTPart(part string, out interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(part)
+
+	if err != nil {
+		return err
+	}
+
+	return encoding_json.Unmarshal(data, out)
+}
@@ -0,0 +1,70 @@
+package rpc
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func signJWT(claims string, secret string) string {
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+	payload := base64.RawURLEncoding.EncodeToString([]byte(claims))
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(header + "." + payload))
+	return header + "." + payload + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func Test_AuthenticatorAPIKey(t *testing.T) {
+	auth := NewAuthenticator([]string{"secret-key"}, "", DefaultPrivilegedMethods())
+
+	r := httptest.NewRequest("POST", "/rpc", nil)
+	require.Nil(t, auth.Intercept(r, "Block.GetBlock"))
+	require.Equal(t, CodeUnauthorized, auth.Intercept(r, "Transaction.Send").Code)
+
+	r.Header.Set("X-API-Key", "secret-key")
+	require.Nil(t, auth.Intercept(r, "Transaction.Send"))
+
+	r = httptest.NewRequest("POST", "/rpc", nil)
+	r.Header.Set("Authorization", "Bearer wrong-key")
+	require.NotNil(t, auth.Intercept(r, "Transaction.Send"))
+}
+
+func Test_AuthenticatorJWT(t *testing.T) {
+	auth := NewAuthenticator(nil, "jwt-secret", DefaultPrivilegedMethods())
+	exp := time.Now().Add(time.Hour).Unix()
+
+	r := httptest.NewRequest("POST", "/rpc", nil)
+	r.Header.Set("Authorization", "Bearer "+signJWT(`{"exp":`+strconv.FormatInt(exp, 10)+`}`, "jwt-secret"))
+	require.Nil(t, auth.Intercept(r, "Transaction.Send"))
+
+	r.Header.Set("Authorization", "Bearer "+signJWT(`{"exp":1}`, "jwt-secret"))
+	require.NotNil(t, auth.Intercept(r, "Transaction.Send"))
+
+	r.Header.Set("Authorization", "Bearer "+signJWT(`{}`, "other-secret"))
+	require.NotNil(t, auth.Intercept(r, "Transaction.Send"))
+}
+
+func Test_AuthenticatorSwap(t *testing.T) {
+	auth := NewAuthenticator([]string{"secret-key"}, "", DefaultPrivilegedMethods())
+
+	r := httptest.NewRequest("POST", "/rpc", nil)
+	require.Nil(t, auth.Intercept(r, "Swap.Pending"))
+	require.Equal(t, CodeUnauthorized, auth.Intercept(r, "Swap.Propose").Code)
+	require.Equal(t, CodeUnauthorized, auth.Intercept(r, "Swap.Accept").Code)
+
+	r.Header.Set("X-API-Key", "secret-key")
+	require.Nil(t, auth.Intercept(r, "Swap.Propose"))
+	require.Nil(t, auth.Intercept(r, "Swap.Accept"))
+}
+
+func Test_AuthenticatorDisabled(t *testing.T) {
+	auth := NewAuthenticator(nil, "", DefaultPrivilegedMethods())
+	r := httptest.NewRequest("POST", "/rpc", nil)
+	require.Nil(t, auth.Intercept(r, "Transaction.Send"))
+}
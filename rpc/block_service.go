@@ -1,13 +1,16 @@
 package rpc
 
 import (
+	"fmt"
 	"log"
 	"net/http"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/syndtr/goleveldb/leveldb"
 
 	"github.com/kyokan/plasma/chain"
 	"github.com/kyokan/plasma/db"
+	"github.com/kyokan/plasma/util"
 )
 
 type GetBlocksArgs struct {
@@ -27,6 +30,30 @@ type GetUTXOsResponse struct {
 	Transactions []chain.Transaction
 }
 
+type FindByMetadataArgs struct {
+	Metadata []byte
+}
+
+type FindByMetadataResponse struct {
+	Transactions []chain.Transaction
+}
+
+type GetSubmissionArgs struct {
+	Height uint64
+}
+
+type GetSubmissionResponse struct {
+	Submission *chain.BlockSubmission `json:"Submission"`
+}
+
+type GetTokenHistoryArgs struct {
+	TokenID uint64
+}
+
+type GetTokenHistoryResponse struct {
+	Proofs []chain.TokenProof `json:"Proofs"`
+}
+
 type BlockService struct {
 	DB *db.Database
 }
@@ -38,6 +65,10 @@ func (t *BlockService) GetBlock(r *http.Request, args *GetBlocksArgs, reply *Get
 
 	block, err := t.DB.BlockDao.BlockAtHeight(height)
 
+	if err == leveldb.ErrNotFound {
+		return NewError(CodeNotFound, "block not found", map[string]uint64{"height": height})
+	}
+
 	if err != nil {
 		return err
 	}
@@ -73,3 +104,111 @@ func (t *BlockService) GetUTXOs(r *http.Request, args *GetUTXOsArgs, reply *GetU
 
 	return nil
 }
+
+// FindByMetadata returns the transactions whose metadata is exactly
+// args.Metadata.
+func (t *BlockService) FindByMetadata(r *http.Request, args *FindByMetadataArgs, reply *FindByMetadataResponse) error {
+	log.Println("Received Block.FindByMetadata request.")
+
+	if len(args.Metadata) == 0 || len(args.Metadata) > chain.MaxMetadataSize {
+		return NewError(CodeInvalidParams, "Invalid params", fmt.Sprintf("Metadata must be between 1 and %d bytes", chain.MaxMetadataSize))
+	}
+
+	txs, err := t.DB.TxDao.FindByMetadata(args.Metadata)
+
+	if err != nil {
+		return err
+	}
+
+	*reply = FindByMetadataResponse{
+		Transactions: txs,
+	}
+
+	return nil
+}
+
+// GetSubmission returns the status of a block's submission to the plasma
+// contract.
+func (t *BlockService) GetSubmission(r *http.Request, args *GetSubmissionArgs, reply *GetSubmissionResponse) error {
+	log.Println("Received Block.GetSubmission request.")
+
+	sub, err := t.DB.BlockDao.Submission(args.Height)
+
+	if err == leveldb.ErrNotFound {
+		return NewError(CodeNotFound, "submission not found", map[string]uint64{"height": args.Height})
+	}
+
+	if err != nil {
+		return err
+	}
+
+	*reply = GetSubmissionResponse{
+		Submission: sub,
+	}
+
+	return nil
+}
+
+// GetTokenHistory proves what happened to a token in every block that sends
+// tokens, from the token's deposit on. Other blocks have no token root, so
+// they do not send the token.
+func (t *BlockService) GetTokenHistory(r *http.Request, args *GetTokenHistoryArgs, reply *GetTokenHistoryResponse) error {
+	log.Println("Received Block.GetTokenHistory request.")
+
+	flows, err := t.DB.TokenDao.Transfers(args.TokenID)
+
+	if err != nil {
+		return err
+	}
+
+	if len(flows) == 0 {
+		return NewError(CodeNotFound, "token not found", map[string]uint64{"tokenId": args.TokenID})
+	}
+
+	blocks, err := t.DB.TokenDao.TokenBlocks(flows[0].BlkNum)
+
+	if err != nil {
+		return err
+	}
+
+	sent := make(map[uint64]chain.Flow, len(flows))
+
+	for _, flow := range flows {
+		sent[flow.BlkNum] = flow
+	}
+
+	proofs := make([]chain.TokenProof, len(blocks))
+
+	for i, blkNum := range blocks {
+		leaves, err := t.DB.TokenDao.Leaves(blkNum)
+
+		if err != nil {
+			return err
+		}
+
+		proofs[i] = chain.TokenProof{
+			BlkNum: blkNum,
+			Proof:  util.NewSparseMerkleTree(leaves).Proof(args.TokenID),
+		}
+
+		flow, ok := sent[blkNum]
+
+		if !ok {
+			continue
+		}
+
+		tx, err := t.DB.TxDao.FindByBlockNumTxIdx(flow.BlkNum, flow.TxIdx)
+
+		if err != nil {
+			return err
+		}
+
+		proofs[i].Transaction = tx
+	}
+
+	*reply = GetTokenHistoryResponse{
+		Proofs: proofs,
+	}
+
+	return nil
+}
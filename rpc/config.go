@@ -0,0 +1,57 @@
+package rpc
+
+import (
+	"gopkg.in/urfave/cli.v1"
+)
+
+type Config struct {
+	Port int
+
+	// Credentials for privileged methods. Privileged methods are open when
+	// neither API keys nor a JWT secret are configured.
+	APIKeys           []string
+	JWTSecret         string
+	PrivilegedMethods []string
+
+	// Requests per second and burst allowed per client IP, and per sending
+	// address for Transaction.Send. A zero rate disables the limit.
+	IPRateLimit      float64
+	IPRateBurst      int
+	AddressRateLimit float64
+	AddressRateBurst int
+
+	MaxRequestSize int64
+
+	TLSCertFile string
+	TLSKeyFile  string
+}
+
+func ConfigFromCLI(c *cli.Context) Config {
+	config := Config{
+		Port:              c.Int("rpc-port"),
+		APIKeys:           c.StringSlice("rpc-api-keys"),
+		JWTSecret:         c.String("rpc-jwt-secret"),
+		PrivilegedMethods: c.StringSlice("rpc-privileged-methods"),
+		IPRateLimit:       c.Float64("rpc-ip-rate-limit"),
+		IPRateBurst:       c.Int("rpc-ip-rate-burst"),
+		AddressRateLimit:  c.Float64("rpc-address-rate-limit"),
+		AddressRateBurst:  c.Int("rpc-address-rate-burst"),
+		MaxRequestSize:    int64(c.Int("rpc-max-request-size")),
+		TLSCertFile:       c.String("rpc-tls-cert"),
+		TLSKeyFile:        c.String("rpc-tls-key"),
+	}
+
+	if len(config.PrivilegedMethods) == 0 {
+		config.PrivilegedMethods = DefaultPrivilegedMethods()
+	}
+
+	return config
+}
+
+// DefaultPrivilegedMethods are the methods that require credentials when
+// no privileged methods are configured explicitly. Transaction.Send,
+// Swap.Propose and Swap.Accept are the methods that change the root node's
+// state.
+func DefaultPrivilegedMethods() []string {
+	return []string{"Transaction.Send", "Swap.Propose", "Swap.Accept"}
+}
@@ -0,0 +1,391 @@
+package rpc
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"reflect"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	encoding_json "encoding/json"
+)
+
+const JSONRPCVersion = "2.0"
+
+// Standard JSON-RPC 2.0 error codes. Errors returned by services that are
+// not already an *Error are reported as CodeServerError.
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+	CodeServerError    = -32000
+)
+
+// Application error codes, within the range reserved for server errors.
+const (
+	CodeNotFound            = -32001
+	CodeTransactionRejected = -32002
+	CodeUnauthorized        = -32003
+	CodeRateLimited         = -32004
+)
+
+type Error struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+func NewError(code int, message string, data interface{}) *Error {
+	return &Error{Code: code, Message: message, Data: data}
+}
+
+func (e *Error) Error() string {
+	if e.Data == nil {
+		return fmt.Sprintf("%s (code %d)", e.Message, e.Code)
+	}
+
+	return fmt.Sprintf("%s (code %d): %v", e.Message, e.Code, e.Data)
+}
+
+type Request struct {
+	Version string                    `json:"jsonrpc"`
+	Method  string                    `json:"method"`
+	Params  *encoding_json.RawMessage `json:"params,omitempty"`
+	Id      *encoding_json.RawMessage `json:"id,omitempty"`
+}
+
+// UnmarshalJSON keeps an id of null, which encoding/json would decode like
+// a missing one. A request with a null id is answered; only a request
+// without an id is a notification.
+func (r *Request) UnmarshalJSON(data []byte) error {
+	type request Request
+	var fields map[string]encoding_json.RawMessage
+
+	if err := encoding_json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+
+	if err := encoding_json.Unmarshal(data, (*request)(r)); err != nil {
+		return err
+	}
+
+	if id, ok := fields["id"]; ok {
+		r.Id = &id
+	}
+
+	return nil
+}
+
+type Response struct {
+	Version string                    `json:"jsonrpc"`
+	Result  interface{}               `json:"result,omitempty"`
+	Error   *Error                    `json:"error,omitempty"`
+	Id      *encoding_json.RawMessage `json:"id"`
+}
+
+var typeOfError = reflect.TypeOf((*error)(nil)).Elem()
+var typeOfRequest = reflect.TypeOf((*http.Request)(nil))
+
+type serviceMethod struct {
+	method    reflect.Method
+	argsType  reflect.Type
+	replyType reflect.Type
+}
+
+type service struct {
+	rcvr    reflect.Value
+	methods map[string]*serviceMethod
+}
+
+// Server is a JSON-RPC 2.0 server supporting batch requests. Services are
+// registered the same way as with gorilla/rpc: every exported method of the
+// form
+//
+//	func (t *T) Method(r *http.Request, args *Args, reply *Reply) error
+//
+// is exposed as "Name.Method".
+type Server struct {
+	services     map[string]*service
+	interceptors []Interceptor
+
+	// MaxRequestSize limits the size of a request body in bytes. Zero
+	// means no limit.
+	MaxRequestSize int64
+}
+
+// Interceptor is run before every call, including each call of a batch. A
+// non-nil error is returned to the client in place of the call's result.
+type Interceptor interface {
+	Intercept(r *http.Request, method string) *Error
+}
+
+func (s *Server) Use(interceptor Interceptor) {
+	s.interceptors = append(s.interceptors, interceptor)
+}
+
+func NewServer() *Server {
+	return &Server{services: make(map[string]*service)}
+}
+
+func (s *Server) RegisterService(rcvr interface{}, name string) error {
+	svc := &service{
+		rcvr:    reflect.ValueOf(rcvr),
+		methods: make(map[string]*serviceMethod),
+	}
+	rcvrType := reflect.TypeOf(rcvr)
+
+	for i := 0; i < rcvrType.NumMethod(); i++ {
+		method := rcvrType.Method(i)
+		mtype := method.Type
+
+		if method.PkgPath != "" || mtype.NumIn() != 4 || mtype.NumOut() != 1 {
+			continue
+		}
+
+		if mtype.In(1) != typeOfRequest || mtype.Out(0) != typeOfError {
+			continue
+		}
+
+		args := mtype.In(2)
+		reply := mtype.In(3)
+
+		if args.Kind() != reflect.Ptr || !isExportedOrBuiltin(args) ||
+			reply.Kind() != reflect.Ptr || !isExportedOrBuiltin(reply) {
+			continue
+		}
+
+		svc.methods[method.Name] = &serviceMethod{
+			method:    method,
+			argsType:  args.Elem(),
+			replyType: reply.Elem(),
+		}
+	}
+
+	if len(svc.methods) == 0 {
+		return fmt.Errorf("rpc: %q has no exported methods of suitable type", name)
+	}
+
+	s.services[name] = svc
+	return nil
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		w.Header().Set("Allow", "POST")
+		http.Error(w, "rpc: POST method required, received "+r.Method, http.StatusMethodNotAllowed)
+		return
+	}
+
+	if s.MaxRequestSize > 0 {
+		if r.ContentLength > s.MaxRequestSize {
+			http.Error(w, "rpc: request body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		r.Body = http.MaxBytesReader(w, r.Body, s.MaxRequestSize)
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+
+	if err != nil {
+		writeJSON(w, errorResponse(nil, NewError(CodeParseError, "Parse error", err.Error())))
+		return
+	}
+
+	body = bytes.TrimSpace(body)
+
+	if len(body) > 0 && body[0] == '[' {
+		var batch []encoding_json.RawMessage
+
+		if err := encoding_json.Unmarshal(body, &batch); err != nil {
+			writeJSON(w, errorResponse(nil, NewError(CodeParseError, "Parse error", err.Error())))
+			return
+		}
+
+		if len(batch) == 0 {
+			writeJSON(w, errorResponse(nil, NewError(CodeInvalidRequest, "Invalid Request", "empty batch")))
+			return
+		}
+
+		var responses []*Response
+
+		for _, raw := range batch {
+			if res := s.handle(r, raw); res != nil {
+				responses = append(responses, res)
+			}
+		}
+
+		if len(responses) == 0 {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		writeJSON(w, responses)
+		return
+	}
+
+	res := s.handle(r, body)
+
+	if res == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	writeJSON(w, res)
+}
+
+// handle processes a single request. It returns nil for notifications,
+// which must not be answered.
+func (s *Server) handle(r *http.Request, raw []byte) (res *Response) {
+	var req Request
+
+	if err := encoding_json.Unmarshal(raw, &req); err != nil {
+		if _, ok := err.(*encoding_json.SyntaxError); ok {
+			return errorResponse(nil, NewError(CodeParseError, "Parse error", err.Error()))
+		}
+
+		return errorResponse(nil, NewError(CodeInvalidRequest, "Invalid Request", err.Error()))
+	}
+
+	if req.Version != JSONRPCVersion || req.Method == "" {
+		return errorResponse(req.Id, NewError(CodeInvalidRequest, "Invalid Request", nil))
+	}
+
+	defer func() {
+		if rec := recover(); rec != nil {
+			log.Printf("Recovered from panic in %s: %v", req.Method, rec)
+			res = errorResponse(req.Id, NewError(CodeInternalError, "Internal error", fmt.Sprint(rec)))
+		}
+
+		if req.Id == nil {
+			res = nil
+		}
+	}()
+
+	for _, interceptor := range s.interceptors {
+		if rpcErr := interceptor.Intercept(r, req.Method); rpcErr != nil {
+			return errorResponse(req.Id, rpcErr)
+		}
+	}
+
+	svc, method, rpcErr := s.lookup(req.Method)
+
+	if rpcErr != nil {
+		return errorResponse(req.Id, rpcErr)
+	}
+
+	args := reflect.New(method.argsType)
+
+	if err := decodeParams(req.Params, args.Interface()); err != nil {
+		return errorResponse(req.Id, NewError(CodeInvalidParams, "Invalid params", err.Error()))
+	}
+
+	reply := reflect.New(method.replyType)
+	out := method.method.Func.Call([]reflect.Value{svc.rcvr, reflect.ValueOf(r), args, reply})
+
+	if errItf := out[0].Interface(); errItf != nil {
+		return errorResponse(req.Id, toError(errItf.(error)))
+	}
+
+	return &Response{
+		Version: JSONRPCVersion,
+		Result:  reply.Interface(),
+		Id:      req.Id,
+	}
+}
+
+func (s *Server) lookup(method string) (*service, *serviceMethod, *Error) {
+	parts := strings.Split(method, ".")
+
+	if len(parts) != 2 {
+		return nil, nil, NewError(CodeMethodNotFound, "Method not found", method)
+	}
+
+	svc, exists := s.services[parts[0]]
+
+	if !exists {
+		return nil, nil, NewError(CodeMethodNotFound, "Method not found", method)
+	}
+
+	m, exists := svc.methods[parts[1]]
+
+	if !exists {
+		return nil, nil, NewError(CodeMethodNotFound, "Method not found", method)
+	}
+
+	return svc, m, nil
+}
+
+// decodeParams accepts both by-name params and the single-element
+// positional form sent by JSON-RPC 1.0 clients.
+func decodeParams(params *encoding_json.RawMessage, args interface{}) error {
+	if params == nil {
+		return nil
+	}
+
+	raw := bytes.TrimSpace(*params)
+
+	if len(raw) > 0 && raw[0] == '[' {
+		var positional []encoding_json.RawMessage
+
+		if err := encoding_json.Unmarshal(raw, &positional); err != nil {
+			return err
+		}
+
+		if len(positional) == 0 {
+			return nil
+		}
+
+		if len(positional) > 1 {
+			return errors.New("expected a single positional parameter")
+		}
+
+		raw = positional[0]
+	}
+
+	return encoding_json.Unmarshal(raw, args)
+}
+
+func toError(err error) *Error {
+	if rpcErr, ok := err.(*Error); ok {
+		return rpcErr
+	}
+
+	return NewError(CodeServerError, err.Error(), nil)
+}
+
+func errorResponse(id *encoding_json.RawMessage, err *Error) *Response {
+	return &Response{
+		Version: JSONRPCVersion,
+		Error:   err,
+		Id:      id,
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	if err := encoding_json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("Failed to write rpc response: %v", err)
+	}
+}
+
+func isExportedOrBuiltin(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t.PkgPath() == "" {
+		return true
+	}
+
+	r, _ := utf8.DecodeRuneInString(t.Name())
+	return unicode.IsUpper(r)
+}
@@ -0,0 +1,107 @@
+package rpc
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	encoding_json "encoding/json"
+
+	"github.com/stretchr/testify/require"
+)
+
+type EchoArgs struct {
+	Value string
+}
+
+type EchoResponse struct {
+	Value string
+}
+
+type echoService struct{}
+
+func (s *echoService) Echo(r *http.Request, args *EchoArgs, reply *EchoResponse) error {
+	reply.Value = args.Value
+	return nil
+}
+
+func (s *echoService) Fail(r *http.Request, args *EchoArgs, reply *EchoResponse) error {
+	if args.Value == "" {
+		return errors.New("plain failure")
+	}
+
+	return NewError(CodeNotFound, "not found", args.Value)
+}
+
+type testResponse struct {
+	Version string                    `json:"jsonrpc"`
+	Result  *EchoResponse             `json:"result"`
+	Error   *Error                    `json:"error"`
+	Id      *encoding_json.RawMessage `json:"id"`
+}
+
+func serve(t *testing.T, body string) *httptest.ResponseRecorder {
+	s := NewServer()
+	require.NoError(t, s.RegisterService(&echoService{}, "Echo"))
+	req := httptest.NewRequest("POST", "/rpc", bytes.NewBufferString(body))
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	return rec
+}
+
+func Test_JSONRPCSingleRequest(t *testing.T) {
+	rec := serve(t, `{"jsonrpc":"2.0","id":7,"method":"Echo.Echo","params":{"Value":"hi"}}`)
+	var res testResponse
+	require.NoError(t, encoding_json.Unmarshal(rec.Body.Bytes(), &res))
+	require.Equal(t, JSONRPCVersion, res.Version)
+	require.Nil(t, res.Error)
+	require.Equal(t, "hi", res.Result.Value)
+	require.Equal(t, "7", string(*res.Id))
+}
+
+func Test_JSONRPCBatch(t *testing.T) {
+	rec := serve(t, `[
+		{"jsonrpc":"2.0","id":1,"method":"Echo.Echo","params":[{"Value":"a"}]},
+		{"jsonrpc":"2.0","method":"Echo.Echo","params":{"Value":"notification"}},
+		{"jsonrpc":"2.0","id":2,"method":"Echo.Missing"},
+		{"jsonrpc":"2.0","id":3,"method":"Echo.Fail","params":{"Value":"x"}},
+		{"jsonrpc":"2.0","id":4,"method":"Echo.Fail","params":{}},
+		{"id":5,"method":"Echo.Echo"}
+	]`)
+	var res []testResponse
+	require.NoError(t, encoding_json.Unmarshal(rec.Body.Bytes(), &res))
+	require.Len(t, res, 5)
+	require.Equal(t, "a", res[0].Result.Value)
+	require.Equal(t, CodeMethodNotFound, res[1].Error.Code)
+	require.Equal(t, CodeNotFound, res[2].Error.Code)
+	require.Equal(t, "x", res[2].Error.Data)
+	require.Equal(t, CodeServerError, res[3].Error.Code)
+	require.Equal(t, CodeInvalidRequest, res[4].Error.Code)
+}
+
+func Test_JSONRPCNullId(t *testing.T) {
+	rec := serve(t, `{"jsonrpc":"2.0","id":null,"method":"Echo.Echo","params":{"Value":"hi"}}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	var res testResponse
+	require.NoError(t, encoding_json.Unmarshal(rec.Body.Bytes(), &res))
+	require.Equal(t, "hi", res.Result.Value)
+	require.Nil(t, res.Id)
+	require.Contains(t, rec.Body.String(), `"id":null`)
+
+	rec = serve(t, `{"jsonrpc":"2.0","method":"Echo.Echo","params":{"Value":"hi"}}`)
+	require.Equal(t, http.StatusNoContent, rec.Code)
+	require.Empty(t, rec.Body.Bytes())
+}
+
+func Test_JSONRPCParseError(t *testing.T) {
+	rec := serve(t, `{"jsonrpc":"2.0","id":1,`)
+	var res testResponse
+	require.NoError(t, encoding_json.Unmarshal(rec.Body.Bytes(), &res))
+	require.Equal(t, CodeParseError, res.Error.Code)
+
+	rec = serve(t, `[]`)
+	require.NoError(t, encoding_json.Unmarshal(rec.Body.Bytes(), &res))
+	require.Equal(t, CodeInvalidRequest, res.Error.Code)
+}
@@ -0,0 +1,104 @@
+package rpc
+
+import (
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// Limiters that have not been used for this long are discarded.
+const limiterIdleTimeout = 10 * time.Minute
+
+type keyedLimiter struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// RateLimiter keeps a token bucket per key, e.g. per client IP or per
+// sending address. A zero limit disables rate limiting.
+type RateLimiter struct {
+	mtx       sync.Mutex
+	limit     rate.Limit
+	burst     int
+	limiters  map[string]*keyedLimiter
+	lastSweep time.Time
+}
+
+func NewRateLimiter(perSecond float64, burst int) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &RateLimiter{
+		limit:     rate.Limit(perSecond),
+		burst:     burst,
+		limiters:  make(map[string]*keyedLimiter),
+		lastSweep: time.Now(),
+	}
+}
+
+func (l *RateLimiter) Allow(key string) bool {
+	if l == nil || l.limit <= 0 {
+		return true
+	}
+
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	now := time.Now()
+
+	if now.Sub(l.lastSweep) > limiterIdleTimeout {
+		for k, kl := range l.limiters {
+			if now.Sub(kl.lastSeen) > limiterIdleTimeout {
+				delete(l.limiters, k)
+			}
+		}
+
+		l.lastSweep = now
+	}
+
+	kl, exists := l.limiters[key]
+
+	if !exists {
+		kl = &keyedLimiter{limiter: rate.NewLimiter(l.limit, l.burst)}
+		l.limiters[key] = kl
+	}
+
+	kl.lastSeen = now
+	return kl.limiter.AllowN(now, 1)
+}
+
+// Intercept limits every call, including each call of a batch, by client IP.
+func (l *RateLimiter) Intercept(r *http.Request, method string) *Error {
+	if l.Allow(clientIP(r)) {
+		return nil
+	}
+
+	return NewError(CodeRateLimited, "Rate limit exceeded", method)
+}
+
+// Middleware limits plain HTTP requests, such as websocket handshakes, by
+// client IP.
+func (l *RateLimiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !l.Allow(clientIP(r)) {
+			http.Error(w, "rate limit exceeded", http.StatusTooManyRequests)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
@@ -0,0 +1,286 @@
+package rpcclient
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	encoding_json "encoding/json"
+
+	"github.com/kyokan/plasma/chain"
+	plasma_rpc "github.com/kyokan/plasma/rpc"
+)
+
+const defaultTimeout = 30 * time.Second
+
+type response struct {
+	Version string                    `json:"jsonrpc"`
+	Result  *encoding_json.RawMessage `json:"result"`
+	Error   *plasma_rpc.Error         `json:"error"`
+	Id      *encoding_json.RawMessage `json:"id"`
+}
+
+// BatchElem is a single call in a batch. Reply and Error are populated by
+// BatchCall.
+type BatchElem struct {
+	Method string
+	Args   interface{}
+	Reply  interface{}
+	Error  error
+}
+
+// Client is a typed JSON-RPC 2.0 client for the root node and validator
+// servers. Errors returned by the server are surfaced as *plasma_rpc.Error.
+type Client struct {
+	url    string
+	http   *http.Client
+	nextId uint64
+}
+
+func NewClient(url string) *Client {
+	return &Client{
+		url:  url,
+		http: &http.Client{Timeout: defaultTimeout},
+	}
+}
+
+func (c *Client) Call(method string, args interface{}, reply interface{}) error {
+	req, err := c.newRequest(method, args)
+
+	if err != nil {
+		return err
+	}
+
+	var res response
+
+	if err := c.post(req, &res); err != nil {
+		return err
+	}
+
+	return decodeResponse(&res, reply)
+}
+
+func (c *Client) BatchCall(elems []BatchElem) error {
+	if len(elems) == 0 {
+		return nil
+	}
+
+	reqs := make([]plasma_rpc.Request, len(elems))
+	byId := make(map[string]*BatchElem, len(elems))
+
+	for i := range elems {
+		req, err := c.newRequest(elems[i].Method, elems[i].Args)
+
+		if err != nil {
+			return err
+		}
+
+		reqs[i] = req
+		byId[string(*reqs[i].Id)] = &elems[i]
+	}
+
+	var responses []response
+
+	if err := c.post(reqs, &responses); err != nil {
+		return err
+	}
+
+	for i := range responses {
+		res := &responses[i]
+
+		if res.Id == nil {
+			continue
+		}
+
+		elem, exists := byId[string(*res.Id)]
+
+		if !exists {
+			continue
+		}
+
+		elem.Error = decodeResponse(res, elem.Reply)
+		delete(byId, string(*res.Id))
+	}
+
+	for _, elem := range byId {
+		elem.Error = errors.New("no response received")
+	}
+
+	return nil
+}
+
+func (c *Client) GetBlock(height uint64) (*plasma_rpc.GetBlocksResponse, error) {
+	var reply plasma_rpc.GetBlocksResponse
+	err := c.Call("Block.GetBlock", &plasma_rpc.GetBlocksArgs{Height: height}, &reply)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &reply, nil
+}
+
+func (c *Client) GetSubmission(height uint64) (*plasma_rpc.GetSubmissionResponse, error) {
+	var reply plasma_rpc.GetSubmissionResponse
+	err := c.Call("Block.GetSubmission", &plasma_rpc.GetSubmissionArgs{Height: height}, &reply)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &reply, nil
+}
+
+func (c *Client) GetUTXOs(userAddress string) (*plasma_rpc.GetUTXOsResponse, error) {
+	var reply plasma_rpc.GetUTXOsResponse
+	err := c.Call("Block.GetUTXOs", &plasma_rpc.GetUTXOsArgs{UserAddress: userAddress}, &reply)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &reply, nil
+}
+
+func (c *Client) FindByMetadata(metadata []byte) (*plasma_rpc.FindByMetadataResponse, error) {
+	var reply plasma_rpc.FindByMetadataResponse
+	err := c.Call("Block.FindByMetadata", &plasma_rpc.FindByMetadataArgs{Metadata: metadata}, &reply)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &reply, nil
+}
+
+func (c *Client) GetTokenHistory(tokenID uint64) (*plasma_rpc.GetTokenHistoryResponse, error) {
+	var reply plasma_rpc.GetTokenHistoryResponse
+	err := c.Call("Block.GetTokenHistory", &plasma_rpc.GetTokenHistoryArgs{TokenID: tokenID}, &reply)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &reply, nil
+}
+
+func (c *Client) Consolidate(userAddress string, target int) (*plasma_rpc.ConsolidateResponse, error) {
+	var reply plasma_rpc.ConsolidateResponse
+	err := c.Call("Wallet.Consolidate", &plasma_rpc.ConsolidateArgs{UserAddress: userAddress, Target: target}, &reply)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &reply, nil
+}
+
+func (c *Client) Send(args *plasma_rpc.SendArgs) (*plasma_rpc.SendResponse, error) {
+	var reply plasma_rpc.SendResponse
+	err := c.Call("Transaction.Send", args, &reply)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &reply, nil
+}
+
+func (c *Client) ProposeSwap(swap *chain.Swap) (*plasma_rpc.ProposeSwapResponse, error) {
+	var reply plasma_rpc.ProposeSwapResponse
+	err := c.Call("Swap.Propose", &plasma_rpc.ProposeSwapArgs{Swap: *swap}, &reply)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &reply, nil
+}
+
+func (c *Client) PendingSwaps(userAddress string) (*plasma_rpc.PendingSwapsResponse, error) {
+	var reply plasma_rpc.PendingSwapsResponse
+	err := c.Call("Swap.Pending", &plasma_rpc.PendingSwapsArgs{UserAddress: userAddress}, &reply)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &reply, nil
+}
+
+func (c *Client) AcceptSwap(tx *chain.Transaction) (*plasma_rpc.AcceptSwapResponse, error) {
+	var reply plasma_rpc.AcceptSwapResponse
+	err := c.Call("Swap.Accept", &plasma_rpc.AcceptSwapArgs{Transaction: *tx}, &reply)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &reply, nil
+}
+
+func (c *Client) newRequest(method string, args interface{}) (plasma_rpc.Request, error) {
+	id := encoding_json.RawMessage(fmt.Sprint(atomic.AddUint64(&c.nextId, 1)))
+	req := plasma_rpc.Request{
+		Version: plasma_rpc.JSONRPCVersion,
+		Method:  method,
+		Id:      &id,
+	}
+
+	if args != nil {
+		params, err := encoding_json.Marshal(args)
+
+		if err != nil {
+			return req, err
+		}
+
+		raw := encoding_json.RawMessage(params)
+		req.Params = &raw
+	}
+
+	return req, nil
+}
+
+func (c *Client) post(body interface{}, out interface{}) error {
+	message, err := encoding_json.Marshal(body)
+
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", c.url, bytes.NewBuffer(message))
+
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.http.Do(req)
+
+	if err != nil {
+		return fmt.Errorf("error in sending request to %s: %v", c.url, err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status from %s: %s", c.url, resp.Status)
+	}
+
+	return encoding_json.NewDecoder(resp.Body).Decode(out)
+}
+
+func decodeResponse(res *response, reply interface{}) error {
+	if res.Error != nil {
+		return res.Error
+	}
+
+	if res.Result == nil || reply == nil {
+		return nil
+	}
+
+	return encoding_json.Unmarshal(*res.Result, reply)
+}
@@ -6,37 +6,96 @@ import (
 	"net/http"
 
 	"github.com/gorilla/mux"
-	grpc "github.com/gorilla/rpc"
-	"github.com/gorilla/rpc/json"
 	"github.com/kyokan/plasma/db"
+	"github.com/kyokan/plasma/metrics"
 	"github.com/kyokan/plasma/node"
 )
 
 func Start(
-	port int,
+	config Config,
 	level *db.Database,
 	sink *node.TransactionSink,
+	notifier *node.Notifier,
 ) {
-	log.Printf("Starting RPC server on port %d.\n", port)
+	log.Printf("Starting RPC server on port %d.\n", config.Port)
 
+	r := NewHandler(config, level, sink, notifier)
+
+	addr := fmt.Sprint(":", config.Port)
+	var err error
+
+	if config.TLSCertFile != "" && config.TLSKeyFile != "" {
+		log.Println("Serving RPC over TLS.")
+		err = http.ListenAndServeTLS(addr, config.TLSCertFile, config.TLSKeyFile, r)
+	} else {
+		err = http.ListenAndServe(addr, r)
+	}
+
+	if err != nil {
+		log.Fatalf("RPC server failed: %v", err)
+	}
+}
+
+// NewHandler returns the handler serving JSON-RPC at /rpc, subscriptions at
+// /ws and Prometheus metrics at /metrics.
+func NewHandler(
+	config Config,
+	level *db.Database,
+	sink *node.TransactionSink,
+	notifier *node.Notifier,
+) http.Handler {
 	chch := make(chan chan node.TransactionRequest)
 
+	addressLimiter := NewRateLimiter(config.AddressRateLimit, config.AddressRateBurst)
+
 	txService := &TransactionService{
-		TxChan: chch,
+		DB:             level,
+		Domain:         sink.Domain(),
+		TxChan:         chch,
+		AddressLimiter: addressLimiter,
 	}
 
 	blockService := &BlockService{
 		DB: level,
 	}
 
+	walletService := &WalletService{
+		DB: level,
+	}
+
+	swapService := &SwapService{
+		DB:             level,
+		Domain:         sink.Domain(),
+		TxChan:         chch,
+		AddressLimiter: addressLimiter,
+	}
+
 	sink.AcceptTransactionRequests(chch)
 
-	s := grpc.NewServer()
-	s.RegisterCodec(json.NewCodec(), "application/json")
-	s.RegisterCodec(json.NewCodec(), "application/json;charset=utf-8")
+	auth := NewAuthenticator(config.APIKeys, config.JWTSecret, config.PrivilegedMethods)
+
+	if !auth.Enabled() {
+		log.Println("No RPC credentials configured, privileged methods are open.")
+	}
+
+	ipLimiter := NewRateLimiter(config.IPRateLimit, config.IPRateBurst)
+
+	s := NewServer()
+	s.MaxRequestSize = config.MaxRequestSize
+	s.Use(ipLimiter)
+	s.Use(auth)
 	s.RegisterService(txService, "Transaction")
 	s.RegisterService(blockService, "Block")
+	s.RegisterService(walletService, "Wallet")
+	s.RegisterService(swapService, "Swap")
+
+	ws := NewSubscriptionServer(notifier)
+	ws.MaxMessageSize = config.MaxRequestSize
+
 	r := mux.NewRouter()
 	r.Handle("/rpc", s)
-	http.ListenAndServe(fmt.Sprint(":", port), r)
+	r.Handle("/ws", ipLimiter.Middleware(ws))
+	r.Handle("/metrics", metrics.Handler())
+
+	return r
 }
@@ -0,0 +1,239 @@
+package rpc
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/kyokan/plasma/chain"
+	"github.com/kyokan/plasma/db"
+	"github.com/kyokan/plasma/node"
+	"github.com/kyokan/plasma/util"
+)
+
+// MaxSwapLifetime bounds how long a swap proposal is kept.
+const MaxSwapLifetime = 24 * time.Hour
+
+type ProposeSwapArgs struct {
+	Swap chain.Swap
+}
+
+type ProposeSwapResponse struct {
+	ID common.Hash
+}
+
+type PendingSwapsArgs struct {
+	UserAddress string
+}
+
+type PendingSwapsResponse struct {
+	Swaps []chain.Swap
+}
+
+type AcceptSwapArgs struct {
+	Transaction chain.Transaction
+}
+
+type AcceptSwapResponse struct {
+	Transaction *chain.Transaction
+}
+
+// SwapService relays swap proposals between the two parties. The proposer
+// posts a transaction it signed, the counterparty fetches it, signs its
+// own input and accepts it, which sends the transaction.
+type SwapService struct {
+	DB             *db.Database
+	Domain         chain.Domain
+	TxChan         chan<- chan node.TransactionRequest
+	AddressLimiter *RateLimiter
+}
+
+// Propose stores a swap proposal. The proposer's inputs must be signed, and
+// every other input must belong to the counterparty.
+func (t *SwapService) Propose(r *http.Request, args *ProposeSwapArgs, reply *ProposeSwapResponse) error {
+	log.Println("Received Swap.Propose request.")
+
+	swap := args.Swap
+	now := time.Now()
+
+	if swap.Expires == 0 {
+		swap.Expires = uint64(now.Add(MaxSwapLifetime).Unix())
+	}
+
+	if swap.Expires <= uint64(now.Unix()) || swap.Expires > uint64(now.Add(MaxSwapLifetime).Unix()) {
+		return NewError(CodeInvalidParams, "Invalid params", fmt.Sprintf("Expires must be within %s", MaxSwapLifetime))
+	}
+
+	if err := t.checkProposal(&swap); err != nil {
+		return NewError(CodeInvalidParams, "Invalid params", err.Error())
+	}
+
+	// The proposer has signed its inputs, so it is limited by its own
+	// address.
+	if !t.AddressLimiter.Allow(util.AddressToHex(&swap.Proposer)) {
+		return NewError(CodeRateLimited, "Rate limit exceeded", swap.Proposer.Hex())
+	}
+
+	if err := t.DB.SwapDao.SaveSwap(&swap); err != nil {
+		return err
+	}
+
+	*reply = ProposeSwapResponse{
+		ID: swap.ID(),
+	}
+
+	return nil
+}
+
+// Pending returns the unexpired proposals awaiting the user's signature.
+func (t *SwapService) Pending(r *http.Request, args *PendingSwapsArgs, reply *PendingSwapsResponse) error {
+	log.Println("Received Swap.Pending request.")
+
+	swaps, err := t.DB.SwapDao.SwapsFor(common.HexToAddress(args.UserAddress))
+
+	if err != nil {
+		return err
+	}
+
+	var pending []chain.Swap
+
+	for i := range swaps {
+		if swaps[i].Expires > uint64(time.Now().Unix()) {
+			pending = append(pending, swaps[i])
+			continue
+		}
+
+		if err := t.DB.SwapDao.DeleteSwap(&swaps[i]); err != nil {
+			return err
+		}
+	}
+
+	*reply = PendingSwapsResponse{
+		Swaps: pending,
+	}
+
+	return nil
+}
+
+// Accept sends the counterparty's signed version of a proposal, and drops
+// the proposal once the transaction is accepted.
+func (t *SwapService) Accept(r *http.Request, args *AcceptSwapArgs, reply *AcceptSwapResponse) error {
+	log.Println("Received Swap.Accept request.")
+
+	id := common.BytesToHash(args.Transaction.StructHash())
+	swap, err := t.DB.SwapDao.FindSwap(id)
+
+	if err != nil {
+		return err
+	}
+
+	if swap == nil || swap.Expires <= uint64(time.Now().Unix()) {
+		return NewError(CodeNotFound, "swap not found", id.Hex())
+	}
+
+	if err := t.checkAcceptance(swap, &args.Transaction); err != nil {
+		return NewError(CodeTransactionRejected, "transaction rejected", err.Error())
+	}
+
+	if !t.AddressLimiter.Allow(util.AddressToHex(&swap.Counterparty)) {
+		return NewError(CodeRateLimited, "Rate limit exceeded", swap.Counterparty.Hex())
+	}
+
+	res := submit(t.TxChan, args.Transaction)
+
+	if res.Error != nil {
+		return NewError(CodeTransactionRejected, "transaction rejected", res.Error.Error())
+	}
+
+	if err := t.DB.SwapDao.DeleteSwap(swap); err != nil {
+		log.Printf("Failed to delete accepted swap %s: %v", id.Hex(), err)
+	}
+
+	*reply = AcceptSwapResponse{
+		Transaction: res.Transaction,
+	}
+
+	return nil
+}
+
+func (t *SwapService) checkProposal(swap *chain.Swap) error {
+	tx := &swap.Transaction
+
+	if err := tx.CheckFormat(); err != nil {
+		return err
+	}
+
+	if swap.Proposer == swap.Counterparty {
+		return errors.New("a swap needs two parties")
+	}
+
+	hash := tx.SignatureHash(t.Domain)
+	proposerInputs := 0
+	counterpartyInputs := 0
+
+	for i, input := range tx.Inputs {
+		if i > 0 && input.IsZeroInput() {
+			continue
+		}
+
+		prevTx, err := t.DB.TxDao.FindByBlockNumTxIdx(input.BlkNum, input.TxIdx)
+
+		if err != nil {
+			return err
+		}
+
+		if prevTx == nil || prevTx.OutputAt(input.OutIdx) == nil {
+			return fmt.Errorf("input %d not found", i)
+		}
+
+		prevOutput := prevTx.OutputAt(input.OutIdx)
+
+		switch {
+		case prevOutput.IsOwner(swap.Proposer):
+			if err := prevOutput.VerifySignature(hash, tx.SigAt(uint8(i))); err != nil {
+				return fmt.Errorf("input %d: %v", i, err)
+			}
+
+			proposerInputs++
+		case prevOutput.IsOwner(swap.Counterparty):
+			counterpartyInputs++
+		default:
+			return fmt.Errorf("input %d belongs to neither party", i)
+		}
+	}
+
+	if proposerInputs == 0 {
+		return errors.New("the proposer has no inputs")
+	}
+
+	if counterpartyInputs == 0 {
+		return errors.New("the counterparty has no inputs")
+	}
+
+	return nil
+}
+
+// checkAcceptance checks that the counterparty signed one of its inputs of
+// tx, so that only the counterparty is rate limited for accepting.
+func (t *SwapService) checkAcceptance(swap *chain.Swap, tx *chain.Transaction) error {
+	spends, err := node.FindSpends(t.DB.TxDao, tx)
+
+	if err != nil {
+		return err
+	}
+
+	for i, spent := range spends {
+		if spent == nil || !spent.IsOwner(swap.Counterparty) {
+			continue
+		}
+
+		if addr, err := inputSigner(t.Domain, tx, uint8(i), spent); err == nil && addr == swap.Counterparty {
+			return nil
+		}
+	}
+
+	return errors.New("the counterparty has not signed its inputs")
+}
@@ -1,20 +1,21 @@
 package rpc
 
 import (
+	"errors"
 	"log"
-	"math/big"
 	"net/http"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/kyokan/plasma/chain"
+	"github.com/kyokan/plasma/db"
 	"github.com/kyokan/plasma/node"
+	"github.com/kyokan/plasma/util"
 )
 
+// SendArgs carries a transaction built and signed by the sender.
 type SendArgs struct {
 	chain.Transaction
-	From   string
-	To     string
-	Amount string
 }
 
 type SendResponse struct {
@@ -22,37 +23,83 @@ type SendResponse struct {
 }
 
 type TransactionService struct {
-	TxChan chan<- chan node.TransactionRequest
+	DB             *db.Database
+	Domain         chain.Domain
+	TxChan         chan<- chan node.TransactionRequest
+	AddressLimiter *RateLimiter
 }
 
+// Send rate limits the sender by the owner that signed input 0, so that
+// one sender cannot use up the limit of another.
 func (t *TransactionService) Send(r *http.Request, args *SendArgs, reply *SendResponse) error {
 	log.Println("Received Transaction.Send request.")
 
-	from := common.HexToAddress(args.From)
-	to := common.HexToAddress(args.To)
-	amount := new(big.Int)
-	amount.SetString(args.Amount, 0)
+	spends, err := node.FindSpends(t.DB.TxDao, &args.Transaction)
 
+	if err != nil {
+		return NewError(CodeTransactionRejected, "transaction rejected", err.Error())
+	}
+
+	from, err := inputSigner(t.Domain, &args.Transaction, 0, spends[0])
+
+	if err != nil {
+		return NewError(CodeTransactionRejected, "transaction rejected", err.Error())
+	}
+
+	if !t.AddressLimiter.Allow(util.AddressToHex(&from)) {
+		return NewError(CodeRateLimited, "Rate limit exceeded", from.Hex())
+	}
+
+	res := submit(t.TxChan, args.Transaction)
+
+	if res.Error != nil {
+		return NewError(CodeTransactionRejected, "transaction rejected", res.Error.Error())
+	}
+
+	*reply = SendResponse{
+		Transaction: res.Transaction,
+	}
+
+	return nil
+}
+
+// submit hands tx to the transaction sink and waits for its verdict.
+func submit(txChan chan<- chan node.TransactionRequest, tx chain.Transaction) *node.TransactionResponse {
 	req := node.TransactionRequest{
-		Transaction: args.Transaction,
-		From:        from,
-		To:          to,
-		Amount:      amount,
+		Transaction: tx,
 	}
 
 	ch := make(chan node.TransactionRequest)
-	t.TxChan <- ch
+	txChan <- ch
 	ch <- req
 	res := <-ch
 	close(ch)
 
-	if res.Response.Error != nil {
-		return res.Response.Error
+	return res.Response
+}
+
+// inputSigner returns the address whose signature on input idx of tx may
+// spend the output spent: one of its owners or its refund owner. Only the
+// first signature of a multisig input is recovered; the threshold is left
+// to the transaction sink.
+func inputSigner(domain chain.Domain, tx *chain.Transaction, idx uint8, spent *chain.Output) (common.Address, error) {
+	sig := tx.SigAt(idx)
+
+	if len(sig) < chain.SigLength {
+		return common.Address{}, errors.New("input is not signed")
 	}
 
-	*reply = SendResponse{
-		Transaction: res.Response.Transaction,
+	pubKey, err := crypto.SigToPub(tx.SignatureHash(domain), sig[:chain.SigLength])
+
+	if err != nil {
+		return common.Address{}, err
 	}
 
-	return nil
+	addr := crypto.PubkeyToAddress(*pubKey)
+
+	if !spent.IsOwner(addr) && (spent.RefundOwner == nil || *spent.RefundOwner != addr) {
+		return common.Address{}, errors.New("signature is not valid")
+	}
+
+	return addr, nil
 }
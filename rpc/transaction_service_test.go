@@ -0,0 +1,74 @@
+package rpc
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/kyokan/plasma/chain"
+	"github.com/kyokan/plasma/db"
+	"github.com/kyokan/plasma/db/mocks"
+	"github.com/kyokan/plasma/node"
+	"github.com/kyokan/plasma/signer"
+	"github.com/stretchr/testify/require"
+)
+
+var testDomain = chain.Domain{ChainID: big.NewInt(1), VerifyingContract: common.Address{0xaa}}
+
+func deposit(owner common.Address) *chain.Transaction {
+	return chain.NewTransaction(nil, []*chain.Output{chain.NewOutput(owner, big.NewInt(100))}, big.NewInt(0))
+}
+
+func acceptAll() chan<- chan node.TransactionRequest {
+	chch := make(chan chan node.TransactionRequest)
+
+	go func() {
+		for ch := range chch {
+			req := <-ch
+			req.Response = &node.TransactionResponse{Transaction: &req.Transaction}
+			ch <- req
+		}
+	}()
+
+	return chch
+}
+
+func Test_SendLimitsBySigner(t *testing.T) {
+	alice, err := signer.GenerateMemorySigner()
+	require.NoError(t, err)
+	bob, err := signer.GenerateMemorySigner()
+	require.NoError(t, err)
+
+	txDao := new(mocks.TransactionDao)
+	txDao.On("FindByBlockNumTxIdx", uint64(1), uint32(0)).Return(deposit(alice.Address()), nil)
+	txDao.On("FindByBlockNumTxIdx", uint64(2), uint32(0)).Return(deposit(bob.Address()), nil)
+
+	service := &TransactionService{
+		DB:             &db.Database{TxDao: txDao},
+		Domain:         testDomain,
+		TxChan:         acceptAll(),
+		AddressLimiter: NewRateLimiter(0.001, 1),
+	}
+
+	send := func(s signer.Signer, blkNum uint64) error {
+		tx := chain.NewTransaction(
+			[]*chain.Input{chain.NewInput(blkNum, 0, 0)},
+			[]*chain.Output{chain.NewOutput(common.Address{2}, big.NewInt(100))},
+			big.NewInt(0),
+		)
+		require.NoError(t, tx.Sign(s, testDomain))
+		return service.Send(nil, &SendArgs{Transaction: *tx}, &SendResponse{})
+	}
+
+	// Bob cannot sign for Alice's output, so he cannot use up her limit.
+	err = send(bob, 1)
+	require.Error(t, err)
+	require.Equal(t, CodeTransactionRejected, err.(*Error).Code)
+
+	require.NoError(t, send(alice, 1))
+	err = send(alice, 1)
+	require.Error(t, err)
+	require.Equal(t, CodeRateLimited, err.(*Error).Code)
+
+	require.NoError(t, send(bob, 2))
+}
@@ -0,0 +1,56 @@
+package rpc
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/kyokan/plasma/chain"
+	"github.com/kyokan/plasma/db"
+)
+
+type ConsolidateArgs struct {
+	UserAddress string
+	Target      int
+}
+
+type ConsolidateResponse struct {
+	Plan *chain.Plan `json:"Plan"`
+}
+
+// WalletService helps clients manage their outputs. It never signs, the
+// client signs and sends the transactions it plans.
+type WalletService struct {
+	DB *db.Database
+}
+
+// Consolidate plans the self-transfers that merge an address's UTXOs down
+// to Target outputs. Steps that spend the outputs of earlier steps must be
+// sent after those were included in a block.
+func (t *WalletService) Consolidate(r *http.Request, args *ConsolidateArgs, reply *ConsolidateResponse) error {
+	log.Println("Received Wallet.Consolidate request.")
+
+	if args.Target < 1 {
+		return NewError(CodeInvalidParams, "Invalid params", "Target must be at least 1")
+	}
+
+	userAddress := common.HexToAddress(args.UserAddress)
+
+	txs, err := t.DB.AddressDao.UTXOs(&userAddress)
+
+	if err != nil {
+		return err
+	}
+
+	plan, err := chain.PlanConsolidation(userAddress, chain.UTXOsFor(userAddress, txs), args.Target)
+
+	if err != nil {
+		return err
+	}
+
+	*reply = ConsolidateResponse{
+		Plan: plan,
+	}
+
+	return nil
+}
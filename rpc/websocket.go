@@ -0,0 +1,241 @@
+package rpc
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+
+	encoding_json "encoding/json"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gorilla/websocket"
+	"github.com/kyokan/plasma/node"
+)
+
+// Clients subscribe by sending
+//
+//	{"jsonrpc": "2.0", "id": 1, "method": "subscribe", "params": ["addressActivity", "0x..."]}
+//
+// and receive the subscription id as the result, which can later be passed
+// as a string to "unsubscribe". Events are then pushed as
+//
+//	{"jsonrpc": "2.0", "method": "subscription", "params": {"subscription": 1, "result": {...}}}
+//
+// Supported topics are newBlocks, addressActivity(address),
+// txStatus(struct hash) and exits. Transactions are tracked by their EIP-712
+// struct hash because it does not change when the transaction is packaged.
+type wsRequest struct {
+	Version string                    `json:"jsonrpc"`
+	Id      *encoding_json.RawMessage `json:"id"`
+	Method  string                    `json:"method"`
+	Params  []string                  `json:"params"`
+}
+
+type wsNotification struct {
+	Version string               `json:"jsonrpc"`
+	Method  string               `json:"method"`
+	Params  wsNotificationParams `json:"params"`
+}
+
+type wsNotificationParams struct {
+	Subscription uint64      `json:"subscription"`
+	Result       interface{} `json:"result"`
+}
+
+type SubscriptionServer struct {
+	Notifier *node.Notifier
+
+	// MaxMessageSize limits the size of messages read from clients in
+	// bytes. Zero means no limit.
+	MaxMessageSize int64
+
+	upgrader websocket.Upgrader
+}
+
+func NewSubscriptionServer(notifier *node.Notifier) *SubscriptionServer {
+	return &SubscriptionServer{
+		Notifier: notifier,
+		upgrader: websocket.Upgrader{
+			CheckOrigin: func(r *http.Request) bool { return true },
+		},
+	}
+}
+
+func (s *SubscriptionServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	conn, err := s.upgrader.Upgrade(w, r, nil)
+
+	if err != nil {
+		log.Printf("Failed to upgrade websocket connection: %v", err)
+		return
+	}
+
+	if s.MaxMessageSize > 0 {
+		conn.SetReadLimit(s.MaxMessageSize)
+	}
+
+	wsc := &wsConn{
+		conn:     conn,
+		notifier: s.Notifier,
+		subs:     make(map[uint64]*node.Subscription),
+	}
+
+	wsc.serve()
+}
+
+type wsConn struct {
+	conn     *websocket.Conn
+	notifier *node.Notifier
+	writeMtx sync.Mutex
+	subsMtx  sync.Mutex
+	subs     map[uint64]*node.Subscription
+}
+
+func (c *wsConn) serve() {
+	defer c.close()
+
+	for {
+		var req wsRequest
+
+		if err := c.conn.ReadJSON(&req); err != nil {
+			if !websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				log.Printf("Closing websocket connection: %v", err)
+			}
+
+			return
+		}
+
+		var result interface{}
+		var err error
+
+		switch req.Method {
+		case "subscribe":
+			result, err = c.subscribe(req.Params)
+		case "unsubscribe":
+			result, err = c.unsubscribe(req.Params)
+		default:
+			err = NewError(CodeMethodNotFound, "Method not found", req.Method)
+		}
+
+		res := Response{Version: JSONRPCVersion, Id: req.Id, Result: result}
+
+		if err != nil {
+			res.Result = nil
+			res.Error = toError(err)
+		}
+
+		if err := c.write(&res); err != nil {
+			return
+		}
+	}
+}
+
+func (c *wsConn) subscribe(params []string) (uint64, error) {
+	if len(params) == 0 {
+		return 0, NewError(CodeInvalidParams, "Invalid params", "missing subscription topic")
+	}
+
+	var filter func(node.Event) bool
+
+	switch params[0] {
+	case node.TopicNewBlocks, node.TopicExits:
+	case node.TopicAddressActivity:
+		if len(params) < 2 || !common.IsHexAddress(params[1]) {
+			return 0, NewError(CodeInvalidParams, "Invalid params", "addressActivity requires an address")
+		}
+
+		addr := common.HexToAddress(params[1])
+		filter = func(e node.Event) bool {
+			return e.Data.(node.AddressActivityEvent).Address == addr
+		}
+	case node.TopicTxStatus:
+		if len(params) < 2 {
+			return 0, NewError(CodeInvalidParams, "Invalid params", "txStatus requires a transaction hash")
+		}
+
+		hash := common.FromHex(params[1])
+		filter = func(e node.Event) bool {
+			return bytes.Equal(e.Data.(node.TxStatusEvent).Hash, hash)
+		}
+	default:
+		return 0, NewError(CodeInvalidParams, "Invalid params", "unknown subscription topic "+params[0])
+	}
+
+	sub := c.notifier.Subscribe(params[0], filter)
+
+	c.subsMtx.Lock()
+	c.subs[sub.ID] = sub
+	c.subsMtx.Unlock()
+
+	go c.forward(sub)
+
+	return sub.ID, nil
+}
+
+func (c *wsConn) unsubscribe(params []string) (bool, error) {
+	if len(params) == 0 {
+		return false, NewError(CodeInvalidParams, "Invalid params", "missing subscription id")
+	}
+
+	id, err := strconv.ParseUint(params[0], 10, 64)
+
+	if err != nil {
+		return false, NewError(CodeInvalidParams, "Invalid params", "invalid subscription id")
+	}
+
+	c.subsMtx.Lock()
+	_, exists := c.subs[id]
+	delete(c.subs, id)
+	c.subsMtx.Unlock()
+
+	if exists {
+		c.notifier.Unsubscribe(id)
+	}
+
+	return exists, nil
+}
+
+func (c *wsConn) forward(sub *node.Subscription) {
+	for e := range sub.C {
+		err := c.write(&wsNotification{
+			Version: JSONRPCVersion,
+			Method:  "subscription",
+			Params: wsNotificationParams{
+				Subscription: sub.ID,
+				Result:       e.Data,
+			},
+		})
+
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (c *wsConn) write(v interface{}) error {
+	c.writeMtx.Lock()
+	defer c.writeMtx.Unlock()
+	return c.conn.WriteJSON(v)
+}
+
+func (c *wsConn) subscriptions() map[uint64]*node.Subscription {
+	c.subsMtx.Lock()
+	defer c.subsMtx.Unlock()
+
+	subs := make(map[uint64]*node.Subscription, len(c.subs))
+
+	for id, sub := range c.subs {
+		subs[id] = sub
+	}
+
+	return subs
+}
+
+func (c *wsConn) close() {
+	for id := range c.subscriptions() {
+		c.notifier.Unsubscribe(id)
+	}
+
+	c.conn.Close()
+}
@@ -0,0 +1,72 @@
+package signer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"gopkg.in/urfave/cli.v1"
+)
+
+const (
+	TypeKeystore = "keystore"
+	TypeExternal = "external"
+)
+
+// FromCLI creates the signer selected by the signer flag for the account in
+// user-address.
+func FromCLI(c *cli.Context) (Signer, error) {
+	address := c.GlobalString("user-address")
+
+	if !common.IsHexAddress(address) {
+		return nil, fmt.Errorf("invalid user address %q", address)
+	}
+
+	switch kind := c.GlobalString("signer"); kind {
+	case TypeKeystore:
+		dir := c.GlobalString("keystore-dir")
+
+		if dir == "" {
+			return nil, errors.New("keystore-dir is required by the keystore signer")
+		}
+
+		passphrase, err := passphraseFromCLI(c)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return NewKeystoreSigner(dir, common.HexToAddress(address), passphrase)
+	case TypeExternal:
+		url := c.GlobalString("signer-url")
+
+		if url == "" {
+			return nil, errors.New("signer-url is required by the external signer")
+		}
+
+		return NewExternalSigner(context.Background(), url, common.HexToAddress(address))
+	default:
+		return nil, fmt.Errorf("unknown signer %q", kind)
+	}
+}
+
+// passphraseFromCLI reads the keystore passphrase from sign-passphrase-file,
+// falling back to sign-passphrase, which is best set in the config file.
+func passphraseFromCLI(c *cli.Context) (string, error) {
+	path := c.GlobalString("sign-passphrase-file")
+
+	if path == "" {
+		return c.GlobalString("sign-passphrase"), nil
+	}
+
+	data, err := ioutil.ReadFile(path)
+
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
@@ -0,0 +1,84 @@
+package signer
+
+import (
+	"context"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// SignHashMethod is the JSON-RPC method an external signer must serve. It
+// takes an address and a 32 byte hash, both hex encoded, and returns the
+// hex encoded signature of the raw hash. eth_sign and account_sign prefix
+// the data before hashing, so their signatures do not verify on the plasma
+// chain.
+const SignHashMethod = "account_signHash"
+
+// SignTypedDataMethod is the JSON-RPC method external signers serve to sign
+// EIP-712 typed data. It takes an address and the typed data, which the
+// signer displays before signing its hash.
+const SignTypedDataMethod = "account_signTypedData"
+
+// ExternalSigner forwards hashes to a separate signer process, such as a
+// clef-style daemon, so that keys never enter the plasma process.
+type ExternalSigner struct {
+	client  *rpc.Client
+	address common.Address
+	timeout time.Duration
+}
+
+// NewExternalSigner dials the signer at url, which is an HTTP or WebSocket
+// URL or the path of an IPC socket.
+func NewExternalSigner(ctx context.Context, url string, address common.Address) (*ExternalSigner, error) {
+	client, err := rpc.DialContext(ctx, url)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &ExternalSigner{
+		client:  client,
+		address: address,
+		// Signers may ask a human to confirm each request.
+		timeout: 2 * time.Minute,
+	}, nil
+}
+
+func (s *ExternalSigner) Address() common.Address {
+	return s.address
+}
+
+func (s *ExternalSigner) SignHash(hash []byte) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	var sig hexutil.Bytes
+	err := s.client.CallContext(ctx, &sig, SignHashMethod, s.address, hexutil.Bytes(hash))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return normalize(sig)
+}
+
+func (s *ExternalSigner) SignTypedData(data *TypedData) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	var sig hexutil.Bytes
+	err := s.client.CallContext(ctx, &sig, SignTypedDataMethod, s.address, data)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return normalize(sig)
+}
+
+func (s *ExternalSigner) Close() error {
+	s.client.Close()
+	return nil
+}
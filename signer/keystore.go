@@ -0,0 +1,45 @@
+package signer
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// KeystoreSigner signs with an account from an encrypted keystore directory.
+// The key is decrypted once and stays inside the keystore.
+type KeystoreSigner struct {
+	ks      *keystore.KeyStore
+	account accounts.Account
+}
+
+// NewKeystoreSigner unlocks address in the keystore at dir with passphrase.
+func NewKeystoreSigner(dir string, address common.Address, passphrase string) (*KeystoreSigner, error) {
+	ks := keystore.NewKeyStore(dir, keystore.StandardScryptN, keystore.StandardScryptP)
+	account, err := ks.Find(accounts.Account{Address: address})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to find %s in %s: %v", address.Hex(), dir, err)
+	}
+
+	if err := ks.Unlock(account, passphrase); err != nil {
+		return nil, fmt.Errorf("failed to unlock %s: %v", address.Hex(), err)
+	}
+
+	return &KeystoreSigner{ks: ks, account: account}, nil
+}
+
+func (s *KeystoreSigner) Address() common.Address {
+	return s.account.Address
+}
+
+func (s *KeystoreSigner) SignHash(hash []byte) ([]byte, error) {
+	return s.ks.SignHash(s.account, hash)
+}
+
+// Close locks the account, removing the decrypted key from memory.
+func (s *KeystoreSigner) Close() error {
+	return s.ks.Lock(s.account.Address)
+}
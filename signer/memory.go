@@ -0,0 +1,41 @@
+package signer
+
+import (
+	"crypto/ecdsa"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// MemorySigner signs with a key held in memory. It is meant for tests and
+// tools that generate their own keys.
+type MemorySigner struct {
+	key     *ecdsa.PrivateKey
+	address common.Address
+}
+
+func NewMemorySigner(key *ecdsa.PrivateKey) *MemorySigner {
+	return &MemorySigner{
+		key:     key,
+		address: crypto.PubkeyToAddress(key.PublicKey),
+	}
+}
+
+// GenerateMemorySigner creates a MemorySigner for a new random key.
+func GenerateMemorySigner() (*MemorySigner, error) {
+	key, err := crypto.GenerateKey()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return NewMemorySigner(key), nil
+}
+
+func (s *MemorySigner) Address() common.Address {
+	return s.address
+}
+
+func (s *MemorySigner) SignHash(hash []byte) ([]byte, error) {
+	return crypto.Sign(hash, s.key)
+}
@@ -0,0 +1,57 @@
+// Package signer signs plasma transactions and Ethereum transactions on
+// behalf of a single account, so that callers never handle raw keys.
+package signer
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Signer signs on behalf of the account returned by Address.
+type Signer interface {
+	Address() common.Address
+
+	// SignHash signs a 32 byte hash and returns a 65 byte [R || S || V]
+	// signature, where V is 0 or 1.
+	SignHash(hash []byte) ([]byte, error)
+}
+
+// ErrWrongAccount is returned when a signer is asked to sign for an account
+// it does not hold.
+var ErrWrongAccount = errors.New("signer does not hold the account")
+
+// TransactorFn adapts a Signer to sign Ethereum transactions through bind.
+func TransactorFn(s Signer) bind.SignerFn {
+	return func(txSigner types.Signer, address common.Address, tx *types.Transaction) (*types.Transaction, error) {
+		if address != s.Address() {
+			return nil, fmt.Errorf("%v: %s", ErrWrongAccount, address.Hex())
+		}
+
+		hash := txSigner.Hash(tx)
+		sig, err := s.SignHash(hash[:])
+
+		if err != nil {
+			return nil, err
+		}
+
+		return tx.WithSignature(txSigner, sig)
+	}
+}
+
+// normalize checks the length of a signature and converts a 27/28 recovery
+// id, as returned by some external signers, to 0/1.
+func normalize(sig []byte) ([]byte, error) {
+	if len(sig) != 65 {
+		return nil, fmt.Errorf("invalid signature length %d", len(sig))
+	}
+
+	if sig[64] >= 27 {
+		sig[64] -= 27
+	}
+
+	return sig, nil
+}
@@ -0,0 +1,57 @@
+package signer
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_KeystoreSignerSignsForAccount(t *testing.T) {
+	dir, err := ioutil.TempDir("", "plasma-keystore")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	ks := keystore.NewKeyStore(dir, keystore.LightScryptN, keystore.LightScryptP)
+	account, err := ks.NewAccount("secret")
+	require.NoError(t, err)
+
+	_, err = NewKeystoreSigner(dir, account.Address, "wrong")
+	require.Error(t, err)
+
+	s, err := NewKeystoreSigner(dir, account.Address, "secret")
+	require.NoError(t, err)
+	defer s.Close()
+
+	hash := crypto.Keccak256([]byte("plasma"))
+	sig, err := s.SignHash(hash)
+	require.NoError(t, err)
+
+	pubKey, err := crypto.SigToPub(hash, sig)
+	require.NoError(t, err)
+	require.Equal(t, account.Address, crypto.PubkeyToAddress(*pubKey))
+}
+
+func Test_TransactorFnSignsTransactions(t *testing.T) {
+	s, err := GenerateMemorySigner()
+	require.NoError(t, err)
+
+	txSigner := types.HomesteadSigner{}
+	tx := types.NewTransaction(0, common.Address{}, big.NewInt(1), 21000, big.NewInt(1), nil)
+
+	signed, err := TransactorFn(s)(txSigner, s.Address(), tx)
+	require.NoError(t, err)
+
+	from, err := types.Sender(txSigner, signed)
+	require.NoError(t, err)
+	require.Equal(t, s.Address(), from)
+
+	_, err = TransactorFn(s)(txSigner, common.Address{}, tx)
+	require.Error(t, err)
+}
@@ -0,0 +1,25 @@
+package signer
+
+// TypedDataField is a member of an EIP-712 struct type.
+type TypedDataField struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// TypedData is EIP-712 typed data, as wallets display and sign it.
+type TypedData struct {
+	Types       map[string][]TypedDataField `json:"types"`
+	PrimaryType string                      `json:"primaryType"`
+	Domain      map[string]interface{}      `json:"domain"`
+	Message     map[string]interface{}      `json:"message"`
+}
+
+// TypedDataSigner is implemented by signers that show the user what they
+// sign. Others are given the hash of the typed data.
+type TypedDataSigner interface {
+	Signer
+
+	// SignTypedData signs the EIP-712 hash of data and returns a signature
+	// in the same format as SignHash.
+	SignTypedData(data *TypedData) ([]byte, error)
+}
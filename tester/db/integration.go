@@ -110,12 +110,9 @@ func createTestTransaction(
 	output0 *chain.Output,
 ) chain.Transaction {
 	return chain.Transaction{
-		Input0:  input0,
-		Input1:  chain.ZeroInput(),
-		Sig0:    []byte{},
-		Sig1:    []byte{},
-		Output0: output0,
-		Output1: chain.ZeroOutput(),
+		Inputs:  []*chain.Input{input0, chain.ZeroInput()},
+		Sigs:    [][]byte{{}, {}},
+		Outputs: []*chain.Output{output0, chain.ZeroOutput()},
 		Fee:     new(big.Int),
 		BlkNum:  blknum,
 		TxIdx:   txId,
@@ -57,7 +57,7 @@ func exitAndChallengeSameBlock(
 		userAddress,
 		txs,
 		merkle,
-		util.Sub(blocknum, 1),
+		util.Sub(blocknum, chain.ChildBlockInterval),
 		new(big.Int).SetInt64(1),
 	)
 	time.Sleep(3 * time.Second)
@@ -69,7 +69,7 @@ func exitAndChallengeSameBlock(
 		userAddress,
 		txs,
 		merkle,
-		util.Sub(blocknum, 1),
+		util.Sub(blocknum, chain.ChildBlockInterval),
 		new(big.Int).SetInt64(2),
 		exitId,
 	)
@@ -90,6 +90,9 @@ func exitAndChallengeDeposit(
 
 	blocknum := CurrentChildBlock(plasma, userAddress)
 
+	// The deposit is the latest deposit block before the next child block.
+	depositBlocknum := new(big.Int).Add(util.Sub(blocknum, chain.ChildBlockInterval+1), CurrentDepositBlock(plasma, userAddress))
+
 	// Transactions for next block
 	txs := createSubmitBlockTxs(blocknum, userAddress)
 	merkle := CreateMerkleTree(txs)
@@ -105,8 +108,7 @@ func exitAndChallengeDeposit(
 		[]chain.Transaction{t},
 		// But this shouldn't work because the merkle for the deposit is diff.
 		depositMerkle,
-		// Exit deposit which is the previous block
-		util.Sub(blocknum, 2),
+		depositBlocknum,
 		util.NewInt(0),
 	)
 	time.Sleep(3 * time.Second)
@@ -119,7 +121,7 @@ func exitAndChallengeDeposit(
 		txs,
 		merkle,
 		// Challenge exit of deposit with tx1 of new block.
-		util.Sub(blocknum, 1),
+		util.Sub(blocknum, chain.ChildBlockInterval),
 		util.NewInt(0),
 		exitId,
 	)
@@ -146,7 +148,7 @@ func finalize(
 		userAddress,
 		txs,
 		merkle,
-		util.Sub(blocknum, 1),
+		util.Sub(blocknum, chain.ChildBlockInterval),
 		util.NewInt(2),
 	)
 	time.Sleep(3 * time.Second)
@@ -193,8 +195,8 @@ func createSubmitBlockTxs(blocknum *big.Int, userAddress string) []chain.Transac
 	return []chain.Transaction{
 		createTestTransaction(
 			&chain.Input{
-				// Reference deposit
-				BlkNum: util.Sub(blocknum, 1).Uint64(),
+				// Reference the first deposit after the previous block
+				BlkNum: util.Sub(blocknum, chain.ChildBlockInterval-1).Uint64(),
 				TxIdx:  0,
 				OutIdx: 0,
 			},
@@ -232,12 +234,9 @@ func createTestTransaction(
 	output0 *chain.Output,
 ) chain.Transaction {
 	return chain.Transaction{
-		Input0:  input0,
-		Input1:  chain.ZeroInput(),
-		Sig0:    []byte{},
-		Sig1:    []byte{},
-		Output0: output0,
-		Output1: chain.ZeroOutput(),
+		Inputs:  []*chain.Input{input0, chain.ZeroInput()},
+		Sigs:    [][]byte{{}, {}},
+		Outputs: []*chain.Output{output0, chain.ZeroOutput()},
 		Fee:     new(big.Int),
 		BlkNum:  uint64(0),
 		TxIdx:   0,
@@ -45,6 +45,21 @@ func CurrentChildBlock(
 	return blocknum
 }
 
+func CurrentDepositBlock(
+	plasma *contracts.Plasma,
+	address string,
+) *big.Int {
+	opts := util.CreateCallOpts(address)
+
+	blocknum, err := plasma.CurrentDepositBlock(opts)
+
+	if err != nil {
+		panic(err)
+	}
+
+	return blocknum
+}
+
 func LastExitId(
 	plasma *contracts.Plasma,
 	address string,
@@ -156,7 +171,7 @@ func SubmitBlock(
 
 	var root [32]byte
 	copy(root[:], merkle.Root.Hash[:32])
-	tx, err := plasma.SubmitBlock(auth, root)
+	tx, err := plasma.SubmitBlock(auth, root, [32]byte{})
 
 	if err != nil {
 		log.Fatalf("Failed to submit block: %v", err)
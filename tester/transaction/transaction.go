@@ -15,13 +15,15 @@ func ExampleEncoder() {
 	fmt.Printf("%v → %X\n", t, bytes)
 
 	t = &chain.Transaction{
-		Output0: &chain.Output{
-			NewOwner: common.HexToAddress("1421e90e-1b4b-4f07-872e-20178c2c2b12"),
-			Amount:   new(big.Int).SetUint64(5),
-		},
-		Output1: &chain.Output{
-			NewOwner: common.HexToAddress("c81c342b-4fb0-46a0-9c6d-9688031e4854"),
-			Amount:   new(big.Int).SetUint64(6),
+		Outputs: []*chain.Output{
+			{
+				NewOwner: common.HexToAddress("1421e90e-1b4b-4f07-872e-20178c2c2b12"),
+				Amount:   new(big.Int).SetUint64(5),
+			},
+			{
+				NewOwner: common.HexToAddress("c81c342b-4fb0-46a0-9c6d-9688031e4854"),
+				Amount:   new(big.Int).SetUint64(6),
+			},
 		},
 	}
 
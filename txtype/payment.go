@@ -0,0 +1,94 @@
+package txtype
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/kyokan/plasma/chain"
+)
+
+// Payment is the type of every transaction no registered type matches. It
+// moves amounts and tokens between outputs: the inputs are signed for by
+// their owners or unlocked, tokens move unchanged, and the outputs and the
+// fee add up to the inputs.
+type Payment struct{}
+
+func (Payment) Name() string {
+	return "payment"
+}
+
+func (Payment) Matches(tx *chain.Transaction) bool {
+	return true
+}
+
+func (Payment) Validate(ctx *Context, tx *chain.Transaction) error {
+	sigHash := tx.SignatureHash(ctx.Domain)
+	totalInput := big.NewInt(0)
+	var spends []*chain.Output
+
+	for i, spend := range ctx.Spends {
+		if spend == nil {
+			continue
+		}
+
+		// Locked outputs are signed for by their owners or, after the
+		// time lock of a hash lock, by the refund owner.
+		signers, err := spend.Unlock(ctx.Height, tx.PreimageAt(uint8(i)))
+
+		if err != nil {
+			return fmt.Errorf("input %d: %v", i, err)
+		}
+
+		if err := signers.VerifySignature(sigHash, tx.SigAt(uint8(i))); err != nil {
+			return fmt.Errorf("input %d: %v", i, err)
+		}
+
+		totalInput = totalInput.Add(totalInput, spend.Amount)
+		spends = append(spends, spend)
+	}
+
+	if err := chain.CheckTokenTransfer(spends, tx.Outputs); err != nil {
+		return err
+	}
+
+	if tx.Fee.Sign() < 0 {
+		return errors.New("amounts must not be negative")
+	}
+
+	totalOutput := new(big.Int).Set(tx.Fee)
+
+	for _, output := range tx.Outputs {
+		if output.Amount.Sign() < 0 {
+			return errors.New("amounts must not be negative")
+		}
+
+		totalOutput = totalOutput.Add(totalOutput, output.Amount)
+	}
+
+	if totalInput.Cmp(totalOutput) != 0 {
+		return errors.New("inputs and outputs do not have the same sum")
+	}
+
+	return nil
+}
+
+// Transition spends the inputs of tx, so that no other transaction in the
+// block spends them.
+func (Payment) Transition(state *BlockState, tx *chain.Transaction) error {
+	if tx.IsDeposit() {
+		return nil
+	}
+
+	var inputs []*chain.Input
+
+	for i, input := range tx.Inputs {
+		if i > 0 && input.IsZeroInput() {
+			continue
+		}
+
+		inputs = append(inputs, input)
+	}
+
+	return state.Spend(inputs...)
+}
@@ -0,0 +1,159 @@
+package txtype
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/kyokan/plasma/chain"
+)
+
+// TxType is a kind of transaction with its own rules. Applications register
+// types to build on the plasma chain; the root node runs every transaction
+// through its type before accepting it and again when packaging it into a
+// block, and validators repeat both steps for every block they sync.
+type TxType interface {
+	// Name identifies the type in registrations and errors.
+	Name() string
+	// Matches returns whether tx is of this type.
+	Matches(tx *chain.Transaction) bool
+	// Validate checks tx against the outputs it spends.
+	Validate(ctx *Context, tx *chain.Transaction) error
+	// Transition applies tx to the state of the block it is packaged in.
+	// Transactions whose transition fails are left out of the block.
+	Transition(state *BlockState, tx *chain.Transaction) error
+}
+
+// Context is what a transaction is validated against.
+type Context struct {
+	// Height is the number of the latest block when the transaction is
+	// accepted. Time locks up to it have passed.
+	Height uint64
+	// Domain is the domain inputs are signed for.
+	Domain chain.Domain
+	// Spends holds the outputs spent by the inputs, by input index. Zero
+	// inputs spend nothing.
+	Spends []*chain.Output
+}
+
+// BlockState is the state the transactions of a block transition, in the
+// order they are packaged.
+type BlockState struct {
+	BlkNum uint64
+	spent  map[chain.Input]bool
+}
+
+func NewBlockState(blkNum uint64) *BlockState {
+	return &BlockState{
+		BlkNum: blkNum,
+		spent:  make(map[chain.Input]bool),
+	}
+}
+
+// Spend marks inputs as spent in the block. It fails without marking any
+// of them when one is spent already.
+func (s *BlockState) Spend(inputs ...*chain.Input) error {
+	for _, input := range inputs {
+		if s.spent[*input] {
+			return fmt.Errorf("input %d/%d/%d is spent twice in block %d", input.BlkNum, input.TxIdx, input.OutIdx, s.BlkNum)
+		}
+	}
+
+	for _, input := range inputs {
+		s.spent[*input] = true
+	}
+
+	return nil
+}
+
+// Registry finds the type of a transaction among the registered types, in
+// the order they were registered. Transactions of no registered type are
+// of the fallback type.
+type Registry struct {
+	mtx      sync.RWMutex
+	types    []TxType
+	fallback TxType
+}
+
+func NewRegistry(fallback TxType) *Registry {
+	return &Registry{
+		fallback: fallback,
+	}
+}
+
+func (r *Registry) Register(t TxType) error {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if t.Name() == r.fallback.Name() {
+		return fmt.Errorf("transaction type %s is already registered", t.Name())
+	}
+
+	for _, registered := range r.types {
+		if registered.Name() == t.Name() {
+			return fmt.Errorf("transaction type %s is already registered", t.Name())
+		}
+	}
+
+	r.types = append(r.types, t)
+	return nil
+}
+
+func (r *Registry) TypeOf(tx *chain.Transaction) TxType {
+	r.mtx.RLock()
+	defer r.mtx.RUnlock()
+
+	for _, t := range r.types {
+		if t.Matches(tx) {
+			return t
+		}
+	}
+
+	return r.fallback
+}
+
+// Validate validates tx as its type. Deposits are checked by the plasma
+// contract instead.
+func (r *Registry) Validate(ctx *Context, tx *chain.Transaction) error {
+	if tx.IsDeposit() {
+		return errors.New("deposits are only credited from the plasma contract")
+	}
+
+	if len(ctx.Spends) != len(tx.Inputs) {
+		return errors.New("spent outputs do not match the inputs")
+	}
+
+	return r.TypeOf(tx).Validate(ctx, tx)
+}
+
+// ApplyBlock transitions the state of block blkNum by txs in order, and
+// returns the transactions whose transition succeeded and those whose
+// transition failed.
+func (r *Registry) ApplyBlock(blkNum uint64, txs []chain.Transaction) (accepted []chain.Transaction, rejected []chain.Transaction) {
+	state := NewBlockState(blkNum)
+
+	for i := range txs {
+		if err := r.TypeOf(&txs[i]).Transition(state, &txs[i]); err != nil {
+			rejected = append(rejected, txs[i])
+			continue
+		}
+
+		accepted = append(accepted, txs[i])
+	}
+
+	return accepted, rejected
+}
+
+var registry = NewRegistry(Payment{})
+
+// Register registers t with the registry of the root node and validators.
+// Applications register their types before starting either.
+func Register(t TxType) error {
+	return registry.Register(t)
+}
+
+// Default returns the registry Register adds to, which falls back to
+// Payment.
+func Default() *Registry {
+	return registry
+}
@@ -0,0 +1,130 @@
+package txtype
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/kyokan/plasma/chain"
+	"github.com/kyokan/plasma/signer"
+	"github.com/stretchr/testify/require"
+)
+
+var testDomain = chain.Domain{ChainID: big.NewInt(1), VerifyingContract: common.Address{0xaa}}
+
+// vote only allows votes of one per block for each candidate.
+type vote struct {
+	votes map[uint64]map[string]bool
+}
+
+func (v *vote) Name() string {
+	return "vote"
+}
+
+func (v *vote) Matches(tx *chain.Transaction) bool {
+	return bytes.HasPrefix(tx.Metadata, []byte("vote:"))
+}
+
+func (v *vote) Validate(ctx *Context, tx *chain.Transaction) error {
+	if tx.Fee.Sign() != 0 {
+		return errors.New("votes have no fee")
+	}
+
+	return Payment{}.Validate(ctx, tx)
+}
+
+func (v *vote) Transition(state *BlockState, tx *chain.Transaction) error {
+	if v.votes[state.BlkNum] == nil {
+		v.votes[state.BlkNum] = make(map[string]bool)
+	}
+
+	if v.votes[state.BlkNum][string(tx.Metadata)] {
+		return errors.New("candidate already has a vote in this block")
+	}
+
+	if err := (Payment{}).Transition(state, tx); err != nil {
+		return err
+	}
+
+	v.votes[state.BlkNum][string(tx.Metadata)] = true
+	return nil
+}
+
+func signedPayment(t *testing.T, s signer.Signer, input *chain.Input, amount int64, fee int64) (*chain.Transaction, *Context) {
+	tx := chain.NewTransaction(
+		[]*chain.Input{input},
+		[]*chain.Output{chain.NewOutput(common.Address{2}, big.NewInt(amount))},
+		big.NewInt(fee),
+	)
+	require.NoError(t, tx.Sign(s, testDomain))
+
+	spends := make([]*chain.Output, len(tx.Inputs))
+	spends[0] = chain.NewOutput(s.Address(), big.NewInt(100))
+	return tx, &Context{Height: 1, Domain: testDomain, Spends: spends}
+}
+
+func Test_Payment(t *testing.T) {
+	s, err := signer.GenerateMemorySigner()
+	require.NoError(t, err)
+	other, err := signer.GenerateMemorySigner()
+	require.NoError(t, err)
+	registry := NewRegistry(Payment{})
+
+	tx, ctx := signedPayment(t, s, chain.NewInput(1, 0, 0), 90, 10)
+	require.NoError(t, registry.Validate(ctx, tx))
+
+	tx, ctx = signedPayment(t, s, chain.NewInput(1, 0, 0), 100, 10)
+	require.Error(t, registry.Validate(ctx, tx))
+
+	tx, ctx = signedPayment(t, other, chain.NewInput(1, 0, 0), 90, 10)
+	ctx.Spends[0] = chain.NewOutput(s.Address(), big.NewInt(100))
+	require.Error(t, registry.Validate(ctx, tx))
+
+	// Two transactions spending the same input cannot share a block.
+	first, _ := signedPayment(t, s, chain.NewInput(1, 0, 0), 90, 10)
+	second, _ := signedPayment(t, s, chain.NewInput(1, 0, 0), 80, 20)
+	third, _ := signedPayment(t, s, chain.NewInput(1, 1, 0), 90, 10)
+	accepted, rejected := registry.ApplyBlock(2, []chain.Transaction{*first, *second, *third})
+	require.Equal(t, []chain.Transaction{*first, *third}, accepted)
+	require.Equal(t, []chain.Transaction{*second}, rejected)
+}
+
+func Test_Registry(t *testing.T) {
+	s, err := signer.GenerateMemorySigner()
+	require.NoError(t, err)
+
+	registry := NewRegistry(Payment{})
+	v := &vote{votes: make(map[uint64]map[string]bool)}
+	require.NoError(t, registry.Register(v))
+	require.Error(t, registry.Register(v))
+	require.Error(t, registry.Register(Payment{}))
+
+	payment, ctx := signedPayment(t, s, chain.NewInput(1, 0, 0), 90, 10)
+	require.Equal(t, Payment{}, registry.TypeOf(payment))
+	require.NoError(t, registry.Validate(ctx, payment))
+
+	// Votes follow their own rules.
+	ballot := chain.NewTransaction(
+		[]*chain.Input{chain.NewInput(1, 0, 0)},
+		[]*chain.Output{chain.NewOutput(common.Address{2}, big.NewInt(90))},
+		big.NewInt(10),
+	)
+	ballot.SetMetadata([]byte("vote:alice"))
+	require.NoError(t, ballot.Sign(s, testDomain))
+	require.Equal(t, v, registry.TypeOf(ballot))
+	require.Error(t, registry.Validate(&Context{Domain: testDomain, Spends: ctx.Spends}, ballot))
+
+	first := chain.NewTransaction([]*chain.Input{chain.NewInput(1, 0, 0)}, nil, big.NewInt(0))
+	first.SetMetadata([]byte("vote:alice"))
+	second := chain.NewTransaction([]*chain.Input{chain.NewInput(1, 1, 0)}, nil, big.NewInt(0))
+	second.SetMetadata([]byte("vote:alice"))
+	accepted, rejected := registry.ApplyBlock(2, []chain.Transaction{*first, *second})
+	require.Len(t, accepted, 1)
+	require.Len(t, rejected, 1)
+
+	// Deposits come from the plasma contract only.
+	deposit := chain.NewTransaction(nil, []*chain.Output{chain.NewOutput(s.Address(), big.NewInt(100))}, big.NewInt(0))
+	require.Error(t, registry.Validate(&Context{Domain: testDomain, Spends: make([]*chain.Output, len(deposit.Inputs))}, deposit))
+}
@@ -10,6 +10,14 @@ import (
 )
 
 func GetBalance(c *cli.Context) {
+	if usesWallet(c) {
+		if err := walletBalance(c); err != nil {
+			log.Fatalf("Failed to get balances: %v", err)
+		}
+
+		return
+	}
+
 	nodeURL := c.GlobalString("node-url")
 	userAddress := c.GlobalString("user-address")
 	client, err := eth.NewClient(nodeURL)
@@ -0,0 +1,216 @@
+package userclient
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/kyokan/plasma/chain"
+	"github.com/kyokan/plasma/eth"
+	plasma_rpc "github.com/kyokan/plasma/rpc"
+	"github.com/kyokan/plasma/rpc/rpcclient"
+	"github.com/kyokan/plasma/signer"
+	"gopkg.in/urfave/cli.v1"
+)
+
+// SendLocked pays payee, a locked output, from s's UTXOs and sends the
+// transaction to the root node.
+func SendLocked(rootClient *rpcclient.Client, s signer.Signer, payee *chain.Output, domain chain.Domain) (*chain.Transaction, error) {
+	if !payee.IsLocked() {
+		return nil, errors.New("payment is not locked")
+	}
+
+	o, err := BuildUnsignedSend(rootClient, s.Address(), payee, false, nil, domain)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := o.Sign(s, nil); err != nil {
+		return nil, err
+	}
+
+	return sendSigned(rootClient, &o.Transaction)
+}
+
+// SpendLocked sends the locked output spent by input to s. With a preimage,
+// it claims a hash-locked output for its owner before its time lock.
+// Without one, it spends the output once its time lock has passed, as the
+// owner of a time-locked output or as the refund owner of a hash-locked
+// one.
+func SpendLocked(
+	rootClient *rpcclient.Client,
+	s signer.Signer,
+	input chain.Input,
+	preimage []byte,
+	domain chain.Domain,
+) (*chain.Transaction, error) {
+	res, err := rootClient.GetBlock(input.BlkNum)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if int(input.TxIdx) >= len(res.Transactions) || res.Transactions[input.TxIdx].OutputAt(input.OutIdx) == nil {
+		return nil, errors.New("output does not exist")
+	}
+
+	output := res.Transactions[input.TxIdx].OutputAt(input.OutIdx)
+
+	if !output.IsLocked() {
+		return nil, errors.New("output is not locked")
+	}
+
+	tx := chain.NewTransaction(
+		[]*chain.Input{&input},
+		[]*chain.Output{chain.NewOutput(s.Address(), output.Amount)},
+		big.NewInt(0),
+	)
+
+	if len(preimage) > 0 {
+		if err := tx.SetPreimage(0, preimage); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Sign(s, domain); err != nil {
+		return nil, err
+	}
+
+	return sendSigned(rootClient, tx)
+}
+
+func sendSigned(rootClient *rpcclient.Client, tx *chain.Transaction) (*chain.Transaction, error) {
+	res, err := rootClient.Send(&plasma_rpc.SendArgs{
+		Transaction: *tx,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return res.Transaction, nil
+}
+
+// LockPreimageCLI prints a random preimage and the hash lock it unlocks.
+// The recipient of a hash-locked payment keeps the preimage and gives the
+// hash lock to the payer.
+func LockPreimageCLI(c *cli.Context) error {
+	preimage := make([]byte, chain.MaxPreimageSize)
+
+	if _, err := rand.Read(preimage); err != nil {
+		return err
+	}
+
+	fmt.Printf("Preimage: %s\n", hexutil.Encode(preimage))
+	fmt.Printf("Hash lock: %s\n", hexutil.Encode(chain.HashPreimage(preimage)))
+	return nil
+}
+
+func LockSendCLI(c *cli.Context) error {
+	rootUrl := fmt.Sprintf("http://localhost:%d/rpc", c.Int("root-port"))
+	to := c.String("to")
+
+	if !common.IsHexAddress(to) {
+		return fmt.Errorf("invalid recipient address %q", to)
+	}
+
+	var hashLock []byte
+
+	if c.String("hash-lock") != "" {
+		decoded, err := hexutil.Decode(c.String("hash-lock"))
+
+		if err != nil {
+			return fmt.Errorf("invalid hash lock: %v", err)
+		}
+
+		hashLock = decoded
+	}
+
+	timeLock := uint64(c.Int("time-lock"))
+	s, err := signer.FromCLI(c)
+
+	if err != nil {
+		return err
+	}
+
+	// A hash-locked payment is refunded to the payer after its time lock.
+	var refundOwner *common.Address
+
+	if len(hashLock) > 0 && timeLock > 0 {
+		payer := s.Address()
+		refundOwner = &payer
+	}
+
+	domain, err := eth.DomainFromCLI(c)
+
+	if err != nil {
+		return err
+	}
+
+	payee := chain.NewLockedOutput(
+		common.HexToAddress(to),
+		big.NewInt(int64(c.Int("amount"))),
+		hashLock,
+		timeLock,
+		refundOwner,
+	)
+
+	tx, err := SendLocked(rpcclient.NewClient(rootUrl), s, payee, domain)
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Locked payment sent with hash: %s\n", common.ToHex(tx.Hash()))
+	return nil
+}
+
+func LockClaimCLI(c *cli.Context) error {
+	var preimage []byte
+
+	if c.String("preimage") != "" {
+		decoded, err := hexutil.Decode(c.String("preimage"))
+
+		if err != nil {
+			return fmt.Errorf("invalid preimage: %v", err)
+		}
+
+		preimage = decoded
+	}
+
+	return spendLockedCLI(c, preimage)
+}
+
+func LockRefundCLI(c *cli.Context) error {
+	return spendLockedCLI(c, nil)
+}
+
+func spendLockedCLI(c *cli.Context, preimage []byte) error {
+	rootUrl := fmt.Sprintf("http://localhost:%d/rpc", c.Int("root-port"))
+	input := chain.NewInput(uint64(c.Int("blocknum")), uint32(c.Int("txindex")), uint8(c.Int("oindex")))
+
+	s, err := signer.FromCLI(c)
+
+	if err != nil {
+		return err
+	}
+
+	domain, err := eth.DomainFromCLI(c)
+
+	if err != nil {
+		return err
+	}
+
+	tx, err := SpendLocked(rpcclient.NewClient(rootUrl), s, *input, preimage, domain)
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Transaction sent with hash: %s\n", common.ToHex(tx.Hash()))
+	return nil
+}
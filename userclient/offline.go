@@ -0,0 +1,401 @@
+package userclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/kyokan/plasma/chain"
+	"github.com/kyokan/plasma/eth"
+	plasma_rpc "github.com/kyokan/plasma/rpc"
+	"github.com/kyokan/plasma/rpc/rpcclient"
+	"github.com/kyokan/plasma/signer"
+	"github.com/kyokan/plasma/util"
+	"github.com/olekukonko/tablewriter"
+	"gopkg.in/urfave/cli.v1"
+)
+
+// OfflineTransaction is the file passed between tx build, tx sign and tx
+// broadcast. SignatureHash lets the signing machine check that it signs the
+// transaction it displays, for the plasma deployment in Domain. Spends
+// holds the output each input spends, so that the owners of a multisig
+// output can each sign the file in turn.
+type OfflineTransaction struct {
+	From          common.Address    `json:"from"`
+	Transaction   chain.Transaction `json:"transaction"`
+	Spends        []*chain.Output   `json:"spends"`
+	Domain        chain.Domain      `json:"domain"`
+	SignatureHash hexutil.Bytes     `json:"signatureHash"`
+}
+
+// BuildUnsignedSend fetches from's UTXOs from the root node and selects the
+// inputs for a payment of payee carrying memo, to be signed for domain.
+// When multisig is set, only from's multisig outputs are spent, and the
+// change is locked to the same owners. Otherwise they are left alone.
+// The payment keeps the owners and lock conditions of payee.
+func BuildUnsignedSend(
+	rootClient *rpcclient.Client,
+	from common.Address,
+	payee *chain.Output,
+	multisig bool,
+	memo []byte,
+	domain chain.Domain,
+) (*OfflineTransaction, error) {
+	if len(memo) > chain.MaxMetadataSize {
+		return nil, fmt.Errorf("memo is longer than %d bytes", chain.MaxMetadataSize)
+	}
+
+	utxos, err := rootClient.GetUTXOs(util.AddressToHex(&from))
+
+	if err != nil {
+		return nil, err
+	}
+
+	var candidates []chain.Transaction
+
+	for _, tx := range utxos.Transactions {
+		if output := tx.OutputFor(&from); output.IsMultisig() == multisig && !output.IsLocked() && !output.IsToken() {
+			candidates = append(candidates, tx)
+		}
+	}
+
+	utxoTxs, err := chain.SelectUTXOs(from, payee.Amount, candidates)
+
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := chain.BuildSendTransaction(from, payee.NewOwner, payee.Amount, utxoTxs)
+
+	if err != nil {
+		return nil, err
+	}
+
+	spends := make([]*chain.Output, len(tx.Inputs))
+
+	for i, input := range tx.Inputs {
+		if !input.IsZeroInput() {
+			spends[i] = utxoTxs[i].OutputAt(input.OutIdx)
+		}
+	}
+
+	if multisig {
+		for _, spend := range spends {
+			if spend != nil && !spend.SameOwners(spends[0]) {
+				return nil, errors.New("selected outputs are locked to different owners")
+			}
+		}
+
+		if change := tx.OutputAt(1); change != nil && !change.IsZeroOutput() {
+			if err := tx.SetOwners(1, spends[0].Owners, spends[0].Threshold); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if payee.IsMultisig() {
+		if err := tx.SetOwners(0, payee.Owners, payee.Threshold); err != nil {
+			return nil, err
+		}
+	}
+
+	if payee.IsLocked() {
+		if err := tx.SetLock(0, payee.HashLock, payee.TimeLock, payee.RefundOwner); err != nil {
+			return nil, err
+		}
+	}
+
+	tx.SetMetadata(memo)
+
+	return &OfflineTransaction{
+		From:          from,
+		Transaction:   *tx,
+		Spends:        spends,
+		Domain:        domain,
+		SignatureHash: hexutil.Bytes(tx.SignatureHash(domain)),
+	}, nil
+}
+
+// Sign checks the signature hash and signs the inputs the signer owns. It
+// refuses to sign a fee above maxFee, unless maxFee is nil.
+func (o *OfflineTransaction) Sign(s signer.Signer, maxFee *big.Int) error {
+	if maxFee != nil && o.Transaction.Fee.Cmp(maxFee) > 0 {
+		return fmt.Errorf("fee %s is above the maximum of %s", o.Transaction.Fee, maxFee)
+	}
+
+	var indexes []int
+
+	for i, spend := range o.Spends {
+		if spend != nil && spend.IsOwner(s.Address()) {
+			indexes = append(indexes, i)
+		}
+	}
+
+	if len(indexes) == 0 {
+		return fmt.Errorf("signer %s owns none of the inputs", s.Address().Hex())
+	}
+
+	if !bytes.Equal(o.Transaction.SignatureHash(o.Domain), o.SignatureHash) {
+		return errors.New("signature hash does not match the transaction")
+	}
+
+	return o.Transaction.SignInputs(s, o.Domain, indexes...)
+}
+
+// CheckSignatures checks that every input carries the signatures its
+// owners need to spend it.
+func (o *OfflineTransaction) CheckSignatures() error {
+	hash := o.Transaction.SignatureHash(o.Domain)
+
+	for i, spend := range o.Spends {
+		if spend == nil {
+			continue
+		}
+
+		if err := spend.VerifySignature(hash, o.Transaction.SigAt(uint8(i))); err != nil {
+			return fmt.Errorf("input %d: %v", i, err)
+		}
+	}
+
+	return nil
+}
+
+func readOfflineTransaction(path string) (*OfflineTransaction, error) {
+	data, err := ioutil.ReadFile(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var o OfflineTransaction
+
+	if err := json.Unmarshal(data, &o); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", path, err)
+	}
+
+	return &o, nil
+}
+
+func writeOfflineTransaction(path string, o *OfflineTransaction) error {
+	data, err := json.MarshalIndent(o, "", "  ")
+
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, data, 0644)
+}
+
+func printOfflineTransaction(o *OfflineTransaction) {
+	tx := o.Transaction
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"", "Block Number", "Tx Index", "Out Index", "Owner", "Amount"})
+
+	for i, input := range tx.Inputs {
+		if !input.IsZeroInput() {
+			owner, amount := o.From.Hex(), ""
+
+			if i < len(o.Spends) && o.Spends[i] != nil {
+				owner, amount = formatOwner(o.Spends[i]), fmt.Sprint(o.Spends[i].Amount)
+			}
+
+			table.Append([]string{
+				fmt.Sprintf("Input%d", i),
+				fmt.Sprint(input.BlkNum),
+				fmt.Sprint(input.TxIdx),
+				fmt.Sprint(input.OutIdx),
+				owner,
+				amount,
+			})
+		}
+	}
+
+	for i, output := range tx.Outputs {
+		if !output.IsZeroOutput() {
+			table.Append([]string{
+				fmt.Sprintf("Output%d", i),
+				"",
+				"",
+				"",
+				formatOwner(output),
+				fmt.Sprint(output.Amount),
+			})
+		}
+	}
+
+	table.Render()
+
+	fmt.Printf("Fee: %s\n", tx.Fee)
+
+	if len(tx.Metadata) > 0 {
+		fmt.Printf("Memo: %q\n", tx.Metadata)
+	}
+
+	fmt.Printf("Chain ID: %s\n", o.Domain.ChainID)
+	fmt.Printf("Plasma contract: %s\n", o.Domain.VerifyingContract.Hex())
+	fmt.Printf("Signature hash: %s\n", o.SignatureHash)
+}
+
+// checkDomain checks the domain of a transaction file against chain-id and
+// contract-addr, when they are set, so that the signing machine does not
+// sign for another deployment than it is configured for.
+func checkDomain(c *cli.Context, domain chain.Domain) error {
+	if chainID := c.GlobalInt("chain-id"); chainID != 0 && (domain.ChainID == nil || domain.ChainID.Cmp(big.NewInt(int64(chainID))) != 0) {
+		return fmt.Errorf("transaction is for chain %s, not %d", domain.ChainID, chainID)
+	}
+
+	if contract := c.GlobalString("contract-addr"); contract != "" && common.HexToAddress(contract) != domain.VerifyingContract {
+		return fmt.Errorf("transaction is for plasma contract %s, not %s", domain.VerifyingContract.Hex(), contract)
+	}
+
+	return nil
+}
+
+// TxBuildCLI writes an unsigned payment from user-address, or from the
+// multisig outputs paid to from, to a file. The payment is locked to owners
+// when they are given.
+func TxBuildCLI(c *cli.Context) error {
+	rootUrl := fmt.Sprintf("http://localhost:%d/rpc", c.Int("root-port"))
+	from := c.String("from")
+	multisig := from != ""
+
+	if !multisig {
+		from = c.GlobalString("user-address")
+	}
+
+	if !common.IsHexAddress(from) {
+		return fmt.Errorf("invalid sender address %q", from)
+	}
+
+	payee := chain.NewOutput(common.HexToAddress(c.String("to")), big.NewInt(int64(c.Int("amount"))))
+
+	for _, owner := range c.StringSlice("owner") {
+		if !common.IsHexAddress(owner) {
+			return fmt.Errorf("invalid owner address %q", owner)
+		}
+
+		payee.Owners = append(payee.Owners, common.HexToAddress(owner))
+	}
+
+	if payee.IsMultisig() {
+		payee.Threshold = uint8(c.Int("threshold"))
+	}
+
+	domain, err := eth.DomainFromCLI(c)
+
+	if err != nil {
+		return err
+	}
+
+	o, err := BuildUnsignedSend(
+		rpcclient.NewClient(rootUrl),
+		common.HexToAddress(from),
+		payee,
+		multisig,
+		[]byte(c.String("memo")),
+		domain,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	printOfflineTransaction(o)
+	return writeOfflineTransaction(c.String("out"), o)
+}
+
+// TxSignCLI signs a transaction file with the configured signer. It does
+// not need a connection to the root node or Ethereum.
+func TxSignCLI(c *cli.Context) error {
+	o, err := readOfflineTransaction(c.String("in"))
+
+	if err != nil {
+		return err
+	}
+
+	printOfflineTransaction(o)
+
+	if err := checkDomain(c, o.Domain); err != nil {
+		return err
+	}
+
+	s, err := signer.FromCLI(c)
+
+	if err != nil {
+		return err
+	}
+
+	if err := o.Sign(s, big.NewInt(c.Int64("max-fee"))); err != nil {
+		return err
+	}
+
+	return writeOfflineTransaction(c.String("out"), o)
+}
+
+// TxFindCLI prints the transactions whose memo is exactly the given one.
+func TxFindCLI(c *cli.Context) error {
+	rootUrl := fmt.Sprintf("http://localhost:%d/rpc", c.Int("root-port"))
+
+	res, err := rpcclient.NewClient(rootUrl).FindByMetadata([]byte(c.String("memo")))
+
+	if err != nil {
+		return err
+	}
+
+	if len(res.Transactions) == 0 {
+		fmt.Println("No transactions found.")
+		return nil
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Hash", "Block Number", "Tx Index", "Outputs"})
+
+	for _, tx := range res.Transactions {
+		table.Append([]string{
+			common.ToHex(tx.Hash()),
+			fmt.Sprint(tx.BlkNum),
+			fmt.Sprint(tx.TxIdx),
+			formatOutputs(tx.Outputs),
+		})
+	}
+
+	table.Render()
+	return nil
+}
+
+// TxBroadcastCLI sends a signed transaction file to the root node.
+func TxBroadcastCLI(c *cli.Context) error {
+	rootUrl := fmt.Sprintf("http://localhost:%d/rpc", c.Int("root-port"))
+
+	o, err := readOfflineTransaction(c.String("in"))
+
+	if err != nil {
+		return err
+	}
+
+	if len(o.Transaction.SigAt(0)) == 0 {
+		return errors.New("transaction is not signed")
+	}
+
+	if err := o.CheckSignatures(); err != nil {
+		return err
+	}
+
+	result, err := rpcclient.NewClient(rootUrl).Send(&plasma_rpc.SendArgs{
+		Transaction: o.Transaction,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Transaction sent with hash: %s\n", common.ToHex(result.Transaction.Hash()))
+	return nil
+}
@@ -0,0 +1,100 @@
+package userclient
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/kyokan/plasma/chain"
+	"github.com/kyokan/plasma/signer"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_OfflineTransactionSignsAfterRoundTrip(t *testing.T) {
+	s, err := signer.GenerateMemorySigner()
+	require.NoError(t, err)
+
+	from := s.Address()
+	utxo := *chain.NewTransaction(nil, []*chain.Output{{NewOwner: from, Amount: big.NewInt(100)}}, big.NewInt(0))
+	utxo.BlkNum = 2
+
+	tx, err := chain.BuildSendTransaction(from, common.Address{1}, big.NewInt(40), []chain.Transaction{utxo})
+	require.NoError(t, err)
+	tx.SetMetadata([]byte("INV-1001"))
+
+	dir, err := ioutil.TempDir("", "plasma-offline")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "tx.json")
+	domain := chain.Domain{ChainID: big.NewInt(1), VerifyingContract: common.Address{0xaa}}
+	unsigned := &OfflineTransaction{
+		From:          from,
+		Transaction:   *tx,
+		Spends:        []*chain.Output{utxo.Outputs[0], nil},
+		Domain:        domain,
+		SignatureHash: hexutil.Bytes(tx.SignatureHash(domain)),
+	}
+	require.NoError(t, writeOfflineTransaction(path, unsigned))
+
+	o, err := readOfflineTransaction(path)
+	require.NoError(t, err)
+	require.NoError(t, o.Sign(s, big.NewInt(0)))
+	require.NoError(t, checkSignature(&o.Transaction, from, domain))
+
+	// The signature does not verify for another deployment.
+	testnet := chain.Domain{ChainID: big.NewInt(3), VerifyingContract: domain.VerifyingContract}
+	require.Error(t, checkSignature(&o.Transaction, from, testnet))
+
+	require.Equal(t, []byte("INV-1001"), o.Transaction.Metadata)
+
+	// Nor is a fee above the maximum.
+	o.Transaction.Fee = big.NewInt(5)
+	err = o.Sign(s, big.NewInt(4))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "above the maximum")
+	o.Transaction.Fee = big.NewInt(0)
+
+	// A transaction changed after it was built is not signed.
+	o.Transaction.Metadata = []byte("INV-1002")
+	require.Error(t, o.Sign(s, big.NewInt(0)))
+}
+
+func Test_OfflineTransactionCollectsMultisigSignatures(t *testing.T) {
+	var signers []signer.Signer
+	var owners []common.Address
+
+	for i := 0; i < 3; i++ {
+		s, err := signer.GenerateMemorySigner()
+		require.NoError(t, err)
+		signers = append(signers, s)
+		owners = append(owners, s.Address())
+	}
+
+	treasury := common.Address{0x7e}
+	spent := chain.NewMultisigOutput(treasury, big.NewInt(100), owners, 2)
+	tx := chain.NewTransaction([]*chain.Input{chain.NewInput(2, 0, 0)}, []*chain.Output{chain.NewOutput(common.Address{1}, big.NewInt(100))}, big.NewInt(0))
+
+	domain := chain.Domain{ChainID: big.NewInt(1), VerifyingContract: common.Address{0xaa}}
+	o := &OfflineTransaction{
+		From:          treasury,
+		Transaction:   *tx,
+		Spends:        []*chain.Output{spent, nil},
+		Domain:        domain,
+		SignatureHash: hexutil.Bytes(tx.SignatureHash(domain)),
+	}
+
+	outsider, err := signer.GenerateMemorySigner()
+	require.NoError(t, err)
+	require.Error(t, o.Sign(outsider, nil))
+
+	require.NoError(t, o.Sign(signers[0], nil))
+	require.Error(t, o.CheckSignatures())
+
+	require.NoError(t, o.Sign(signers[1], nil))
+	require.NoError(t, o.CheckSignatures())
+}
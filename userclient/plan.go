@@ -0,0 +1,203 @@
+package userclient
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/kyokan/plasma/chain"
+	"github.com/kyokan/plasma/eth"
+	plasma_rpc "github.com/kyokan/plasma/rpc"
+	"github.com/kyokan/plasma/rpc/rpcclient"
+	"github.com/kyokan/plasma/signer"
+	"github.com/kyokan/plasma/util"
+	"gopkg.in/urfave/cli.v1"
+)
+
+// inclusionPollInterval is how often the root node is asked whether a step
+// of a plan was included in a block.
+var inclusionPollInterval = time.Second
+
+// PlanClient is the part of the root node's RPC client that plans are
+// executed with.
+type PlanClient interface {
+	GetUTXOs(userAddress string) (*plasma_rpc.GetUTXOsResponse, error)
+	Send(args *plasma_rpc.SendArgs) (*plasma_rpc.SendResponse, error)
+}
+
+// PlanSend fetches from's UTXOs from the root node and plans a payment.
+func PlanSend(
+	rootClient *rpcclient.Client,
+	from common.Address,
+	to common.Address,
+	amount *big.Int,
+	strategy chain.Strategy,
+) (*chain.Plan, error) {
+	utxos, err := rootClient.GetUTXOs(util.AddressToHex(&from))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return chain.PlanPayment(from, to, amount, chain.UTXOsFor(from, utxos.Transactions), strategy)
+}
+
+// ExecutePlan signs the steps of a plan for domain and sends them in order.
+// Before a step that spends the output of an earlier one, it waits until
+// that step was included in a block. It returns the transactions it sent.
+func ExecutePlan(
+	ctx context.Context,
+	rootClient PlanClient,
+	plan *chain.Plan,
+	s signer.Signer,
+	domain chain.Domain,
+) ([]chain.Transaction, error) {
+	included := make(map[int]*chain.Transaction)
+	sent := make([]chain.Transaction, 0, len(plan.Steps))
+
+	for i := range plan.Steps {
+		for _, dep := range plan.DependsOn(i) {
+			if included[dep] != nil {
+				continue
+			}
+
+			tx, err := waitForInclusion(ctx, rootClient, plan.From, &sent[dep])
+
+			if err != nil {
+				return sent, err
+			}
+
+			included[dep] = tx
+		}
+
+		tx, err := plan.Build(i, included)
+
+		if err != nil {
+			return sent, err
+		}
+
+		if err := tx.Sign(s, domain); err != nil {
+			return sent, err
+		}
+
+		_, err = rootClient.Send(&plasma_rpc.SendArgs{Transaction: *tx})
+
+		if err != nil {
+			return sent, err
+		}
+
+		sent = append(sent, *tx)
+	}
+
+	return sent, nil
+}
+
+// waitForInclusion polls owner's UTXOs until tx shows up in a block. The
+// included copy has its block number and index set, so it is matched by
+// its struct hash, which leaves them out.
+func waitForInclusion(
+	ctx context.Context,
+	rootClient PlanClient,
+	owner common.Address,
+	tx *chain.Transaction,
+) (*chain.Transaction, error) {
+	hash := tx.StructHash()
+	tick := time.NewTicker(inclusionPollInterval)
+	defer tick.Stop()
+
+	for {
+		utxos, err := rootClient.GetUTXOs(util.AddressToHex(&owner))
+
+		if err == nil {
+			for i := range utxos.Transactions {
+				if bytes.Equal(utxos.Transactions[i].StructHash(), hash) {
+					return &utxos.Transactions[i], nil
+				}
+			}
+		}
+
+		select {
+		case <-tick.C:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// ConsolidateCLI merges the UTXOs of user-address, or of every wallet
+// account when a wallet is set, down to the target count.
+func ConsolidateCLI(c *cli.Context) error {
+	rootClient := rpcclient.NewClient(fmt.Sprintf("http://localhost:%d/rpc", c.Int("root-port")))
+	target := c.Int("target")
+
+	signers, err := consolidateSigners(c)
+
+	if err != nil {
+		return err
+	}
+
+	domain, err := eth.DomainFromCLI(c)
+
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.Duration("inclusion-timeout"))
+	defer cancel()
+
+	for _, s := range signers {
+		res, err := rootClient.Consolidate(s.Address().Hex(), target)
+
+		if err != nil {
+			return err
+		}
+
+		if len(res.Plan.Steps) == 0 {
+			fmt.Printf("%s has at most %d outputs.\n", s.Address().Hex(), target)
+			continue
+		}
+
+		fmt.Printf("Consolidating %s in %d transactions.\n", s.Address().Hex(), len(res.Plan.Steps))
+
+		txs, err := ExecutePlan(ctx, rootClient, res.Plan, s, domain)
+
+		for _, tx := range txs {
+			fmt.Printf("Transaction sent with hash: %s\n", common.ToHex(tx.Hash()))
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func consolidateSigners(c *cli.Context) ([]signer.Signer, error) {
+	if !usesWallet(c) {
+		s, err := signer.FromCLI(c)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return []signer.Signer{s}, nil
+	}
+
+	w, err := openWallet(c)
+
+	if err != nil {
+		return nil, err
+	}
+
+	passphrase, err := walletPassphrase(c)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return w.Signers(passphrase)
+}
@@ -0,0 +1,75 @@
+package userclient
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/kyokan/plasma/chain"
+	plasma_rpc "github.com/kyokan/plasma/rpc"
+	"github.com/kyokan/plasma/signer"
+	"github.com/stretchr/testify/require"
+)
+
+// stubRootClient includes every transaction it is sent in a block of its
+// own, and returns all of them as UTXOs.
+type stubRootClient struct {
+	mtx    sync.Mutex
+	blkNum uint64
+	txs    []chain.Transaction
+}
+
+func (c *stubRootClient) GetUTXOs(userAddress string) (*plasma_rpc.GetUTXOsResponse, error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	return &plasma_rpc.GetUTXOsResponse{Transactions: append([]chain.Transaction(nil), c.txs...)}, nil
+}
+
+func (c *stubRootClient) Send(args *plasma_rpc.SendArgs) (*plasma_rpc.SendResponse, error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	c.blkNum++
+	tx := args.Transaction
+	tx.BlkNum = c.blkNum
+	c.txs = append(c.txs, tx)
+	return &plasma_rpc.SendResponse{Transaction: &tx}, nil
+}
+
+func Test_ExecutePlanWaitsForMerges(t *testing.T) {
+	inclusionPollInterval = time.Millisecond
+
+	s, err := signer.GenerateMemorySigner()
+	require.NoError(t, err)
+
+	client := &stubRootClient{blkNum: 100}
+
+	for i := 1; i <= 6; i++ {
+		utxo := *chain.NewTransaction(nil, []*chain.Output{chain.NewOutput(s.Address(), big.NewInt(10))}, big.NewInt(0))
+		utxo.BlkNum = uint64(i)
+		client.txs = append(client.txs, utxo)
+	}
+
+	to := common.Address{1}
+	plan, err := chain.PlanPayment(s.Address(), to, big.NewInt(55), chain.UTXOsFor(s.Address(), client.txs), chain.ExactMatch{})
+	require.NoError(t, err)
+	require.Len(t, plan.Steps, 2)
+
+	domain := chain.Domain{ChainID: big.NewInt(1), VerifyingContract: common.Address{0xaa}}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	sent, err := ExecutePlan(ctx, client, plan, s, domain)
+	require.NoError(t, err)
+	require.Len(t, sent, 2)
+
+	// The payment spends the merged output at the block it was included in.
+	payment := sent[1]
+	require.Equal(t, uint64(101), payment.Inputs[3].BlkNum)
+	require.Equal(t, to, payment.Outputs[0].NewOwner)
+	require.Equal(t, big.NewInt(55), payment.Outputs[0].Amount)
+}
@@ -1,25 +1,37 @@
 package userclient
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"log"
 	"math/big"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/kyokan/plasma/chain"
 	"github.com/kyokan/plasma/eth"
+	"github.com/kyokan/plasma/signer"
 	"github.com/kyokan/plasma/util"
 	"gopkg.in/urfave/cli.v1"
 )
 
-func Finalize(c *cli.Context) {
+func Finalize(c *cli.Context) error {
 	plasma := eth.CreatePlasmaClientCLI(c)
-	plasma.Finalize()
+	ctx := context.Background()
+
+	sub, err := plasma.Finalize(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = sub.Wait(ctx)
+	return err
 }
 
-func StartExit(c *cli.Context) {
-	plasma := eth.CreatePlasmaClientCLI(c)
+func StartExit(c *cli.Context) error {
+	ctx := context.Background()
 
 	rootUrl := fmt.Sprintf("http://localhost:%d/rpc", c.Int("root-port"))
 	blocknum := c.Int("blocknum")
@@ -32,20 +44,228 @@ func StartExit(c *cli.Context) {
 	res := rootClient.GetBlock(uint64(blocknum))
 
 	if res == nil {
-		log.Fatalln("Block does not exist!")
+		return errors.New("block does not exist")
+	}
+
+	if txindex < 0 || txindex >= len(res.Transactions) || oindex < 0 || oindex >= len(res.Transactions[txindex].Outputs) {
+		return errors.New("output does not exist")
+	}
+
+	var preimage []byte
+
+	if c.String("preimage") != "" {
+		decoded, err := hexutil.Decode(c.String("preimage"))
+
+		if err != nil {
+			return fmt.Errorf("invalid preimage: %v", err)
+		}
+
+		preimage = decoded
+	}
+
+	output := res.Transactions[txindex].OutputAt(uint8(oindex))
+	claim := len(preimage) > 0
+	owner, err := exitOwner(c, output, claim)
+
+	if err != nil {
+		return err
+	}
+
+	plasma, err := exitClient(c, owner)
+
+	if err != nil {
+		return err
+	}
+
+	var sigs []byte
+
+	if output.IsMultisig() && exitsToOwner(output, claim) {
+		hash := chain.ExitHash(common.HexToAddress(c.GlobalString("contract-addr")), uint64(blocknum), uint32(txindex), uint8(oindex))
+		sigs, err = exitSignatures(c, output, hash)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	var sub *eth.Submission
+
+	switch {
+	case claim:
+		sub, err = plasma.StartClaimExit(
+			ctx,
+			res.Block,
+			res.Transactions,
+			util.NewInt(blocknum),
+			util.NewInt(txindex),
+			util.NewInt(oindex),
+			preimage,
+			sigs,
+		)
+	case len(sigs) > 0:
+		sub, err = plasma.StartMultisigExit(
+			ctx,
+			res.Block,
+			res.Transactions,
+			util.NewInt(blocknum),
+			util.NewInt(txindex),
+			util.NewInt(oindex),
+			sigs,
+		)
+	default:
+		sub, err = plasma.StartExit(
+			ctx,
+			res.Block,
+			res.Transactions,
+			util.NewInt(blocknum),
+			util.NewInt(txindex),
+			util.NewInt(oindex),
+		)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	_, err = sub.Wait(ctx)
+	return err
+}
+
+// SignExitCLI prints the signature of the user over the exit of a multisig
+// output, to be passed to exit with --sig.
+func SignExitCLI(c *cli.Context) error {
+	s, err := signer.FromCLI(c)
+
+	if err != nil {
+		return err
+	}
+
+	hash := chain.ExitHash(
+		common.HexToAddress(c.GlobalString("contract-addr")),
+		uint64(c.Int("blocknum")),
+		uint32(c.Int("txindex")),
+		uint8(c.Int("oindex")),
+	)
+
+	sig, err := s.SignHash(hash)
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(hexutil.Encode(sig))
+	return nil
+}
+
+// exitsToOwner returns whether output exits to its owner rather than to its
+// refund owner: a hash-locked output only does when it is claimed with the
+// preimage.
+func exitsToOwner(output *chain.Output, claim bool) bool {
+	return claim || len(output.HashLock) == 0 || output.RefundOwner == nil
+}
+
+// exitSignatures collects the signatures over hash passed with --sig, and
+// those of the owners in the wallet, until output's threshold is met.
+func exitSignatures(c *cli.Context, output *chain.Output, hash util.Hash) ([]byte, error) {
+	var sigs [][]byte
+
+	for _, encoded := range c.StringSlice("sig") {
+		sig, err := hexutil.Decode(encoded)
+
+		if err != nil {
+			return nil, err
+		}
+
+		sigs = append(sigs, sig)
+	}
+
+	if usesWallet(c) {
+		w, err := openWallet(c)
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, account := range w.Accounts() {
+			if len(sigs) >= int(output.Threshold) {
+				break
+			}
+
+			if !output.IsOwner(account.Address) {
+				continue
+			}
+
+			s, err := walletSigner(c, account.Address)
+
+			if err != nil {
+				return nil, err
+			}
+
+			sig, err := s.SignHash(hash)
+
+			if err != nil {
+				return nil, err
+			}
+
+			sigs = append(sigs, sig)
+		}
+	}
+
+	return output.ExitSignatures(hash, sigs)
+}
+
+// exitOwner returns the account that starts the exit of output. The exit of
+// a multisig output carries its owners' signatures, so anyone can send it:
+// the first owner in the wallet does. A hash-locked output exits to its
+// refund owner, unless its owner claims it.
+func exitOwner(c *cli.Context, output *chain.Output, claim bool) (common.Address, error) {
+	if !exitsToOwner(output, claim) {
+		return *output.RefundOwner, nil
 	}
 
-	plasma.StartExit(
-		res.Block,
-		res.Transactions,
-		util.NewInt(blocknum),
-		util.NewInt(txindex),
-		util.NewInt(oindex),
+	if !output.IsMultisig() || !usesWallet(c) {
+		return output.NewOwner, nil
+	}
+
+	w, err := openWallet(c)
+
+	if err != nil {
+		return common.Address{}, err
+	}
+
+	for _, account := range w.Accounts() {
+		if output.IsOwner(account.Address) {
+			return account.Address, nil
+		}
+	}
+
+	return common.Address{}, errors.New("no owner of the output is in the wallet")
+}
+
+// exitClient returns a plasma client that sends from owner's wallet account
+// when a wallet is set, and from user-address otherwise.
+func exitClient(c *cli.Context, owner common.Address) (*eth.PlasmaClient, error) {
+	if !usesWallet(c) {
+		return eth.CreatePlasmaClientCLI(c), nil
+	}
+
+	s, err := walletSigner(c, owner)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return eth.CreatePlasmaClient(
+		c.GlobalString("node-url"),
+		c.GlobalString("contract-addr"),
+		s,
+		eth.TxManagerConfigFromCLI(c),
 	)
 }
 
-func Deposit(c *cli.Context) {
+func Deposit(c *cli.Context) error {
 	plasma := eth.CreatePlasmaClientCLI(c)
+	ctx := context.Background()
 
 	userAddress := c.GlobalString("user-address")
 	amount := uint64(c.Int("amount"))
@@ -54,29 +274,39 @@ func Deposit(c *cli.Context) {
 
 	t := createDepositTx(userAddress, amount)
 
-	plasma.Deposit(amount, &t)
+	sub, err := plasma.Deposit(ctx, amount, &t)
 
-	time.Sleep(3 * time.Second)
+	if err != nil {
+		return err
+	}
+
+	if _, err := sub.Wait(ctx); err != nil {
+		return err
+	}
 
-	curr, err := plasma.CurrentChildBlock()
+	var curr *big.Int
+
+	// The deposit is mined, a lost connection is worth waiting out.
+	err = eth.Retry(ctx, 3*time.Second, func() error {
+		var err error
+		curr, err = plasma.CurrentChildBlock(ctx)
+		return err
+	})
 
 	if err != nil {
-		log.Fatalf("Failed to get current child block: %v", err)
+		return err
 	}
 
 	fmt.Printf("Last child block: %v\n", curr)
+	return nil
 }
 
 // TODO: Use same code as transaction sink.
 func createDepositTx(userAddress string, value uint64) chain.Transaction {
-	return chain.Transaction{
-		Input0: chain.ZeroInput(),
-		Input1: chain.ZeroInput(),
-		Output0: &chain.Output{
-			NewOwner: common.HexToAddress(userAddress),
-			Amount:   util.NewUint64(value),
-		},
-		Output1: chain.ZeroOutput(),
-		Fee:     big.NewInt(0),
+	output := &chain.Output{
+		NewOwner: common.HexToAddress(userAddress),
+		Amount:   util.NewUint64(value),
 	}
+
+	return *chain.NewTransaction(nil, []*chain.Output{output}, big.NewInt(0))
 }
@@ -1,33 +1,26 @@
 package userclient
 
 import (
-	"bytes"
+	"context"
 	"fmt"
 	"log"
 	"math/big"
-	"net/http"
 	"os"
-
-	encoding_json "encoding/json"
+	"strings"
 
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/gorilla/rpc/json"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/kyokan/plasma/chain"
 	"github.com/kyokan/plasma/eth"
 	plasma_rpc "github.com/kyokan/plasma/rpc"
-	"github.com/kyokan/plasma/chain"
+	"github.com/kyokan/plasma/rpc/rpcclient"
+	"github.com/kyokan/plasma/signer"
 	"github.com/olekukonko/tablewriter"
 	"gopkg.in/urfave/cli.v1"
 )
 
-// clientResponse represents a JSON-RPC response returned to a client.
-type ClientResponse struct {
-	Result *encoding_json.RawMessage `json:"result"`
-	Error  interface{}               `json:"error"`
-	Id     uint64                    `json:"id"`
-}
-
 type client struct {
-	RootURL string
+	rpc *rpcclient.Client
 }
 
 type RootClient interface {
@@ -36,66 +29,82 @@ type RootClient interface {
 }
 
 func NewRootClient(rootURL string) RootClient {
-	c := client{RootURL: rootURL}
+	c := client{rpc: rpcclient.NewClient(rootURL)}
 	return &c
 }
 
 func SendCLI(c *cli.Context) {
-	userAddress := c.GlobalString("user-address")
 	rootUrl := fmt.Sprintf("http://localhost:%d/rpc", c.Int("root-port"))
-	nodeUrl := c.GlobalString("node-url")
-	toAddr  := c.String("to")
-	amount  := int64(c.Int("amount"))
+	toAddr := c.String("to")
+	amount := big.NewInt(int64(c.Int("amount")))
+	memo := []byte(c.String("memo"))
 
-	log.Printf("Sending amount: %d to: %s\n", amount, toAddr)
+	if len(memo) > chain.MaxMetadataSize {
+		log.Printf("Could not build transaction for send: memo is longer than %d bytes", chain.MaxMetadataSize)
+		return
+	}
 
-	client, err := eth.NewClient(nodeUrl)
+	strategy, err := chain.StrategyByName(c.String("strategy"))
 
-	utxosEndpoint := "Block.GetUTXOs"
-	utxosArgs := &plasma_rpc.GetUTXOsArgs{
-		UserAddress: userAddress,
+	if err != nil {
+		log.Printf("Could not build transaction for send: %s", err.Error())
+		return
 	}
-	utxosResponse := request(rootUrl, utxosArgs, utxosEndpoint)
-	if utxosResponse == nil {
-		log.Printf("Failed to get UTXOs for %s", userAddress)
+
+	log.Printf("Sending amount: %d to: %s\n", amount, toAddr)
+
+	rootClient := rpcclient.NewClient(rootUrl)
+	s, err := sendSigner(c, rootClient, amount)
+
+	if err != nil {
+		log.Printf("Could not build transaction for send: %s", err.Error())
 		return
 	}
-	var utxos plasma_rpc.GetUTXOsResponse
-	err = encoding_json.Unmarshal(*utxosResponse, &utxos)
+
+	domain, err := eth.DomainFromCLI(c)
+
 	if err != nil {
-		log.Printf("Failed to unmarshal UTXO response: %s", err.Error())
+		log.Printf("Could not build transaction for send: %s", err.Error())
 		return
 	}
-	tx, err := chain.FindBestUTXOs(common.HexToAddress(userAddress), common.HexToAddress(toAddr), big.NewInt(amount), utxos.Transactions, client)
+
+	plan, err := PlanSend(rootClient, s.Address(), common.HexToAddress(toAddr), amount, strategy)
+
 	if err != nil {
-		log.Printf("Could not find a suitable input for send: %s", err.Error())
+		log.Printf("Could not build transaction for send: %s", err.Error())
 		return
 	}
 
+	// The memo goes on the payment, not on the consolidating steps.
+	plan.Steps[len(plan.Steps)-1].Metadata = memo
 
-	sendArgs := &plasma_rpc.SendArgs{
-		Transaction: *tx,
-		From:   userAddress,
-		To:     toAddr,
-		Amount: fmt.Sprintf("%d", amount),
+	if len(plan.Steps) > 1 {
+		log.Printf("Consolidating outputs in %d transactions before paying.\n", len(plan.Steps)-1)
 	}
-	sendEndpoint  := "Transaction.Send"
 
-	sendResponse := request(rootUrl, sendArgs, sendEndpoint)
+	ctx, cancel := context.WithTimeout(context.Background(), c.Duration("inclusion-timeout"))
+	defer cancel()
 
-	if sendResponse != nil {
-		var result plasma_rpc.SendResponse
+	txs, err := ExecutePlan(ctx, rootClient, plan, s, domain)
 
-		err = encoding_json.Unmarshal(*sendResponse, &result)
-		if err != nil {
-			log.Printf("Failed to unmarshal response after sending transaction: %s", err.Error())
-			return
-		}
+	for _, tx := range txs {
+		log.Printf("Transaction sent with hash: %s\n", common.ToHex(tx.Hash()))
+	}
 
-		log.Printf("Transaction sent with hash: %v\n", result.Transaction.Hash)
-	} else {
-		fmt.Println("Transction failed no repsonse given\n")
+	if err != nil {
+		log.Printf("Transaction failed: %s", err.Error())
+	}
+}
+
+// sendSigner returns the signer of the account a payment is sent from: the
+// first wallet account that can afford amount when a wallet is set, and
+// user-address otherwise.
+func sendSigner(c *cli.Context, rootClient *rpcclient.Client, amount *big.Int) (signer.Signer, error) {
+	if usesWallet(c) {
+		return walletPayer(c, rootClient, amount)
 	}
+
+	return signer.FromCLI(c)
 }
 
 func GetBlockCLI(c *cli.Context) {
@@ -128,106 +137,104 @@ func GetBlockCLI(c *cli.Context) {
 			"Hash",
 			"Block Number",
 			"Tx Index",
-			"Input0 BlkNum",
-			"Input0 TxIdx",
-			"Input0 OutIdx",
-			"Input1 BlkNum",
-			"Input1 TxIdx",
-			"Input1 OutIdx",
-			"Output0 NewOwner",
-			"Output0 Amount",
-			"Output1 NewOwner",
-			"Output1 Amount",
+			"Inputs",
+			"Outputs",
 		})
 		for _, tx := range txs {
 			table2.Append([]string{
 				common.ToHex(tx.Hash()),
 				fmt.Sprint(tx.BlkNum),
 				fmt.Sprint(tx.TxIdx),
-				fmt.Sprint(tx.Input0.BlkNum),
-				fmt.Sprint(tx.Input0.TxIdx),
-				fmt.Sprint(tx.Input0.OutIdx),
-				fmt.Sprint(tx.Input1.BlkNum),
-				fmt.Sprint(tx.Input1.TxIdx),
-				fmt.Sprint(tx.Input1.OutIdx),
-				tx.Output0.NewOwner.Hex(),
-				fmt.Sprint(tx.Output0.Amount),
-				tx.Output1.NewOwner.Hex(),
-				fmt.Sprint(tx.Output1.Amount),
+				formatInputs(tx.Inputs),
+				formatOutputs(tx.Outputs),
 			})
 		}
 
 		table2.Render()
 	} else {
-		fmt.Println("Transaction failed no repsonse given\n")
+		fmt.Println("Transaction failed no repsonse given")
 	}
 }
 
-func (c client) GetBlock(height uint64) *plasma_rpc.GetBlocksResponse {
-	args := &plasma_rpc.GetBlocksArgs{
-		Height: height,
-	}
-	endpoint := "Block.GetBlock"
+// formatInputs lists the inputs as blknum:txidx:outidx, one per line.
+func formatInputs(inputs []*chain.Input) string {
+	var lines []string
 
-	response := request(c.RootURL, args, endpoint)
+	for _, input := range inputs {
+		lines = append(lines, fmt.Sprintf("%d:%d:%d", input.BlkNum, input.TxIdx, input.OutIdx))
+	}
 
-	if response != nil {
-		var result plasma_rpc.GetBlocksResponse
+	return strings.Join(lines, "\n")
+}
 
-		encoding_json.Unmarshal(*response, &result)
+// formatOutputs lists the outputs as owner and amount, one per line.
+func formatOutputs(outputs []*chain.Output) string {
+	var lines []string
 
-		return &result
+	for _, output := range outputs {
+		lines = append(lines, fmt.Sprintf("%s %s", formatOwner(output), output.Amount))
 	}
 
-	return nil
+	return strings.Join(lines, "\n")
 }
 
-func (c client) GetUTXOs(userAddress string) *plasma_rpc.GetUTXOsResponse {
-	args := &plasma_rpc.GetUTXOsArgs{
-		UserAddress: userAddress,
+// formatOwner returns the owner of output, followed by the threshold and
+// owners of a multisig output, the conditions of a locked output and the
+// token of a token output.
+func formatOwner(output *chain.Output) string {
+	owner := output.NewOwner.Hex()
+
+	if output.IsMultisig() {
+		owners := make([]string, len(output.Owners))
+
+		for i, o := range output.Owners {
+			owners[i] = o.Hex()
+		}
+
+		owner = fmt.Sprintf("%s (%d of %s)", owner, output.Threshold, strings.Join(owners, ", "))
 	}
-	endpoint := "Block.GetUTXOs"
 
-	response := request(c.RootURL, args, endpoint)
+	if len(output.HashLock) > 0 {
+		owner = fmt.Sprintf("%s [hash lock %s]", owner, hexutil.Encode(output.HashLock))
+	}
 
-	if response != nil {
-		var result plasma_rpc.GetUTXOsResponse
+	if output.TimeLock > 0 {
+		owner = fmt.Sprintf("%s [time lock %d]", owner, output.TimeLock)
+	}
 
-		encoding_json.Unmarshal(*response, &result)
+	if output.RefundOwner != nil {
+		owner = fmt.Sprintf("%s [refund %s]", owner, output.RefundOwner.Hex())
+	}
 
-		return &result
+	if output.IsToken() {
+		owner = fmt.Sprintf("%s [token %#x]", owner, output.TokenID)
 	}
 
-	return nil
+	return owner
 }
 
-func request(url string, args interface{}, endpoint string) *encoding_json.RawMessage {
-	message, err := json.EncodeClientRequest(endpoint, args)
-	if err != nil {
-		log.Fatalf("%s", err)
-	}
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(message))
-	if err != nil {
-		log.Fatalf("%s", err)
+func (c client) GetBlock(height uint64) *plasma_rpc.GetBlocksResponse {
+	response, err := c.rpc.GetBlock(height)
+
+	if rpcErr, ok := err.(*plasma_rpc.Error); ok && rpcErr.Code == plasma_rpc.CodeNotFound {
+		return nil
 	}
-	req.Header.Set("Content-Type", "application/json")
-	client := new(http.Client)
-	resp, err := client.Do(req)
+
 	if err != nil {
-		log.Fatalf("Error in sending request to %s. %s", url, err)
+		log.Printf("Failed to get block %d: %v", height, err)
+		return nil
 	}
-	defer resp.Body.Close()
 
-	var c ClientResponse
-	err = encoding_json.NewDecoder(resp.Body).Decode(&c)
+	return response
+}
 
-	if err != nil {
-		log.Fatalf("Failed to decode to json: %v", err)
-	}
+func (c client) GetUTXOs(userAddress string) *plasma_rpc.GetUTXOsResponse {
+	response, err := c.rpc.GetUTXOs(userAddress)
 
-	if c.Error == nil {
-		return c.Result
+	if err != nil {
+		log.Printf("Failed to get UTXOs for %s: %v", userAddress, err)
+		return nil
 	}
 
-	return nil
+	return response
 }
@@ -0,0 +1,242 @@
+package userclient
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/kyokan/plasma/chain"
+	"github.com/kyokan/plasma/eth"
+	"github.com/kyokan/plasma/rpc/rpcclient"
+	"github.com/kyokan/plasma/signer"
+	"github.com/kyokan/plasma/util"
+	"github.com/olekukonko/tablewriter"
+	"gopkg.in/urfave/cli.v1"
+)
+
+// ProposeSwap builds a swap in which s pays pay to counterparty and
+// receives receive from it, each from a single UTXO, signs s's input and
+// posts the proposal to the root node.
+func ProposeSwap(
+	rootClient *rpcclient.Client,
+	s signer.Signer,
+	counterparty common.Address,
+	pay *big.Int,
+	receive *big.Int,
+	domain chain.Domain,
+) (*chain.Swap, error) {
+	proposer := s.Address()
+	mine, err := swapUTXO(rootClient, proposer, pay)
+
+	if err != nil {
+		return nil, err
+	}
+
+	theirs, err := swapUTXO(rootClient, counterparty, receive)
+
+	if err != nil {
+		return nil, fmt.Errorf("counterparty: %v", err)
+	}
+
+	tx, err := chain.BuildSwap(
+		chain.SwapLeg{Owner: proposer, UTXO: *mine, Pays: pay},
+		chain.SwapLeg{Owner: counterparty, UTXO: *theirs, Pays: receive},
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	swap := &chain.Swap{
+		Transaction:  *tx,
+		Proposer:     proposer,
+		Counterparty: counterparty,
+	}
+
+	spends := []*chain.Output{chain.NewOutput(proposer, mine.Amount), chain.NewOutput(counterparty, theirs.Amount)}
+
+	if err := swap.Sign(s, domain, spends); err != nil {
+		return nil, err
+	}
+
+	if _, err := rootClient.ProposeSwap(swap); err != nil {
+		return nil, err
+	}
+
+	return swap, nil
+}
+
+// AcceptSwap signs the inputs of swap owned by s and sends the transaction.
+func AcceptSwap(rootClient *rpcclient.Client, s signer.Signer, swap *chain.Swap, domain chain.Domain) (*chain.Transaction, error) {
+	owner := s.Address()
+
+	if swap.Counterparty != owner {
+		return nil, errors.New("swap is not addressed to the signer")
+	}
+
+	utxos, err := rootClient.GetUTXOs(util.AddressToHex(&owner))
+
+	if err != nil {
+		return nil, err
+	}
+
+	spends := make([]*chain.Output, len(swap.Transaction.Inputs))
+
+	for i, input := range swap.Transaction.Inputs {
+		for _, u := range chain.UTXOsFor(owner, utxos.Transactions) {
+			if *u.Input() == *input {
+				spends[i] = chain.NewOutput(owner, u.Amount)
+			}
+		}
+	}
+
+	if err := swap.Sign(s, domain, spends); err != nil {
+		return nil, err
+	}
+
+	res, err := rootClient.AcceptSwap(&swap.Transaction)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return res.Transaction, nil
+}
+
+// swapUTXO returns the smallest of owner's UTXOs that covers amount. A swap
+// spends a single UTXO of each party.
+func swapUTXO(rootClient *rpcclient.Client, owner common.Address, amount *big.Int) (*chain.UTXO, error) {
+	res, err := rootClient.GetUTXOs(util.AddressToHex(&owner))
+
+	if err != nil {
+		return nil, err
+	}
+
+	selected := chain.SmallestSufficient{}.Select(chain.UTXOsFor(owner, res.Transactions), amount)
+
+	if len(selected) != 1 {
+		return nil, fmt.Errorf("no single output of %s covers %s, consolidate first", owner.Hex(), amount)
+	}
+
+	return &selected[0], nil
+}
+
+func SwapProposeCLI(c *cli.Context) error {
+	rootUrl := fmt.Sprintf("http://localhost:%d/rpc", c.Int("root-port"))
+	counterparty := c.String("counterparty")
+
+	if !common.IsHexAddress(counterparty) {
+		return fmt.Errorf("invalid counterparty address %q", counterparty)
+	}
+
+	s, err := signer.FromCLI(c)
+
+	if err != nil {
+		return err
+	}
+
+	domain, err := eth.DomainFromCLI(c)
+
+	if err != nil {
+		return err
+	}
+
+	swap, err := ProposeSwap(
+		rpcclient.NewClient(rootUrl),
+		s,
+		common.HexToAddress(counterparty),
+		big.NewInt(int64(c.Int("pay"))),
+		big.NewInt(int64(c.Int("receive"))),
+		domain,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Swap proposed with id: %s\n", swap.ID().Hex())
+	return nil
+}
+
+func SwapListCLI(c *cli.Context) error {
+	rootUrl := fmt.Sprintf("http://localhost:%d/rpc", c.Int("root-port"))
+	userAddress := c.GlobalString("user-address")
+
+	res, err := rpcclient.NewClient(rootUrl).PendingSwaps(userAddress)
+
+	if err != nil {
+		return err
+	}
+
+	if len(res.Swaps) == 0 {
+		fmt.Println("No pending swaps.")
+		return nil
+	}
+
+	printSwaps(res.Swaps)
+	return nil
+}
+
+func SwapAcceptCLI(c *cli.Context) error {
+	rootUrl := fmt.Sprintf("http://localhost:%d/rpc", c.Int("root-port"))
+	id := common.HexToHash(c.String("id"))
+	rootClient := rpcclient.NewClient(rootUrl)
+
+	s, err := signer.FromCLI(c)
+
+	if err != nil {
+		return err
+	}
+
+	domain, err := eth.DomainFromCLI(c)
+
+	if err != nil {
+		return err
+	}
+
+	owner := s.Address()
+	res, err := rootClient.PendingSwaps(util.AddressToHex(&owner))
+
+	if err != nil {
+		return err
+	}
+
+	for _, swap := range res.Swaps {
+		if swap.ID() != id {
+			continue
+		}
+
+		printSwaps([]chain.Swap{swap})
+
+		tx, err := AcceptSwap(rootClient, s, &swap, domain)
+
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Swap sent with hash: %s\n", common.ToHex(tx.Hash()))
+		return nil
+	}
+
+	return fmt.Errorf("no pending swap %s", id.Hex())
+}
+
+func printSwaps(swaps []chain.Swap) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"ID", "Proposer", "Inputs", "Outputs", "Expires"})
+
+	for _, swap := range swaps {
+		table.Append([]string{
+			swap.ID().Hex(),
+			swap.Proposer.Hex(),
+			formatInputs(swap.Transaction.Inputs),
+			formatOutputs(swap.Transaction.Outputs),
+			time.Unix(int64(swap.Expires), 0).Format(time.RFC3339),
+		})
+	}
+
+	table.Render()
+}
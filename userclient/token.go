@@ -0,0 +1,233 @@
+package userclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/kyokan/plasma/chain"
+	"github.com/kyokan/plasma/eth"
+	"github.com/kyokan/plasma/rpc/rpcclient"
+	"github.com/kyokan/plasma/signer"
+	"gopkg.in/urfave/cli.v1"
+)
+
+// TokenHistory returns the proofs of what happened to tokenID in every
+// block that sends tokens since its deposit, after checking them against
+// the block headers and checking that each transfer spends the previous
+// one.
+func TokenHistory(rootClient *rpcclient.Client, tokenID uint64) ([]chain.TokenProof, error) {
+	res, err := rootClient.GetTokenHistory(tokenID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var last *chain.Input
+
+	for i := range res.Proofs {
+		proof := &res.Proofs[i]
+		block, err := rootClient.GetBlock(proof.BlkNum)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if err := proof.Verify(tokenID, block.Block.Header); err != nil {
+			return nil, err
+		}
+
+		tx := proof.Transaction
+
+		if tx == nil {
+			continue
+		}
+
+		if last != nil && !spends(tx, last) {
+			return nil, fmt.Errorf("transfer in block %d does not spend the token", proof.BlkNum)
+		}
+
+		outIdx, _ := tx.TokenOutput(tokenID)
+		last = chain.NewInput(tx.BlkNum, tx.TxIdx, outIdx)
+	}
+
+	if last == nil {
+		return nil, fmt.Errorf("token %d has no deposit", tokenID)
+	}
+
+	return res.Proofs, nil
+}
+
+// SendToken sends tokenID, which s must own, to the address to.
+func SendToken(rootClient *rpcclient.Client, s signer.Signer, tokenID uint64, to common.Address, domain chain.Domain) (*chain.Transaction, error) {
+	proofs, err := TokenHistory(rootClient, tokenID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var current *chain.Transaction
+
+	for _, proof := range proofs {
+		if proof.Transaction != nil {
+			current = proof.Transaction
+		}
+	}
+
+	outIdx, _ := current.TokenOutput(tokenID)
+
+	if !current.Outputs[outIdx].IsOwner(s.Address()) {
+		return nil, errors.New("token is not owned by the sender")
+	}
+
+	tx := chain.NewTransaction(
+		[]*chain.Input{chain.NewInput(current.BlkNum, current.TxIdx, outIdx)},
+		[]*chain.Output{chain.NewTokenOutput(to, tokenID)},
+		big.NewInt(0),
+	)
+
+	if err := tx.Sign(s, domain); err != nil {
+		return nil, err
+	}
+
+	return sendSigned(rootClient, tx)
+}
+
+func spends(tx *chain.Transaction, input *chain.Input) bool {
+	for _, in := range tx.Inputs {
+		if *in == *input {
+			return true
+		}
+	}
+
+	return false
+}
+
+func DepositNFTCLI(c *cli.Context) error {
+	plasma := eth.CreatePlasmaClientCLI(c)
+	ctx := context.Background()
+
+	token := c.String("token")
+
+	if !common.IsHexAddress(token) {
+		return fmt.Errorf("invalid token contract address %q", token)
+	}
+
+	id, ok := new(big.Int).SetString(c.String("id"), 0)
+
+	if !ok {
+		return fmt.Errorf("invalid token id %q", c.String("id"))
+	}
+
+	owner := common.HexToAddress(c.GlobalString("user-address"))
+	tokenID := chain.TokenIDFor(common.HexToAddress(token), id)
+
+	sub, err := plasma.DepositNFT(ctx, common.HexToAddress(token), id, chain.NewTokenDeposit(owner, tokenID))
+
+	if err != nil {
+		return err
+	}
+
+	if _, err := sub.Wait(ctx); err != nil {
+		return err
+	}
+
+	fmt.Printf("Token deposited with plasma id: %#x\n", tokenID)
+	return nil
+}
+
+func TokenSendCLI(c *cli.Context) error {
+	rootUrl := fmt.Sprintf("http://localhost:%d/rpc", c.Int("root-port"))
+	to := c.String("to")
+
+	if !common.IsHexAddress(to) {
+		return fmt.Errorf("invalid recipient address %q", to)
+	}
+
+	tokenID, err := tokenIDFromCLI(c)
+
+	if err != nil {
+		return err
+	}
+
+	s, err := signer.FromCLI(c)
+
+	if err != nil {
+		return err
+	}
+
+	domain, err := eth.DomainFromCLI(c)
+
+	if err != nil {
+		return err
+	}
+
+	tx, err := SendToken(rpcclient.NewClient(rootUrl), s, tokenID, common.HexToAddress(to), domain)
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Token sent with hash: %s\n", common.ToHex(tx.Hash()))
+	return nil
+}
+
+func TokenHistoryCLI(c *cli.Context) error {
+	rootUrl := fmt.Sprintf("http://localhost:%d/rpc", c.Int("root-port"))
+	tokenID, err := tokenIDFromCLI(c)
+
+	if err != nil {
+		return err
+	}
+
+	proofs, err := TokenHistory(rpcclient.NewClient(rootUrl), tokenID)
+
+	if err != nil {
+		return err
+	}
+
+	for _, proof := range proofs {
+		if proof.Transaction == nil {
+			fmt.Printf("Block %d: not sent\n", proof.BlkNum)
+			continue
+		}
+
+		outIdx, _ := proof.Transaction.TokenOutput(tokenID)
+		fmt.Printf("Block %d: sent to %s in transaction %d\n", proof.BlkNum, proof.Transaction.Outputs[outIdx].NewOwner.Hex(), proof.Transaction.TxIdx)
+	}
+
+	return nil
+}
+
+func TokenWithdrawCLI(c *cli.Context) error {
+	tokenID, err := tokenIDFromCLI(c)
+
+	if err != nil {
+		return err
+	}
+
+	plasma := eth.CreatePlasmaClientCLI(c)
+	ctx := context.Background()
+
+	sub, err := plasma.WithdrawToken(ctx, tokenID)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = sub.Wait(ctx)
+	return err
+}
+
+func tokenIDFromCLI(c *cli.Context) (uint64, error) {
+	tokenID, err := strconv.ParseUint(c.String("token-id"), 0, 64)
+
+	if err != nil || tokenID == 0 {
+		return 0, fmt.Errorf("invalid token id %q", c.String("token-id"))
+	}
+
+	return tokenID, nil
+}
@@ -0,0 +1,60 @@
+package userclient
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/kyokan/plasma/chain"
+	"github.com/kyokan/plasma/rpc/rpcclient"
+	"github.com/kyokan/plasma/signer"
+	"github.com/kyokan/plasma/util"
+)
+
+// BuildSignedSend fetches the sender's UTXOs from the root node, selects the
+// inputs for a payment and signs the result for domain with the sender's
+// signer.
+func BuildSignedSend(
+	rootClient *rpcclient.Client,
+	to common.Address,
+	amount *big.Int,
+	s signer.Signer,
+	domain chain.Domain,
+) (*chain.Transaction, error) {
+	from := s.Address()
+
+	utxos, err := rootClient.GetUTXOs(util.AddressToHex(&from))
+
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := chain.FindBestUTXOs(to, amount, utxos.Transactions, s, domain)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkSignature(tx, from, domain); err != nil {
+		return nil, err
+	}
+
+	return tx, nil
+}
+
+func checkSignature(tx *chain.Transaction, from common.Address, domain chain.Domain) error {
+	pubKey, err := crypto.SigToPub(tx.SignatureHash(domain), tx.SigAt(0))
+
+	if err != nil {
+		return err
+	}
+
+	signer := crypto.PubkeyToAddress(*pubKey)
+
+	if !util.AddressesEqual(&signer, &from) {
+		return errors.New("transaction was signed by the wrong key")
+	}
+
+	return nil
+}
@@ -0,0 +1,293 @@
+package userclient
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/kyokan/plasma/eth"
+	"github.com/kyokan/plasma/rpc/rpcclient"
+	"github.com/kyokan/plasma/signer"
+	"github.com/kyokan/plasma/util"
+	"github.com/kyokan/plasma/wallet"
+	"github.com/olekukonko/tablewriter"
+	"gopkg.in/urfave/cli.v1"
+)
+
+// usesWallet reports whether a command should operate across the accounts
+// of the wallet instead of the single user-address.
+func usesWallet(c *cli.Context) bool {
+	return c.GlobalString("wallet") != ""
+}
+
+func walletPath(c *cli.Context) (string, error) {
+	path := c.GlobalString("wallet")
+
+	if path == "" {
+		return "", errors.New("wallet is required")
+	}
+
+	return path, nil
+}
+
+func openWallet(c *cli.Context) (*wallet.Wallet, error) {
+	path, err := walletPath(c)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return wallet.Open(path)
+}
+
+func walletPassphrase(c *cli.Context) (string, error) {
+	path := c.GlobalString("wallet-passphrase-file")
+
+	if path == "" {
+		return "", errors.New("wallet-passphrase-file is required")
+	}
+
+	return readSecret(path)
+}
+
+// readSecret reads a file holding a passphrase or mnemonic, or stdin when
+// path is -.
+func readSecret(path string) (string, error) {
+	var data []byte
+	var err error
+
+	if path == "-" {
+		data, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		data, err = ioutil.ReadFile(path)
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(data)), nil
+}
+
+func WalletCreateCLI(c *cli.Context) error {
+	path, err := walletPath(c)
+
+	if err != nil {
+		return err
+	}
+
+	passphrase, err := walletPassphrase(c)
+
+	if err != nil {
+		return err
+	}
+
+	w, mnemonic, err := wallet.Create(path, passphrase)
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Mnemonic: %s\n", mnemonic)
+	fmt.Println("Write the mnemonic down and keep it safe, it is the only way to recover the wallet.")
+	fmt.Printf("Address: %s\n", w.Accounts()[0].Address.Hex())
+	return nil
+}
+
+func WalletImportCLI(c *cli.Context) error {
+	path, err := walletPath(c)
+
+	if err != nil {
+		return err
+	}
+
+	passphrase, err := walletPassphrase(c)
+
+	if err != nil {
+		return err
+	}
+
+	mnemonic, err := readSecret(c.String("mnemonic-file"))
+
+	if err != nil {
+		return err
+	}
+
+	w, err := wallet.Import(path, mnemonic, passphrase)
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Address: %s\n", w.Accounts()[0].Address.Hex())
+	return nil
+}
+
+func WalletListCLI(c *cli.Context) error {
+	w, err := openWallet(c)
+
+	if err != nil {
+		return err
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Index", "Address"})
+
+	for _, account := range w.Accounts() {
+		table.Append([]string{fmt.Sprint(account.Index), account.Address.Hex()})
+	}
+
+	table.Render()
+	return nil
+}
+
+func WalletDeriveCLI(c *cli.Context) error {
+	w, err := openWallet(c)
+
+	if err != nil {
+		return err
+	}
+
+	passphrase, err := walletPassphrase(c)
+
+	if err != nil {
+		return err
+	}
+
+	derived, err := w.Derive(passphrase, c.Int("count"))
+
+	if err != nil {
+		return err
+	}
+
+	for _, account := range derived {
+		fmt.Printf("%d: %s\n", account.Index, account.Address.Hex())
+	}
+
+	return nil
+}
+
+// walletBalance prints the Ethereum and plasma balances of every wallet
+// account, and their totals.
+func walletBalance(c *cli.Context) error {
+	w, err := openWallet(c)
+
+	if err != nil {
+		return err
+	}
+
+	client, err := eth.NewClient(c.GlobalString("node-url"))
+
+	if err != nil {
+		return err
+	}
+
+	rootClient := rpcclient.NewClient(fmt.Sprintf("http://localhost:%d/rpc", c.Int("root-port")))
+	totalEth := big.NewInt(0)
+	totalPlasma := big.NewInt(0)
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Index", "Address", "Ethereum", "Plasma"})
+
+	for _, account := range w.Accounts() {
+		ethBalance, err := client.GetBalance(account.Address)
+
+		if err != nil {
+			return err
+		}
+
+		childBalance, err := plasmaBalance(rootClient, account.Address)
+
+		if err != nil {
+			return err
+		}
+
+		totalEth.Add(totalEth, ethBalance)
+		totalPlasma.Add(totalPlasma, childBalance)
+
+		table.Append([]string{
+			fmt.Sprint(account.Index),
+			account.Address.Hex(),
+			ethBalance.String(),
+			childBalance.String(),
+		})
+	}
+
+	table.SetFooter([]string{"", "Total", totalEth.String(), totalPlasma.String()})
+	table.Render()
+	return nil
+}
+
+// plasmaBalance sums the unspent outputs of address.
+func plasmaBalance(rootClient *rpcclient.Client, address common.Address) (*big.Int, error) {
+	utxos, err := rootClient.GetUTXOs(util.AddressToHex(&address))
+
+	if err != nil {
+		return nil, err
+	}
+
+	balance := big.NewInt(0)
+
+	for _, tx := range utxos.Transactions {
+		balance.Add(balance, tx.OutputFor(&address).Amount)
+	}
+
+	return balance, nil
+}
+
+// walletPayer returns the signer of the first wallet account whose UTXOs
+// add up to amount.
+func walletPayer(c *cli.Context, rootClient *rpcclient.Client, amount *big.Int) (signer.Signer, error) {
+	w, err := openWallet(c)
+
+	if err != nil {
+		return nil, err
+	}
+
+	passphrase, err := walletPassphrase(c)
+
+	if err != nil {
+		return nil, err
+	}
+
+	signers, err := w.Signers(passphrase)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, s := range signers {
+		balance, err := plasmaBalance(rootClient, s.Address())
+
+		if err != nil {
+			return nil, err
+		}
+
+		if balance.Cmp(amount) >= 0 {
+			return s, nil
+		}
+	}
+
+	return nil, errors.New("no wallet account can cover the amount")
+}
+
+// walletSigner returns the signer of the wallet account that owns address.
+func walletSigner(c *cli.Context, address common.Address) (signer.Signer, error) {
+	w, err := openWallet(c)
+
+	if err != nil {
+		return nil, err
+	}
+
+	passphrase, err := walletPassphrase(c)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return w.Signer(passphrase, address)
+}
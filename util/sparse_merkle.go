@@ -0,0 +1,115 @@
+package util
+
+import (
+	"bytes"
+)
+
+// SparseMerkleDepth is the depth of a sparse Merkle tree, which has a leaf
+// for every uint64 key.
+const SparseMerkleDepth = 64
+
+// emptySparseHashes[i] is the hash of an empty subtree of height i.
+var emptySparseHashes = func() []Hash {
+	hashes := make([]Hash, SparseMerkleDepth+1)
+	hashes[0] = make(Hash, 32)
+
+	for i := 1; i <= SparseMerkleDepth; i++ {
+		hashes[i] = DoHash(append(append([]byte(nil), hashes[i-1]...), hashes[i-1]...))
+	}
+
+	return hashes
+}()
+
+// SparseMerkleTree commits to a value for every uint64 key. Keys without a
+// value have an empty leaf of 32 zero bytes, so the tree proves that a key
+// has no value as well as that it has one.
+type SparseMerkleTree struct {
+	// levels[i] holds the non-empty nodes of height i by index.
+	levels []map[uint64]Hash
+}
+
+func NewSparseMerkleTree(leaves map[uint64]Hash) *SparseMerkleTree {
+	levels := make([]map[uint64]Hash, SparseMerkleDepth+1)
+	levels[0] = make(map[uint64]Hash, len(leaves))
+
+	for key, leaf := range leaves {
+		levels[0][key] = leaf
+	}
+
+	for i := 0; i < SparseMerkleDepth; i++ {
+		levels[i+1] = make(map[uint64]Hash)
+
+		for index := range levels[i] {
+			parent := index >> 1
+
+			if _, done := levels[i+1][parent]; done {
+				continue
+			}
+
+			left := nodeAt(levels[i], i, parent<<1)
+			right := nodeAt(levels[i], i, parent<<1|1)
+			levels[i+1][parent] = DoHash(append(append([]byte(nil), left...), right...))
+		}
+	}
+
+	return &SparseMerkleTree{levels: levels}
+}
+
+// EmptySparseMerkleRoot returns the root of a tree without values.
+func EmptySparseMerkleRoot() Hash {
+	return emptySparseHashes[SparseMerkleDepth]
+}
+
+func (t *SparseMerkleTree) Root() Hash {
+	return nodeAt(t.levels[SparseMerkleDepth], SparseMerkleDepth, 0)
+}
+
+// Proof returns the siblings of the path from key's leaf to the root,
+// starting with the leaf's sibling.
+func (t *SparseMerkleTree) Proof(key uint64) []Hash {
+	proof := make([]Hash, SparseMerkleDepth)
+	index := key
+
+	for i := 0; i < SparseMerkleDepth; i++ {
+		proof[i] = nodeAt(t.levels[i], i, index^1)
+		index >>= 1
+	}
+
+	return proof
+}
+
+// VerifySparseMerkleProof checks that key has leaf in the tree with root.
+// A nil leaf checks that key has no value.
+func VerifySparseMerkleProof(root Hash, key uint64, leaf Hash, proof []Hash) bool {
+	if len(proof) != SparseMerkleDepth {
+		return false
+	}
+
+	node := leaf
+
+	if node == nil {
+		node = emptySparseHashes[0]
+	}
+
+	index := key
+
+	for _, sibling := range proof {
+		if index&1 == 0 {
+			node = DoHash(append(append([]byte(nil), node...), sibling...))
+		} else {
+			node = DoHash(append(append([]byte(nil), sibling...), node...))
+		}
+
+		index >>= 1
+	}
+
+	return bytes.Equal(node, root)
+}
+
+func nodeAt(level map[uint64]Hash, height int, index uint64) Hash {
+	if node, ok := level[index]; ok {
+		return node
+	}
+
+	return emptySparseHashes[height]
+}
@@ -1,6 +1,7 @@
 package validator
 
 import (
+	"context"
 	"log"
 	"math/big"
 	"time"
@@ -14,77 +15,88 @@ import (
 	"github.com/kyokan/plasma/eth"
 )
 
-func ExitStartedListener(rootUrl string, level *db.Database, plasma *eth.PlasmaClient) {
+// ExitStartedListener challenges started exits that spend outputs which
+// were spent later on the plasma chain. An exit that cannot be checked is
+// retried before any later exit.
+func ExitStartedListener(rootUrl string, level *db.Database, plasma *eth.PlasmaClient, config eth.WatcherConfig) {
 	rootClient := userclient.NewRootClient(rootUrl)
-	for {
-		idx, err := level.ExitDao.LastExitEventIdx()
 
-		if err != nil && err.Error() != "leveldb: not found" {
-			log.Fatalf("Failed to get last exit event idx: %v", err)
-		}
-
-		log.Printf("Looking for exit events at block number: %d\n", idx)
-
-		events, lastIdx := plasma.ExitStartedFilter(idx)
+	watcher := eth.NewEventWatcher(plasma, "exit", db.ExitCheckpoint(level.ExitDao), config)
+	exits := watcher.StartedExits()
 
-		if len(events) > 0 {
-			count := uint64(0)
-
-			for _, event := range events {
-				count += 1
+	go func() {
+		if err := watcher.Start(context.Background()); err != nil {
+			log.Fatalf("Failed to watch exits: %v", err)
+		}
+	}()
 
-				exitId := event.ExitId
+	for event := range exits {
+		log.Printf("Found exit %s in block %d.\n", event.ExitId, event.Raw.BlockNumber)
 
-				exit := plasma.GetExit(exitId)
+		for {
+			err := checkExit(rootClient, level, plasma, event.ExitId)
 
-				txs, blockId, txId := FindDoubleSpend(rootClient, level, plasma, exit)
+			if err == nil {
+				break
+			}
 
-				if txs != nil && txId != nil {
-					plasma.ChallengeExit(
-						exitId,
-						txs,
-						blockId,
-						txId,
-					)
+			log.Printf("Failed to check exit %s, retrying: %v", event.ExitId, err)
+			time.Sleep(10 * time.Second)
+		}
 
-					time.Sleep(3 * time.Second)
+		// TODO: also if someone exits on the plasma chain you need to
+		// make sure you exit it from the root node.
+		// So the root node also needs an exit listener.
 
-					events, _ := plasma.ChallengeSuccessFilter(0)
+		// There's a race condition where someone could try to spend
+		// while an exit is happenning
 
-					for _, event := range events {
-						log.Printf("challenge success: %v", event.ExitId)
-					}
+		// This sort of implies that you should be validating exits
+		// often, not just on notification.
+	}
+}
 
-					events2, _ := plasma.ChallengeFailureFilter(0)
+func checkExit(rootClient userclient.RootClient, level *db.Database, plasma *eth.PlasmaClient, exitId *big.Int) error {
+	exit, err := plasma.GetExit(context.Background(), exitId)
 
-					for _, event := range events2 {
-						log.Printf("challenge failure: %v", event.ExitId)
-					}
-				}
+	if eth.IsNotFound(err) {
+		// Finalized exits are removed from the contract.
+		return nil
+	}
 
-				// TODO: also if someone exits on the plasma chain you need to
-				// make sure you exit it from the root node.
-				// So the root node also needs an exit listener.
+	if err != nil {
+		return err
+	}
 
-				// There's a race condition where someone could try to spend
-				// while an exit is happenning
+	txs, blockId, txId := FindDoubleSpend(rootClient, level, plasma, exit)
 
-				// This sort of implies that you should be validating exits
-				// often, not just on notification.
+	if txs == nil || txId == nil {
+		return nil
+	}
 
-				// It's not synchronized right now...
-				time.Sleep(time.Second * 3)
-			}
+	challenge, err := plasma.ChallengeExit(
+		context.Background(),
+		exitId,
+		txs,
+		blockId,
+		txId,
+	)
 
-			log.Printf("Found %d exit events at from blocks %d to %d.\n", count, idx, lastIdx)
+	if err != nil {
+		return err
+	}
 
-			level.ExitDao.SaveExitEventIdx(lastIdx + 1)
-		} else {
-			log.Printf("No exit events at block %d.\n", idx)
-		}
+	_, err = challenge.Wait(context.Background())
 
-		time.Sleep(time.Second * 10)
+	if eth.IsReverted(err) {
+		log.Printf("challenge failure: %v", exitId)
+	} else if err != nil {
+		log.Printf("Failed to challenge exit %s: %v", exitId, err)
+	} else {
+		log.Printf("challenge success: %v", exitId)
 	}
+
+	return nil
 }
 
 func FindDoubleSpend(rootClient userclient.RootClient, level *db.Database, plasma *eth.PlasmaClient, exit eth.Exit) ([]chain.Transaction, *big.Int, *big.Int) {
@@ -96,7 +108,8 @@ func FindDoubleSpend(rootClient userclient.RootClient, level *db.Database, plasm
 
 	txIdx := exit.TxIndex.Uint64()
 	lastBlockHeight := latestBlock.Header.Number
-	currBlockHeight := exit.BlockNum.Uint64() + 1
+	// Deposit blocks spend nothing.
+	currBlockHeight := chain.NextChildBlock(exit.BlockNum.Uint64())
 
 	response := rootClient.GetBlock(exit.BlockNum.Uint64())
 
@@ -112,7 +125,7 @@ func FindDoubleSpend(rootClient userclient.RootClient, level *db.Database, plasm
 	// TODO: actually in theory it should never happen in the current block.
 	// Because root node will never create and submit that block.
 	// Also, how do you protect against exits happenning more than once?
-	for i := currBlockHeight; i <= lastBlockHeight; i++ {
+	for i := currBlockHeight; i <= lastBlockHeight; i += chain.ChildBlockInterval {
 		response := rootClient.GetBlock(i)
 		currTxs := response.Transactions
 		rej := node.FindMatchingInputs(&exitTx, currTxs)
@@ -77,7 +77,7 @@ func (f validator_fixture) GetBlock(height uint64) *plasma_rpc.GetBlocksResponse
 }
 
 func (f validator_fixture) GetLatest() (*chain.Block, error) {
-    response := f.GetBlock(1000)
+    response := f.GetBlock(10000)
     return response.Block, nil
 }
 
@@ -86,7 +86,7 @@ func Test_FindDoubleSpend(t *testing.T) {
     err := test_util.LoadFixture(t, &fixture)
     require.NoError(t, err)
     blockDao := new(dbMocks.BlockDao)
-    blockDao.On("Latest").Return(fixture.GetBlock(1000).Block, nil)
+    blockDao.On("Latest").Return(fixture.GetBlock(10000).Block, nil)
     db := mockDB(nil, blockDao, nil, nil, nil, nil, nil)
     rootClient := new(clientMocks.RootClient)
     getBlock := func(height uint64) *plasma_rpc.GetBlocksResponse {
@@ -2,28 +2,23 @@ package validator
 
 import (
 	"bytes"
+	"context"
 	"encoding/hex"
 	"fmt"
 	"log"
 	"time"
 
-	encoding_json "encoding/json"
-
 	"github.com/kyokan/plasma/chain"
 	"github.com/kyokan/plasma/db"
 	"github.com/kyokan/plasma/eth"
+	"github.com/kyokan/plasma/metrics"
+	"github.com/kyokan/plasma/node"
+	"github.com/kyokan/plasma/txtype"
 	"github.com/kyokan/plasma/userclient"
 	"github.com/kyokan/plasma/util"
 )
 
-// clientResponse represents a JSON-RPC response returned to a client.
-type ClientResponse struct {
-	Result *encoding_json.RawMessage `json:"result"`
-	Error  interface{}               `json:"error"`
-	Id     uint64                    `json:"id"`
-}
-
-func RootNodeListener(rootUrl string, level *db.Database, plasma *eth.PlasmaClient, userAddress string) {
+func RootNodeListener(rootUrl string, level *db.Database, plasma *eth.PlasmaClient, userAddress string, registry *txtype.Registry, domain chain.Domain) {
 	rootClient := userclient.NewRootClient(rootUrl)
 	for {
 		log.Println("Watching root node...")
@@ -34,12 +29,19 @@ func RootNodeListener(rootUrl string, level *db.Database, plasma *eth.PlasmaClie
 			log.Fatalf("Failed to get latest block: %v", err)
 		}
 
-		var blockNum uint64
+		var prevNum uint64
 
-		if block == nil { // first block in the plasma chain is genesis
-			blockNum = 1
-		} else {
-			blockNum = block.Header.Number + 1
+		if block != nil {
+			prevNum = block.Header.Number
+		}
+
+		// Without blocks, this is genesis, the contract's first child block.
+		blockNum := prevNum + chain.ChildBlockInterval
+
+		metrics.BlockHeight.Set(float64(prevNum))
+
+		if curr, err := plasma.CurrentChildBlock(context.Background()); err == nil {
+			metrics.ContractBlockHeight.Set(float64(curr.Uint64() - chain.ChildBlockInterval))
 		}
 
 		log.Printf("Looking for block number: %d\n", blockNum)
@@ -50,11 +52,47 @@ func RootNodeListener(rootUrl string, level *db.Database, plasma *eth.PlasmaClie
 			log.Printf("Found block number: %d\n", blockNum)
 			plasmaBlock := response.Block
 
-			// Block number for the contract is off by one
-			contractBlock := plasma.GetBlock(util.NewUint64(blockNum))
+			// The root node numbers blocks after the contract's child blocks
+			contractBlock, err := plasma.GetBlock(context.Background(), util.NewUint64(blockNum))
+
+			if eth.IsNotFound(err) {
+				// The block has not been committed yet, check again later.
+				log.Printf("Block %d is not on the plasma contract yet.\n", blockNum)
+				time.Sleep(10 * time.Second)
+				continue
+			}
+
+			if err != nil {
+				log.Printf("Failed to get block %d from the plasma contract: %v", blockNum, err)
+				time.Sleep(10 * time.Second)
+				continue
+			}
+
+			// The block may spend the deposits numbered before it.
+			if err := syncDeposits(rootClient, level, plasma, registry, domain, prevNum, blockNum); err != nil {
+				log.Printf("Failed to sync the deposit blocks before block %d: %v", blockNum, err)
+				time.Sleep(10 * time.Second)
+				continue
+			}
+
+			valid := IsValidBlock(plasmaBlock, contractBlock)
 
-			if IsValidBlock(plasmaBlock, contractBlock) {
+			if valid {
+				err := CheckTokenRoot(plasmaBlock.Header, response.Transactions)
+
+				if err == nil {
+					err = CheckTransactions(level, registry, domain, blockNum, response.Transactions)
+				}
+
+				if err != nil {
+					log.Printf("Block %d has invalid transactions: %v", blockNum, err)
+					valid = false
+				}
+			}
+
+			if valid {
 				log.Println("Block is valid, saving locally.")
+				level.TxDao.SaveMany(response.Transactions)
 				level.BlockDao.Save(response.Block)
 			} else {
 				_, err := level.InvalidBlockDao.Get(response.Block.BlockHash)
@@ -78,6 +116,100 @@ func RootNodeListener(rootUrl string, level *db.Database, plasma *eth.PlasmaClie
 	}
 }
 
+// syncDeposits saves the deposit blocks numbered between the submitted
+// blocks from and to. Once to is on the contract, no more deposits are
+// numbered before it.
+func syncDeposits(rootClient userclient.RootClient, level *db.Database, plasma *eth.PlasmaClient, registry *txtype.Registry, domain chain.Domain, from, to uint64) error {
+	for blkNum := from + 1; blkNum < to; blkNum++ {
+		if _, err := level.BlockDao.BlockAtHeight(blkNum); err == nil {
+			continue
+		}
+
+		contractBlock, err := plasma.GetBlock(context.Background(), util.NewUint64(blkNum))
+
+		// Deposit blocks are numbered one after the other.
+		if eth.IsNotFound(err) {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		response := rootClient.GetBlock(blkNum)
+
+		if response == nil {
+			return fmt.Errorf("deposit block %d is not on the root node yet", blkNum)
+		}
+
+		if !IsValidBlock(response.Block, contractBlock) {
+			return fmt.Errorf("deposit block %d does not match the plasma contract", blkNum)
+		}
+
+		if err := CheckTokenRoot(response.Block.Header, response.Transactions); err != nil {
+			return fmt.Errorf("deposit block %d: %v", blkNum, err)
+		}
+
+		if err := CheckTransactions(level, registry, domain, blkNum, response.Transactions); err != nil {
+			return fmt.Errorf("deposit block %d: %v", blkNum, err)
+		}
+
+		level.TxDao.SaveMany(response.Transactions)
+		level.BlockDao.Save(response.Block)
+	}
+
+	return nil
+}
+
+// CheckTokenRoot recomputes the token root of a block from its
+// transactions.
+func CheckTokenRoot(header *chain.BlockHeader, txs []chain.Transaction) error {
+	if root := chain.TokenRoot(txs); !bytes.Equal(root, header.TokenRoot) {
+		return fmt.Errorf("token root is 0x%x, not 0x%x", header.TokenRoot, root)
+	}
+
+	return nil
+}
+
+// CheckTransactions repeats what the root node did with the transactions of
+// block blkNum: it validates each of them as its type against the
+// transactions saved before, and transitions the block's state by them.
+func CheckTransactions(level *db.Database, registry *txtype.Registry, domain chain.Domain, blkNum uint64, txs []chain.Transaction) error {
+	for i := range txs {
+		tx := &txs[i]
+
+		// The plasma contract checks deposits, and the genesis block holds
+		// a zero transaction.
+		if tx.IsDeposit() || tx.IsZeroTransaction() {
+			continue
+		}
+
+		spends, err := node.FindSpends(level.TxDao, tx)
+
+		if err != nil {
+			return fmt.Errorf("transaction %d: %v", i, err)
+		}
+
+		// The root node accepted the transaction while the block before
+		// was the latest.
+		ctx := &txtype.Context{
+			Height: blkNum - chain.ChildBlockInterval,
+			Domain: domain,
+			Spends: spends,
+		}
+
+		if err := registry.Validate(ctx, tx); err != nil {
+			return fmt.Errorf("transaction %d: %v", i, err)
+		}
+	}
+
+	if _, rejected := registry.ApplyBlock(blkNum, txs); len(rejected) > 0 {
+		return fmt.Errorf("%d transactions fail their state transition", len(rejected))
+	}
+
+	return nil
+}
+
 func ExitUTXOs(rootUrl string, plasma *eth.PlasmaClient, userAddress string) {
 	rootClient := userclient.NewRootClient(rootUrl)
 	res := rootClient.GetUTXOs(userAddress)
@@ -101,11 +233,13 @@ func ExitUTXOs(rootUrl string, plasma *eth.PlasmaClient, userAddress string) {
 		// Collect a list of outputs because technically both can belong to the user.
 		var outputIdxs []int
 
-		if tx.Output0.NewOwner.String() == userAddress {
-			outputIdxs = append(outputIdxs, 0)
-		} else if tx.Output1.NewOwner.String() == userAddress {
-			outputIdxs = append(outputIdxs, 1)
-		} else {
+		for i, output := range tx.Outputs {
+			if output.NewOwner.String() == userAddress {
+				outputIdxs = append(outputIdxs, i)
+			}
+		}
+
+		if len(outputIdxs) == 0 {
 			log.Fatalf("Transaction must have at least one output that belongs to address: %s\n", userAddress)
 		}
 
@@ -129,7 +263,8 @@ func ExitUTXOs(rootUrl string, plasma *eth.PlasmaClient, userAddress string) {
 		for _, utxo := range utxos {
 			log.Printf("Exiting block: %d, tx: %d, output: %d\n", utxo.BlkNum, utxo.TxIdx, utxo.OutputIdx)
 
-			plasma.StartExit(
+			_, err := plasma.StartExit(
+				context.Background(),
 				res2.Block,
 				res2.Transactions,
 				util.NewUint64(utxo.BlkNum),
@@ -137,14 +272,32 @@ func ExitUTXOs(rootUrl string, plasma *eth.PlasmaClient, userAddress string) {
 				util.NewInt(utxo.OutputIdx),
 			)
 
+			if err != nil {
+				log.Printf("Failed to exit block: %d, tx: %d, output: %d: %v\n", utxo.BlkNum, utxo.TxIdx, utxo.OutputIdx, err)
+				continue
+			}
+
 			time.Sleep(3 * time.Second)
 		}
 	}
 }
 
+// IsValidBlock checks the roots of block against the plasma contract. The
+// contract has no token roots for deposit blocks.
 func IsValidBlock(block *chain.Block, plasmaBlock eth.Block) bool {
 	fmt.Println(block.Header.Number)
 	fmt.Println(hex.EncodeToString(block.Header.RLPMerkleRoot))
 	fmt.Println(hex.EncodeToString(plasmaBlock.Root))
-	return bytes.Equal(block.Header.RLPMerkleRoot, plasmaBlock.Root)
+
+	if !bytes.Equal(block.Header.RLPMerkleRoot, plasmaBlock.Root) {
+		return false
+	}
+
+	if chain.IsDepositBlock(block.Header.Number) {
+		return true
+	}
+
+	var tokenRoot [32]byte
+	copy(tokenRoot[:], block.Header.TokenRoot)
+	return bytes.Equal(tokenRoot[:], plasmaBlock.TokenRoot)
 }
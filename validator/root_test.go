@@ -0,0 +1,48 @@
+package validator
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/kyokan/plasma/chain"
+	"github.com/kyokan/plasma/eth"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_TokenRootIsChecked(t *testing.T) {
+	tokenID := chain.TokenIDFor(common.Address{0xee}, big.NewInt(7))
+	send := *chain.NewTransaction(
+		[]*chain.Input{chain.NewInput(1, 0, 0)},
+		[]*chain.Output{chain.NewTokenOutput(common.Address{2}, tokenID)},
+		big.NewInt(0),
+	)
+	send.BlkNum = 2 * chain.ChildBlockInterval
+
+	header := &chain.BlockHeader{
+		RLPMerkleRoot: make([]byte, 32),
+		Number:        send.BlkNum,
+		TokenRoot:     chain.TokenRoot([]chain.Transaction{send}),
+	}
+	block := &chain.Block{Header: header}
+
+	// The transactions reach the validator as JSON.
+	enc, err := json.Marshal([]chain.Transaction{send})
+	require.NoError(t, err)
+	var txs []chain.Transaction
+	require.NoError(t, json.Unmarshal(enc, &txs))
+	require.NoError(t, CheckTokenRoot(header, txs))
+
+	contractBlock := eth.Block{Root: header.RLPMerkleRoot, StartedAt: big.NewInt(1), TokenRoot: header.TokenRoot}
+	require.True(t, IsValidBlock(block, contractBlock))
+
+	// The contract commits to a different token tree.
+	contractBlock.TokenRoot = make([]byte, 32)
+	require.False(t, IsValidBlock(block, contractBlock))
+
+	// The header commits to a token tree the transactions do not make.
+	header.TokenRoot = nil
+	require.Error(t, CheckTokenRoot(header, txs))
+	require.True(t, IsValidBlock(block, contractBlock))
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/kyokan/plasma/db"
 	"github.com/kyokan/plasma/eth"
+	"github.com/kyokan/plasma/txtype"
 	"gopkg.in/urfave/cli.v1"
 )
 
@@ -29,9 +30,19 @@ func Start(c *cli.Context) {
 
 	defer db.Close()
 
-	go RootNodeListener(rootUrl, level, plasma, userAddress)
+	if _, err := plasma.ResumeSubmissions(level.SubmissionDao); err != nil {
+		log.Fatalf("Failed to resume Ethereum submissions: %v", err)
+	}
+
+	domain, err := eth.DomainFromCLI(c)
+
+	if err != nil {
+		log.Fatalf("Failed to read the signing domain: %v", err)
+	}
+
+	go RootNodeListener(rootUrl, level, plasma, userAddress, txtype.Default(), domain)
 
-	go ExitStartedListener(rootUrl, level, plasma)
+	go ExitStartedListener(rootUrl, level, plasma, eth.WatcherConfigFromCLI(c))
 
 	go Run(validatorPort)
 
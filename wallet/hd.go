@@ -0,0 +1,103 @@
+package wallet
+
+import (
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// hardened is the first hardened BIP-32 child index.
+const hardened = 0x80000000
+
+var errInvalidChild = errors.New("invalid child key, use the next index")
+
+// extendedKey is a BIP-32 extended private key.
+type extendedKey struct {
+	key       *big.Int
+	chainCode []byte
+}
+
+func newMasterKey(seed []byte) (*extendedKey, error) {
+	mac := hmac.New(sha512.New, []byte("Bitcoin seed"))
+	mac.Write(seed)
+	sum := mac.Sum(nil)
+
+	key := new(big.Int).SetBytes(sum[:32])
+
+	if key.Sign() == 0 || key.Cmp(crypto.S256().Params().N) >= 0 {
+		return nil, errors.New("invalid master key")
+	}
+
+	return &extendedKey{key: key, chainCode: sum[32:]}, nil
+}
+
+// child derives the private child key at index.
+func (k *extendedKey) child(index uint32) (*extendedKey, error) {
+	var data []byte
+
+	if index >= hardened {
+		data = append([]byte{0}, math.PaddedBigBytes(k.key, 32)...)
+	} else {
+		priv, err := k.privateKey()
+
+		if err != nil {
+			return nil, err
+		}
+
+		data = crypto.CompressPubkey(&priv.PublicKey)
+	}
+
+	var indexBytes [4]byte
+	binary.BigEndian.PutUint32(indexBytes[:], index)
+	data = append(data, indexBytes[:]...)
+
+	mac := hmac.New(sha512.New, k.chainCode)
+	mac.Write(data)
+	sum := mac.Sum(nil)
+
+	n := crypto.S256().Params().N
+	tweak := new(big.Int).SetBytes(sum[:32])
+
+	if tweak.Cmp(n) >= 0 {
+		return nil, errInvalidChild
+	}
+
+	key := tweak.Add(tweak, k.key)
+	key.Mod(key, n)
+
+	if key.Sign() == 0 {
+		return nil, errInvalidChild
+	}
+
+	return &extendedKey{key: key, chainCode: sum[32:]}, nil
+}
+
+func (k *extendedKey) privateKey() (*ecdsa.PrivateKey, error) {
+	return crypto.ToECDSA(math.PaddedBigBytes(k.key, 32))
+}
+
+// deriveKey derives the private key at path from a BIP-39 seed.
+func deriveKey(seed []byte, path accounts.DerivationPath) (*ecdsa.PrivateKey, error) {
+	key, err := newMasterKey(seed)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, index := range path {
+		key, err = key.child(index)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return key.privateKey()
+}
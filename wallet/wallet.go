@@ -0,0 +1,333 @@
+// Package wallet implements a hierarchical deterministic wallet: accounts
+// are derived from a BIP-39 mnemonic along the BIP-44 Ethereum path, and the
+// mnemonic is stored encrypted on disk.
+package wallet
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/kyokan/plasma/signer"
+	"github.com/tyler-smith/go-bip39"
+	"golang.org/x/crypto/scrypt"
+)
+
+const version = 1
+
+var (
+	ErrExists          = errors.New("wallet already exists")
+	ErrDecrypt         = errors.New("could not decrypt wallet, wrong passphrase?")
+	ErrInvalidMnemonic = errors.New("invalid mnemonic")
+	ErrUnknownAddress  = errors.New("address is not in the wallet")
+)
+
+// The scrypt parameters of new wallets. Tests lower them.
+var (
+	scryptN = keystore.StandardScryptN
+	scryptP = keystore.StandardScryptP
+)
+
+// Account is an address derived at Index under the wallet's base path.
+type Account struct {
+	Index   uint32         `json:"index"`
+	Address common.Address `json:"address"`
+}
+
+type encryptedMnemonic struct {
+	N          int           `json:"n"`
+	R          int           `json:"r"`
+	P          int           `json:"p"`
+	Salt       hexutil.Bytes `json:"salt"`
+	Nonce      hexutil.Bytes `json:"nonce"`
+	Ciphertext hexutil.Bytes `json:"ciphertext"`
+}
+
+type walletFile struct {
+	Version  int               `json:"version"`
+	BasePath string            `json:"basePath"`
+	Mnemonic encryptedMnemonic `json:"mnemonic"`
+	Accounts []Account         `json:"accounts"`
+}
+
+// Wallet is a wallet file. Its addresses can be listed without the
+// passphrase, which is only needed to derive keys.
+type Wallet struct {
+	path string
+	file walletFile
+}
+
+// Create creates a wallet at path for a new mnemonic, and returns the
+// mnemonic so that it can be written down.
+func Create(path string, passphrase string) (*Wallet, string, error) {
+	entropy, err := bip39.NewEntropy(256)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	mnemonic, err := bip39.NewMnemonic(entropy)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	w, err := Import(path, mnemonic, passphrase)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	return w, mnemonic, nil
+}
+
+// Import creates a wallet at path for an existing mnemonic. The account at
+// index 0 is derived.
+func Import(path string, mnemonic string, passphrase string) (*Wallet, error) {
+	if _, err := os.Stat(path); err == nil {
+		return nil, ErrExists
+	}
+
+	if !bip39.IsMnemonicValid(mnemonic) {
+		return nil, ErrInvalidMnemonic
+	}
+
+	encrypted, err := encrypt([]byte(mnemonic), passphrase)
+
+	if err != nil {
+		return nil, err
+	}
+
+	w := &Wallet{
+		path: path,
+		file: walletFile{
+			Version:  version,
+			BasePath: accounts.DefaultRootDerivationPath.String(),
+			Mnemonic: *encrypted,
+		},
+	}
+
+	if _, err := w.Derive(passphrase, 1); err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+// Open reads the wallet at path.
+func Open(path string) (*Wallet, error) {
+	data, err := ioutil.ReadFile(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	w := &Wallet{path: path}
+
+	if err := json.Unmarshal(data, &w.file); err != nil {
+		return nil, fmt.Errorf("failed to parse wallet %s: %v", path, err)
+	}
+
+	if w.file.Version != version {
+		return nil, fmt.Errorf("unsupported wallet version %d", w.file.Version)
+	}
+
+	return w, nil
+}
+
+// Accounts returns the derived accounts in the order they were derived.
+func (w *Wallet) Accounts() []Account {
+	return append([]Account(nil), w.file.Accounts...)
+}
+
+// Derive derives the next count accounts and saves them to the wallet file.
+func (w *Wallet) Derive(passphrase string, count int) ([]Account, error) {
+	seed, err := w.seed(passphrase)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var derived []Account
+
+	for i := 0; i < count; i++ {
+		index := uint32(len(w.file.Accounts))
+		key, err := w.deriveKey(seed, index)
+
+		if err != nil {
+			return nil, err
+		}
+
+		account := Account{Index: index, Address: crypto.PubkeyToAddress(key.PublicKey)}
+		w.file.Accounts = append(w.file.Accounts, account)
+		derived = append(derived, account)
+	}
+
+	if err := w.save(); err != nil {
+		return nil, err
+	}
+
+	return derived, nil
+}
+
+// Signers returns a signer for each derived account, in the order of
+// Accounts.
+func (w *Wallet) Signers(passphrase string) ([]signer.Signer, error) {
+	seed, err := w.seed(passphrase)
+
+	if err != nil {
+		return nil, err
+	}
+
+	signers := make([]signer.Signer, len(w.file.Accounts))
+
+	for i, account := range w.file.Accounts {
+		key, err := w.deriveKey(seed, account.Index)
+
+		if err != nil {
+			return nil, err
+		}
+
+		signers[i] = signer.NewMemorySigner(key)
+	}
+
+	return signers, nil
+}
+
+// Signer returns the signer of a derived account.
+func (w *Wallet) Signer(passphrase string, address common.Address) (signer.Signer, error) {
+	for _, account := range w.file.Accounts {
+		if account.Address != address {
+			continue
+		}
+
+		seed, err := w.seed(passphrase)
+
+		if err != nil {
+			return nil, err
+		}
+
+		key, err := w.deriveKey(seed, account.Index)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return signer.NewMemorySigner(key), nil
+	}
+
+	return nil, ErrUnknownAddress
+}
+
+func (w *Wallet) seed(passphrase string) ([]byte, error) {
+	mnemonic, err := decrypt(&w.file.Mnemonic, passphrase)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return bip39.NewSeedWithErrorChecking(string(mnemonic), "")
+}
+
+func (w *Wallet) deriveKey(seed []byte, index uint32) (*ecdsa.PrivateKey, error) {
+	path, err := accounts.ParseDerivationPath(w.file.BasePath)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return deriveKey(seed, append(path, index))
+}
+
+// save writes the wallet file atomically, readable only by its owner.
+func (w *Wallet) save() error {
+	data, err := json.MarshalIndent(&w.file, "", "  ")
+
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(w.path), 0700); err != nil {
+		return err
+	}
+
+	tmp := w.path + ".tmp"
+
+	if err := ioutil.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, w.path)
+}
+
+func encrypt(plaintext []byte, passphrase string) (*encryptedMnemonic, error) {
+	params := &encryptedMnemonic{
+		N:    scryptN,
+		R:    8,
+		P:    scryptP,
+		Salt: make([]byte, 32),
+	}
+
+	if _, err := rand.Read(params.Salt); err != nil {
+		return nil, err
+	}
+
+	gcm, err := newGCM(params, passphrase)
+
+	if err != nil {
+		return nil, err
+	}
+
+	params.Nonce = make([]byte, gcm.NonceSize())
+
+	if _, err := rand.Read(params.Nonce); err != nil {
+		return nil, err
+	}
+
+	params.Ciphertext = gcm.Seal(nil, params.Nonce, plaintext, nil)
+	return params, nil
+}
+
+func decrypt(params *encryptedMnemonic, passphrase string) ([]byte, error) {
+	gcm, err := newGCM(params, passphrase)
+
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := gcm.Open(nil, params.Nonce, params.Ciphertext, nil)
+
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+
+	return plaintext, nil
+}
+
+func newGCM(params *encryptedMnemonic, passphrase string) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(passphrase), params.Salt, params.N, params.R, params.P, 32)
+
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,65 @@
+package wallet
+
+import (
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+// The mnemonic ganache and truffle develop derive their accounts from.
+const ganacheMnemonic = "candy maple cake sugar pudding cream honey rich smooth crumble sweet treat"
+
+func init() {
+	scryptN = keystore.LightScryptN
+	scryptP = keystore.LightScryptP
+}
+
+func Test_DeriveKeyBIP32Vector(t *testing.T) {
+	seed, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+
+	key, err := deriveKey(seed, accounts.DerivationPath{})
+	require.NoError(t, err)
+	require.Equal(t, "e8f32e723decf4051aefac8e2c93c9c5b214313817cdb01a1494b917c8436b35", hex.EncodeToString(crypto.FromECDSA(key)))
+
+	key, err = deriveKey(seed, accounts.DerivationPath{hardened, 1})
+	require.NoError(t, err)
+	require.Equal(t, "3c6cb8d0f6a264c91ea8b5030fadaa8e538b020f0a387421a12de9319dc93368", hex.EncodeToString(crypto.FromECDSA(key)))
+}
+
+func Test_ImportDeriveAndOpen(t *testing.T) {
+	dir, err := ioutil.TempDir("", "plasma-wallet")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "wallet.json")
+
+	w, err := Import(path, ganacheMnemonic, "secret")
+	require.NoError(t, err)
+	require.Equal(t, common.HexToAddress("0x627306090abab3a6e1400e9345bc60c78a8bef57"), w.Accounts()[0].Address)
+
+	_, err = Import(path, ganacheMnemonic, "secret")
+	require.Equal(t, ErrExists, err)
+
+	derived, err := w.Derive("secret", 1)
+	require.NoError(t, err)
+	require.Equal(t, common.HexToAddress("0xf17f52151ebef6c7334fad080c5704d77216b732"), derived[0].Address)
+
+	_, err = w.Derive("wrong", 1)
+	require.Equal(t, ErrDecrypt, err)
+
+	w, err = Open(path)
+	require.NoError(t, err)
+	require.Len(t, w.Accounts(), 2)
+
+	s, err := w.Signer("secret", derived[0].Address)
+	require.NoError(t, err)
+	require.Equal(t, derived[0].Address, s.Address())
+}